@@ -1,6 +1,6 @@
 module github.com/IBM-tfproviders/terraform-provider-nsxv
 
-go 1.17
+go 1.18
 
 require (
         github.com/IBM-tfproviders/govnsx v1.0.2
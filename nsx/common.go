@@ -2,13 +2,13 @@ package nsx
 
 import (
 	"bytes"
-	"encoding/binary"
 	"fmt"
 	"github.com/IBM-tfproviders/govnsx"
 	"github.com/IBM-tfproviders/govnsx/nsxresource"
+	"math/big"
 	"net"
 	"regexp"
-	"strconv"
+	"sort"
 	"strings"
 )
 
@@ -37,97 +37,344 @@ func validateIP(v interface{}, k string) (ws []string, errors []error) {
 
 	if allowedIP := net.ParseIP(ip); allowedIP == nil {
 		errors = append(errors, fmt.Errorf(
-			"%s: IP '%d' is not valid.", k, ip))
+			"%s: IP '%s' is not valid.", k, ip))
 	}
 	return
 }
 
-func validateIPRange(v interface{}) error {
+// parseNetmask returns the IPv4 netmask in dotted decimal notation mask if
+// it is contiguous.
+func parseNetmask(mask string) (net.IPMask, error) {
 
-	ipRange := v.(string)
+	ip := net.ParseIP(mask).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("Netmask '%s' is not a valid IPv4 netmask.", mask)
+	}
 
-	match, _ := regexp.MatchString("^(\\d+).(\\d+).(\\d+).(\\d+)-(\\d+).(\\d+).(\\d+).(\\d+)$",
-		strings.TrimSpace(ipRange))
+	ipMask := net.IPMask(ip)
+	if ones, bits := ipMask.Size(); bits == 0 || ones == 0 {
+		return nil, fmt.Errorf("Netmask '%s' is not contiguous.", mask)
+	}
 
-	if match {
-		ip := strings.Split(strings.TrimSpace(ipRange), "-")
+	return ipMask, nil
+}
 
-		// Validate start ip
-		startIP := net.ParseIP(strings.TrimSpace(ip[0]))
-		if startIP == nil {
-			return fmt.Errorf("Start IP '%s' is not valid in range '%s'.",
-				ip[0], ipRange)
-		}
+func validateNetmask(v interface{}, k string) (ws []string, errors []error) {
 
-		// Validate end ip
-		endIP := net.ParseIP(strings.TrimSpace(ip[1]))
-		if endIP == nil {
-			return fmt.Errorf("End IP '%s' is not valid in range '%s'.",
-				ip[1], ipRange)
-		}
+	if _, err := parseNetmask(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%s: %s", k, err))
+	}
+	return
+}
 
-		// Validate the range of the start and end ip
-		if bytes.Compare(startIP, endIP) >= 0 {
-			return fmt.Errorf(
-				"Start IP '%s' needs to be smaller than End IP '%s' in the range %s.",
-				startIP, endIP, ipRange)
-		}
+// validateHostAddress checks ip is a usable host address of the subnet
+// defined by mask, i.e. neither its network nor its broadcast address.
+func validateHostAddress(ip string, mask string) (*net.IPNet, error) {
 
-	} else {
+	hostIP := net.ParseIP(ip).To4()
+	if hostIP == nil {
+		return nil, fmt.Errorf("IP '%s' is not a valid IPv4 address.", ip)
+	}
+
+	ipMask, err := parseNetmask(mask)
+	if err != nil {
+		return nil, err
+	}
+
+	subnet := &net.IPNet{IP: hostIP.Mask(ipMask), Mask: ipMask}
+
+	// /31 and /32 subnets have no network and broadcast addresses.
+	if ones, _ := ipMask.Size(); ones >= 31 {
+		return subnet, nil
+	}
+
+	broadcast := lastIP(subnet)
+	if hostIP.Equal(subnet.IP) {
+		return nil, fmt.Errorf("IP '%s' is the network address of subnet '%s'.",
+			ip, subnet)
+	}
+	if hostIP.Equal(broadcast) {
+		return nil, fmt.Errorf("IP '%s' is the broadcast address of subnet '%s'.",
+			ip, subnet)
+	}
+
+	return subnet, nil
+}
+
+func validateVlanId(v interface{}, k string) (ws []string, errors []error) {
+
+	vlan := v.(int)
+
+	if vlan < 0 || vlan > 4094 {
+		errors = append(errors, fmt.Errorf(
+			"%s: VLAN '%d' is not valid, it must be between 0 and 4094.", k, vlan))
+	}
+	return
+}
+
+func validateNonNegativeInt(v interface{}, k string) (ws []string, errors []error) {
+
+	value := v.(int)
+
+	if value < 0 {
+		errors = append(errors, fmt.Errorf(
+			"%s: '%d' is not valid, it must not be negative.", k, value))
+	}
+	return
+}
+
+func validateMacAddress(v interface{}, k string) (ws []string, errors []error) {
+
+	mac := v.(string)
+
+	// NSX only accepts the colon separated EUI-48 notation.
+	match, _ := regexp.MatchString("^([0-9A-Fa-f]{2}:){5}[0-9A-Fa-f]{2}$", mac)
+
+	if !match {
+		errors = append(errors, fmt.Errorf(
+			"%s: MAC address '%s' is not valid.", k, mac))
+	}
+	return
+}
+
+func validateIPRange(v interface{}) error {
+
+	ipRange := v.(string)
+
+	ip := strings.Split(strings.TrimSpace(ipRange), "-")
+	if len(ip) != 2 {
 		return fmt.Errorf("IP range '%s' is not valid.",
 			ipRange)
 	}
 
+	// Validate start ip
+	startIP := net.ParseIP(strings.TrimSpace(ip[0]))
+	if startIP == nil {
+		return fmt.Errorf("Start IP '%s' is not valid in range '%s'.",
+			ip[0], ipRange)
+	}
+
+	// Validate end ip
+	endIP := net.ParseIP(strings.TrimSpace(ip[1]))
+	if endIP == nil {
+		return fmt.Errorf("End IP '%s' is not valid in range '%s'.",
+			ip[1], ipRange)
+	}
+
+	if isIPv4(startIP) != isIPv4(endIP) {
+		return fmt.Errorf("Start IP '%s' and End IP '%s' are not of the same "+
+			"IP version in range '%s'.", startIP, endIP, ipRange)
+	}
+
+	// Validate the range of the start and end ip
+	if compareIP(startIP, endIP) >= 0 {
+		return fmt.Errorf(
+			"Start IP '%s' needs to be smaller than End IP '%s' in the range %s.",
+			startIP, endIP, ipRange)
+	}
+
 	return nil
 }
 
+// validateIPRangeValue is validateIPRange as a schema ValidateFunc.
+func validateIPRangeValue(v interface{}, k string) (ws []string, errors []error) {
+
+	if err := validateIPRange(v); err != nil {
+		errors = append(errors, fmt.Errorf("%s: %s", k, err))
+	}
+	return
+}
+
+// parseIPRange returns the start and end addresses of a valid "start-end"
+// range.
+func parseIPRange(v string) (ipRange, error) {
+
+	if err := validateIPRange(v); err != nil {
+		return ipRange{}, err
+	}
+
+	ip := strings.Split(strings.TrimSpace(v), "-")
+	return ipRange{
+		start: normalizeIP(net.ParseIP(strings.TrimSpace(ip[0]))),
+		end:   normalizeIP(net.ParseIP(strings.TrimSpace(ip[1]))),
+	}, nil
+}
+
+// validateAndSortIPRange sorts the ranges by start address and fails if
+// any two of them overlap, including a range containing another one.
 func validateAndSortIPRange(ipRangeCfgs []ipRange) ([]ipRange, error) {
 
-	for i := 0; i < len(ipRangeCfgs); i++ {
-		for j := i + 1; j < len(ipRangeCfgs); j++ {
+	values := make([]valueRange, len(ipRangeCfgs))
+	for i, r := range ipRangeCfgs {
+		if compareIP(r.start, r.end) > 0 {
+			return nil, fmt.Errorf("IP range '%s' is not valid.", getIPRangeString(r))
+		}
+		values[i] = valueRange{ipValue(r.start), ipValue(r.end)}
+	}
 
-			r1 := ipRangeCfgs[i]
-			r2 := ipRangeCfgs[j]
+	order, overlap := sortRanges(values)
+	if overlap != nil {
+		return nil, fmt.Errorf("Overlapping IP Ranges '%s' and '%s'",
+			getIPRangeString(ipRangeCfgs[overlap[0]]),
+			getIPRangeString(ipRangeCfgs[overlap[1]]))
+	}
 
-			if checkIPInRange(r1, r2.start) || checkIPInRange(r1, r2.end) {
+	sorted := []ipRange{}
+	for _, i := range order {
+		sorted = append(sorted, ipRangeCfgs[i])
+	}
+
+	return sorted, nil
+}
 
-				return nil, fmt.Errorf("Overlapping IP Ranges '%s' and '%s'",
-					getIPRangeString(r1), getIPRangeString(r2))
-			}
+// valueRange is an inclusive range of integers, the VNIs of a segment ID
+// pool or the addresses of an IP range given by ipValue.
+type valueRange struct {
+	start *big.Int
+	end   *big.Int
+}
 
-			// if r1 > r2, swap
-			if ipToInt(r1.start) > ipToInt(r2.end) {
-				ipRangeCfgs[i] = r2
-				ipRangeCfgs[j] = r1
-			}
+// sortRanges returns the indexes of the ranges sorted by start value. If
+// two ranges overlap, it returns their indexes as well.
+func sortRanges(ranges []valueRange) ([]int, []int) {
+
+	order := make([]int, len(ranges))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return ranges[order[i]].start.Cmp(ranges[order[j]].start) < 0
+	})
+
+	// Once sorted by start value, a range overlaps another one only if
+	// it starts before the end of the range preceding it.
+	for i := 1; i < len(order); i++ {
+		if ranges[order[i]].start.Cmp(ranges[order[i-1]].end) <= 0 {
+			return order, []int{order[i-1], order[i]}
 		}
 	}
 
-	return ipRangeCfgs, nil
+	return order, nil
+}
+
+//
+// The address helpers below work on both IPv4 and IPv6 addresses. IPv4
+// addresses are handled in their 4 bytes form, whatever the form given.
+//
+
+func isIPv4(ip net.IP) bool {
+	return ip.To4() != nil
+}
+
+// normalizeIP returns ip in the 4 bytes form for IPv4, 16 bytes for IPv6.
+func normalizeIP(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip.To16()
 }
 
-func ipToInt(ip net.IP) uint32 {
-	if len(ip) == 16 {
-		return binary.BigEndian.Uint32(ip[12:16])
+// ipValue maps the addresses to integers in the order of compareIP, the
+// IPv6 addresses after all the IPv4 ones.
+func ipValue(ip net.IP) *big.Int {
+
+	v := ipToInt(ip)
+	if !isIPv4(ip) {
+		v.Add(v, new(big.Int).Lsh(big.NewInt(1), 8*net.IPv4len))
 	}
-	return binary.BigEndian.Uint32(ip)
+	return v
+}
+
+func ipToInt(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(normalizeIP(ip))
 }
 
-func intToIP(nn uint32) net.IP {
-	ip := make(net.IP, 4)
-	binary.BigEndian.PutUint32(ip, nn)
+// intToIP converts n to an address of size bytes, net.IPv4len or
+// net.IPv6len, wrapping around the address space.
+func intToIP(n *big.Int, size int) net.IP {
+
+	space := new(big.Int).Lsh(big.NewInt(1), uint(size*8))
+	b := new(big.Int).Mod(n, space).Bytes()
+
+	ip := make(net.IP, size)
+	copy(ip[size-len(b):], b)
+	return ip
+}
+
+// addToIP returns the address n after ip, before it when n is negative.
+func addToIP(ip net.IP, n int64) net.IP {
+
+	ip = normalizeIP(ip)
+	return intToIP(new(big.Int).Add(ipToInt(ip), big.NewInt(n)), len(ip))
+}
+
+// compareIP orders addresses, IPv4 ones first.
+func compareIP(a net.IP, b net.IP) int {
+
+	a, b = normalizeIP(a), normalizeIP(b)
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return bytes.Compare(a, b)
+}
+
+// lastIP returns the last address of subnet, the broadcast address for IPv4.
+func lastIP(subnet *net.IPNet) net.IP {
+
+	network := normalizeIP(subnet.IP)
+	mask := subnet.Mask
+	if len(mask) != len(network) {
+		mask = mask[len(mask)-len(network):]
+	}
+
+	ip := make(net.IP, len(network))
+	for i := range network {
+		ip[i] = network[i] | ^mask[i]
+	}
 	return ip
 }
 
 func checkIPInRange(rangeVal ipRange, ip net.IP) bool {
 
-	if (bytes.Compare(ip, rangeVal.start) >= 0) &&
-		(bytes.Compare(ip, rangeVal.end) <= 0) {
+	if (compareIP(ip, rangeVal.start) >= 0) &&
+		(compareIP(ip, rangeVal.end) <= 0) {
 		return true
 	}
 	return false
 }
 
+// removeAddrFromIPRanges removes ip from the range holding it.
+func removeAddrFromIPRanges(ranges []ipRange, ip net.IP) []ipRange {
+
+	retVal := []ipRange{}
+	for _, rangeVal := range ranges {
+		retVal = append(retVal, removeGwAddrFromIPRange(rangeVal, ip)...)
+	}
+	return retVal
+}
+
+// takeFirstIP removes the first address of the first range and returns it
+// with the remaining ranges, the range is dropped once empty.
+func takeFirstIP(ranges []ipRange) (net.IP, []ipRange, error) {
+
+	if len(ranges) == 0 {
+		return nil, ranges, fmt.Errorf("No address left in the IP ranges.")
+	}
+
+	ip := ranges[0].start
+	if compareIP(ranges[0].start, ranges[0].end) >= 0 {
+		return ip, ranges[1:], nil
+	}
+
+	ranges = append([]ipRange{}, ranges...)
+	ranges[0].start = addToIP(ip, 1)
+	return ip, ranges, nil
+}
+
 func getIPRangeString(r ipRange) string {
 
 	return fmt.Sprintf(r.start.String() + "-" + r.end.String())
@@ -135,11 +382,14 @@ func getIPRangeString(r ipRange) string {
 
 func isIPInCIDR(cidr string, ip string) bool {
 
-	_, ipNet, _ := net.ParseCIDR(cidr)
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
 
 	netIP := net.ParseIP(ip)
 
-	if ipNet.Contains(netIP) {
+	if netIP != nil && ipNet.Contains(netIP) {
 		return true
 	}
 	return false
@@ -147,33 +397,24 @@ func isIPInCIDR(cidr string, ip string) bool {
 
 func getIPRangeFromCIDR(cidr string) (ipRange, error) {
 
-	_, ipNet, _ := net.ParseCIDR(cidr)
-
-	elements := strings.Split(ipNet.String(), "/")
-
-	ip := ipToInt(ipNet.IP)
-
-	bits, _ := strconv.ParseUint(elements[1], 10, 64)
-
-	var mask int64
-	mask = ^(0xffffffff >> bits)
-
-	network := int64(ip) & mask
-	broadcast := network + ^mask
-
 	rangeVal := ipRange{}
-	if bits > 30 {
-		return rangeVal, fmt.Errorf("CIDR '%s' is not valid to configure IP Ranges.",
-			cidr)
-	} else {
-		startIP := network + 1
-		endIP := broadcast - 1
 
-		//rangeVal = ipRange{intToIP(uint32(startIP)), intToIP(uint32(endIP))}
-		rangeVal.start = intToIP(uint32(startIP))
-		rangeVal.end = intToIP(uint32(endIP))
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return rangeVal, fmt.Errorf("CIDR '%s' is not valid.", cidr)
+	}
+
+	// /31 and /32 subnets, /127 and /128 in IPv6, have no network and
+	// broadcast addresses to leave out.
+	if ones, bits := ipNet.Mask.Size(); bits-ones < 2 {
+		rangeVal.start = normalizeIP(ipNet.IP)
+		rangeVal.end = lastIP(ipNet)
+		return rangeVal, nil
 	}
 
+	rangeVal.start = addToIP(ipNet.IP, 1)
+	rangeVal.end = addToIP(lastIP(ipNet), -1)
+
 	return rangeVal, nil
 }
 
@@ -181,15 +422,21 @@ func removeGwAddrFromIPRange(rangeVal ipRange, gwIP net.IP) []ipRange {
 
 	retVal := []ipRange{}
 
-	if rangeVal.start.String() == gwIP.String() {
-		rangeVal.start = intToIP(ipToInt(rangeVal.start) + 1)
+	if !checkIPInRange(rangeVal, gwIP) {
+		return append(retVal, rangeVal)
+	}
+
+	if rangeVal.start.Equal(gwIP) && rangeVal.end.Equal(gwIP) {
+		return retVal
+	} else if rangeVal.start.Equal(gwIP) {
+		rangeVal.start = addToIP(rangeVal.start, 1)
 		retVal = append(retVal, rangeVal)
-	} else if rangeVal.end.String() == gwIP.String() {
-		rangeVal.end = intToIP(ipToInt(rangeVal.end) - 1)
+	} else if rangeVal.end.Equal(gwIP) {
+		rangeVal.end = addToIP(rangeVal.end, -1)
 		retVal = append(retVal, rangeVal)
 	} else {
-		retVal = append(retVal, ipRange{rangeVal.start, intToIP(ipToInt(gwIP) - 1)})
-		retVal = append(retVal, ipRange{intToIP(ipToInt(gwIP) + 1), rangeVal.end})
+		retVal = append(retVal, ipRange{rangeVal.start, addToIP(gwIP, -1)})
+		retVal = append(retVal, ipRange{addToIP(gwIP, 1), rangeVal.end})
 	}
 
 	return retVal
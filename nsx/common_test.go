@@ -2,6 +2,7 @@ package nsx
 
 import (
 	"log"
+	"net"
 	"strings"
 	"testing"
 
@@ -65,3 +66,244 @@ func verifySchemaValidationFunctions(
 		}
 	}
 }
+
+func TestAccNsxCommon_AddressArithmetic(t *testing.T) {
+
+	cases := []struct {
+		ip       string
+		n        int64
+		expected string
+	}{
+		{"10.0.0.1", 1, "10.0.0.2"},
+		{"10.0.0.255", 1, "10.0.1.0"},
+		{"10.0.1.0", -1, "10.0.0.255"},
+		{"255.255.255.255", 1, "0.0.0.0"},
+		{"2001:db8::1", 1, "2001:db8::2"},
+		{"2001:db8::ffff", 1, "2001:db8::1:0"},
+		{"2001:db8::1:0", -1, "2001:db8::ffff"},
+		{"2001:db8:0:0:ffff:ffff:ffff:ffff", 1, "2001:db8:0:1::"},
+	}
+
+	for _, c := range cases {
+		ip := addToIP(net.ParseIP(c.ip), c.n)
+		if ip.String() != c.expected {
+			t.Fatalf("%s + %d: expected %s, got %s", c.ip, c.n, c.expected, ip)
+		}
+		if isIPv4(ip) != isIPv4(net.ParseIP(c.ip)) {
+			t.Fatalf("%s + %d: IP version changed to %s", c.ip, c.n, ip)
+		}
+	}
+}
+
+func TestAccNsxCommon_CompareIP(t *testing.T) {
+
+	cases := []struct {
+		a, b     net.IP
+		expected int
+	}{
+		{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2"), -1},
+		// 16 and 4 bytes forms of the same address.
+		{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.1").To4(), 0},
+		{net.ParseIP("10.0.1.0").To4(), net.ParseIP("10.0.0.255"), 1},
+		{net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2"), -1},
+		{net.ParseIP("255.255.255.255"), net.ParseIP("::1"), -1},
+		{net.ParseIP("::"), net.ParseIP("0.0.0.0"), 1},
+	}
+
+	for _, c := range cases {
+		if r := compareIP(c.a, c.b); r != c.expected {
+			t.Fatalf("compareIP(%s, %s): expected %d, got %d", c.a, c.b, c.expected, r)
+		}
+		if r := ipValue(c.a).Cmp(ipValue(c.b)); r != c.expected {
+			t.Fatalf("ipValue(%s) cmp ipValue(%s): expected %d, got %d", c.a, c.b, c.expected, r)
+		}
+	}
+}
+
+func TestAccNsxCommon_GetIPRangeFromCIDR(t *testing.T) {
+
+	cases := []struct {
+		cidr     string
+		expected string
+		expErr   string
+	}{
+		{"10.0.0.0/24", "10.0.0.1-10.0.0.254", ""},
+		{"10.0.0.77/30", "10.0.0.77-10.0.0.78", ""},
+		{"10.0.0.0/31", "10.0.0.0-10.0.0.1", ""},
+		{"10.0.0.7/32", "10.0.0.7-10.0.0.7", ""},
+		{"2001:db8::/64", "2001:db8::1-2001:db8::ffff:ffff:ffff:fffe", ""},
+		{"2001:db8::/126", "2001:db8::1-2001:db8::2", ""},
+		{"2001:db8::/127", "2001:db8::-2001:db8::1", ""},
+		{"2001:db8::5/128", "2001:db8::5-2001:db8::5", ""},
+		{"10.0.0.0/33", "", "is not valid"},
+		{"2001:db8::/129", "", "is not valid"},
+	}
+
+	for _, c := range cases {
+		r, err := getIPRangeFromCIDR(c.cidr)
+		if c.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expErr) {
+				t.Fatalf("%s: expected error '%s', got '%v'", c.cidr, c.expErr, err)
+			}
+			continue
+		}
+		if err != nil || getIPRangeString(r) != c.expected {
+			t.Fatalf("%s: expected %s, got %s, '%v'", c.cidr, c.expected,
+				getIPRangeString(r), err)
+		}
+	}
+}
+
+func TestAccNsxCommon_RemoveGwAddrFromIPRange(t *testing.T) {
+
+	cases := []struct {
+		start, end, gw string
+		expected       []string
+	}{
+		{"10.0.0.1", "10.0.0.254", "10.0.0.1", []string{"10.0.0.2-10.0.0.254"}},
+		{"10.0.0.1", "10.0.0.254", "10.0.0.254", []string{"10.0.0.1-10.0.0.253"}},
+		{"10.0.0.1", "10.0.0.254", "10.0.0.100",
+			[]string{"10.0.0.1-10.0.0.99", "10.0.0.101-10.0.0.254"}},
+		{"2001:db8::1", "2001:db8::ff", "2001:db8::1", []string{"2001:db8::2-2001:db8::ff"}},
+		{"2001:db8::1", "2001:db8::ff", "2001:db8::10",
+			[]string{"2001:db8::1-2001:db8::f", "2001:db8::11-2001:db8::ff"}},
+		{"10.0.0.1", "10.0.0.254", "10.0.1.1", []string{"10.0.0.1-10.0.0.254"}},
+		{"10.0.0.7", "10.0.0.7", "10.0.0.7", []string{}},
+	}
+
+	for _, c := range cases {
+		ranges := removeGwAddrFromIPRange(
+			ipRange{net.ParseIP(c.start), net.ParseIP(c.end)}, net.ParseIP(c.gw))
+
+		result := []string{}
+		for _, r := range ranges {
+			result = append(result, getIPRangeString(r))
+		}
+		if strings.Join(result, ",") != strings.Join(c.expected, ",") {
+			t.Fatalf("%s-%s without %s: expected %v, got %v", c.start, c.end, c.gw,
+				c.expected, result)
+		}
+	}
+}
+
+func TestAccNsxCommon_TakeFirstIP(t *testing.T) {
+
+	ranges := []ipRange{
+		{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")},
+		{net.ParseIP("10.0.0.9"), net.ParseIP("10.0.0.9")},
+	}
+
+	expected := []string{"10.0.0.1", "10.0.0.2", "10.0.0.9"}
+	for _, exp := range expected {
+		ip, remaining, err := takeFirstIP(ranges)
+		if err != nil || ip.String() != exp {
+			t.Fatalf("expected %s, got %s, '%v'", exp, ip, err)
+		}
+		ranges = remaining
+	}
+
+	if len(ranges) != 0 {
+		t.Fatalf("expected no range left, got %v", ranges)
+	}
+	if _, _, err := takeFirstIP(ranges); err == nil {
+		t.Fatalf("expected an error taking an address from no range")
+	}
+}
+
+func TestAccNsxCommon_ValidateIPRangeIPv6(t *testing.T) {
+
+	cases := []struct {
+		ipRange string
+		expErr  string
+	}{
+		{"2001:db8::10-2001:db8::20", ""},
+		{" 2001:db8::10 - 2001:db8::20 ", ""},
+		{"2001:db8::20-2001:db8::10", "needs to be smaller than"},
+		{"10.0.0.1-2001:db8::10", "are not of the same IP version"},
+		{"2001:db8::g-2001:db8::10", "Start IP '2001:db8::g' is not valid"},
+		{"2001:db8::10", "is not valid"},
+	}
+
+	for _, c := range cases {
+		err := validateIPRange(c.ipRange)
+		if c.expErr == "" {
+			if err != nil {
+				t.Fatalf("IP range '%s' is not VALID: %s", c.ipRange, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.expErr) {
+			t.Fatalf("IP range '%s': expected error '%s', got '%v'", c.ipRange, c.expErr, err)
+		}
+	}
+
+	if !isIPInCIDR("2001:db8::/64", "2001:db8::ffff") || isIPInCIDR("2001:db8::/64", "2001:db9::1") {
+		t.Fatalf("IPv6 CIDR membership is not valid")
+	}
+	if isIPInCIDR("not a cidr", "10.0.0.1") {
+		t.Fatalf("IP found in an invalid CIDR")
+	}
+}
+
+func FuzzAddToIP(f *testing.F) {
+
+	f.Add([]byte{10, 0, 0, 1}, int64(1))
+	f.Add([]byte{255, 255, 255, 255}, int64(1))
+	f.Add([]byte(net.ParseIP("2001:db8::1").To16()), int64(-2))
+
+	f.Fuzz(func(t *testing.T, b []byte, n int64) {
+		if len(b) != net.IPv4len && len(b) != net.IPv6len {
+			return
+		}
+		ip := net.IP(b)
+
+		back := addToIP(addToIP(ip, n), -n)
+		if !back.Equal(ip) {
+			t.Fatalf("%s + %d - %d gives %s", ip, n, n, back)
+		}
+		if isIPv4(addToIP(ip, n)) != isIPv4(ip) {
+			t.Fatalf("%s + %d changed the IP version", ip, n)
+		}
+	})
+}
+
+func FuzzGetIPRangeFromCIDR(f *testing.F) {
+
+	for _, cidr := range []string{"10.0.0.0/24", "10.0.0.0/31", "10.0.0.1/32",
+		"2001:db8::/64", "2001:db8::/127", "2001:db8::1/128", "0.0.0.0/0"} {
+		f.Add(cidr)
+	}
+
+	f.Fuzz(func(t *testing.T, cidr string) {
+		r, err := getIPRangeFromCIDR(cidr)
+		if err != nil {
+			return
+		}
+		if compareIP(r.start, r.end) > 0 {
+			t.Fatalf("%s: inverted range %s", cidr, getIPRangeString(r))
+		}
+		if !isIPInCIDR(cidr, r.start.String()) || !isIPInCIDR(cidr, r.end.String()) {
+			t.Fatalf("%s: range %s is not in the CIDR", cidr, getIPRangeString(r))
+		}
+	})
+}
+
+func FuzzValidateIPRange(f *testing.F) {
+
+	for _, r := range []string{"1.2.3.4-1.2.3.50", "1.2.3.4", "2001:db8::1-2001:db8::2",
+		"-", "1.2.3.4-2001:db8::1", " 1.2.3.4 - 1.2.3.5 "} {
+		f.Add(r)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		if err := validateIPRange(s); err != nil {
+			return
+		}
+		ips := strings.Split(strings.TrimSpace(s), "-")
+		start := net.ParseIP(strings.TrimSpace(ips[0]))
+		end := net.ParseIP(strings.TrimSpace(ips[1]))
+		if compareIP(start, end) >= 0 {
+			t.Fatalf("IP range '%s' accepted with start not before end", s)
+		}
+	})
+}
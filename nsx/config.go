@@ -3,6 +3,7 @@ package nsx
 import (
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"time"
@@ -11,14 +12,18 @@ import (
 )
 
 type Config struct {
-	User          string
-	Password      string
-	NsxManagerUri string
-	UserAgentName string
-	InsecureFlag  bool
-	Debug         bool
-	DebugPath     string
-	DebugPathRun  string
+	User                 string
+	Password             string
+	NsxManagerUri        string
+	PrimaryNsxManagerUri string
+	UserAgentName        string
+	InsecureFlag         bool
+	Debug                bool
+	DebugPath            string
+	DebugPathRun         string
+	MaxRetries           int
+	RetryMinDelay        int
+	RetryMaxDelay        int
 }
 
 //
@@ -44,12 +49,55 @@ func (c *Config) Client() (*govnsx.Client, error) {
 		return nil, fmt.Errorf("Error setting up client: %s", err)
 	}
 
+	c.EnableRetries(client)
+
 	log.Printf("[INFO] NSX Manager Client configured for URL: %s",
 		c.NsxManagerUri)
 
+	// Universal objects are sent to the primary NSX Manager.
+	if c.PrimaryNsxManagerUri != "" && c.PrimaryNsxManagerUri != c.NsxManagerUri {
+		primaryParams := *nsxMgrParams
+		primaryParams.Uri = c.PrimaryNsxManagerUri
+
+		primary, err := govnsx.NewClient(&primaryParams)
+		if err != nil {
+			return nil, fmt.Errorf("Error setting up primary NSX Manager client: %s", err)
+		}
+		c.EnableRetries(primary)
+		setPrimaryClient(client, primary)
+
+		log.Printf("[INFO] Primary NSX Manager Client configured for URL: %s",
+			c.PrimaryNsxManagerUri)
+	}
+
 	return client, nil
 }
 
+//
+// EnableRetries() makes every request sent through the client retry with
+// a capped exponential backoff and jitter while NSX Manager is busy.
+//
+func (c *Config) EnableRetries(client *govnsx.Client) {
+	if c.MaxRetries <= 0 {
+		return
+	}
+
+	base := client.Rclient.GetClient().Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	client.Rclient.SetTransport(&nsxRetryTransport{
+		base:       base,
+		maxRetries: c.MaxRetries,
+		minDelay:   time.Duration(c.RetryMinDelay) * time.Millisecond,
+		maxDelay:   time.Duration(c.RetryMaxDelay) * time.Millisecond,
+	})
+
+	log.Printf("[INFO] NSX Manager Client retries: %d, delay: %d-%d ms",
+		c.MaxRetries, c.RetryMinDelay, c.RetryMaxDelay)
+}
+
 func (c *Config) EnableDebug() error {
 	if !c.Debug {
 		return nil
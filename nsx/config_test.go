@@ -0,0 +1,222 @@
+package nsx
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx/nsxresource"
+)
+
+const testEdgeBusyError = `<error><details>Edge edge-1 is busy, another operation is in progress.</details>` +
+	`<errorCode>10220</errorCode><moduleName>vShield Edge</moduleName></error>`
+
+type retryResponseData struct {
+	method     string
+	statusCode int
+	body       string
+	expected   bool
+}
+
+func TestAccNsxConfig_IsRetryableResponse(t *testing.T) {
+
+	testData := []retryResponseData{
+		{"GET", 200, "", false},
+		{"PUT", 204, "", false},
+		{"GET", 502, "", true},
+		{"PUT", 503, "", true},
+		{"DELETE", 504, "Gateway Timeout", true},
+		{"POST", 502, "", false},
+		{"POST", 504, "Gateway Timeout", false},
+		{"GET", 400, testEdgeBusyError, true},
+		{"POST", 400, testEdgeBusyError, true},
+		{"PUT", 500, `<error><details>Operation in progress</details><errorCode>1</errorCode></error>`, true},
+		{"POST", 500, `<error><details>Operation in progress</details><errorCode>1</errorCode></error>`, false},
+		{"PUT", 400, `<error><details>Invalid IP address</details><errorCode>15001</errorCode></error>`, false},
+		{"GET", 404, "Not Found", false},
+		{"GET", 500, "Internal Server Error", false},
+	}
+
+	for _, data := range testData {
+		retVal := isRetryableResponse(data.method, data.statusCode, []byte(data.body))
+		if retVal != data.expected {
+			t.Fatalf("isRetryableResponse(%s, %d, %q) returned %t, expected %t",
+				data.method, data.statusCode, data.body, retVal, data.expected)
+		}
+	}
+}
+
+func testRetryClientConfig(uri string, maxRetries int) *Config {
+	return &Config{
+		User:          mockUser,
+		Password:      mockPassword,
+		NsxManagerUri: uri,
+		MaxRetries:    maxRetries,
+		RetryMinDelay: 1,
+		RetryMaxDelay: 10,
+	}
+}
+
+func TestAccNsxConfig_RetryWhileEdgeBusy(t *testing.T) {
+
+	m := newMockNsxManager()
+	defer m.Server.Close()
+
+	// Answer the first two calls as busy, then hand over to the mock.
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(testEdgeBusyError))
+			return
+		}
+		m.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client, err := testRetryClientConfig(server.URL, 3).Client()
+	if err != nil {
+		t.Fatalf("Unable to create client: %s", err)
+	}
+
+	edgeCfg, err := nsxresource.NewEdge(client).Get(mockEdgeId)
+	if err != nil {
+		t.Fatalf("Edge Get failed with error: %s", err)
+	}
+	if edgeCfg.Id != mockEdgeId {
+		t.Fatalf("Unexpected edge: %#v", edgeCfg)
+	}
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestAccNsxConfig_NoRetryOnClientError(t *testing.T) {
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client, err := testRetryClientConfig(server.URL, 3).Client()
+	if err != nil {
+		t.Fatalf("Unable to create client: %s", err)
+	}
+
+	if _, err := nsxresource.NewEdge(client).Get(mockEdgeId); err == nil {
+		t.Fatalf("Edge Get succeeded, expected an error")
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("Expected 1 call, got %d", calls)
+	}
+}
+
+func TestAccNsxConfig_RetriesExhausted(t *testing.T) {
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := testRetryClientConfig(server.URL, 2).Client()
+	if err != nil {
+		t.Fatalf("Unable to create client: %s", err)
+	}
+
+	if _, err := nsxresource.NewEdge(client).Get(mockEdgeId); err == nil {
+		t.Fatalf("Edge Get succeeded, expected an error")
+	}
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestAccNsxConfig_NoPostRetryOnGatewayError(t *testing.T) {
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}))
+	defer server.Close()
+
+	client, err := testRetryClientConfig(server.URL, 3).Client()
+	if err != nil {
+		t.Fatalf("Unable to create client: %s", err)
+	}
+
+	if _, _, err := nsxPost(client, server.URL+"/api/4.0/edges", nil); err == nil {
+		t.Fatalf("Edge POST succeeded, expected an error")
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("Expected 1 call, got %d", calls)
+	}
+}
+
+func TestAccNsxConfig_PostRetryWhileEdgeBusy(t *testing.T) {
+
+	var calls int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(testEdgeBusyError))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client, err := testRetryClientConfig(server.URL, 3).Client()
+	if err != nil {
+		t.Fatalf("Unable to create client: %s", err)
+	}
+
+	in := &objectRef{ObjectId: "edge-1"}
+	if _, _, err := nsxPost(client, server.URL+"/api/4.0/edges", in); err != nil {
+		t.Fatalf("Edge POST failed with error: %s", err)
+	}
+	if len(bodies) != 2 || bodies[0] == "" || bodies[0] != bodies[1] {
+		t.Fatalf("Expected the same body to be sent twice, got %q", bodies)
+	}
+}
+
+func TestAccNsxConfig_TransportErrorRetry(t *testing.T) {
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	client, err := testRetryClientConfig(server.URL, 2).Client()
+	if err != nil {
+		t.Fatalf("Unable to create client: %s", err)
+	}
+
+	if _, _, err := nsxPost(client, server.URL+"/api/4.0/edges", nil); err == nil {
+		t.Fatalf("Edge POST succeeded, expected an error")
+	}
+	if n := atomic.SwapInt32(&calls, 0); n != 1 {
+		t.Fatalf("Expected 1 POST call, got %d", n)
+	}
+
+	if err := nsxGet(client, server.URL+"/api/4.0/edges", nil); err == nil {
+		t.Fatalf("Edge GET succeeded, expected an error")
+	}
+	if n := atomic.LoadInt32(&calls); n < 3 {
+		t.Fatalf("Expected at least 3 GET calls, got %d", n)
+	}
+}
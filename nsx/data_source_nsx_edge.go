@@ -0,0 +1,243 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	EdgeListUriFormat = "%s/api/4.0/edges?startIndex=%d&pageSize=%d"
+
+	listPageSize = 256
+)
+
+type pagingInfo struct {
+	PageSize   int `xml:"pageSize"`
+	StartIndex int `xml:"startIndex"`
+	TotalCount int `xml:"totalCount"`
+}
+
+type edgeAppliancesSummary struct {
+	VmVersion string `xml:"vmVersion,omitempty"`
+}
+
+type edgeSummary struct {
+	ObjectId          string                `xml:"objectId"`
+	Name              string                `xml:"name"`
+	EdgeType          string                `xml:"edgeType"`
+	AppliancesSummary edgeAppliancesSummary `xml:"appliancesSummary"`
+}
+
+type pagedEdgeList struct {
+	XMLName      xml.Name      `xml:"pagedEdgeList"`
+	PagingInfo   pagingInfo    `xml:"edgePage>pagingInfo"`
+	EdgeSummarys []edgeSummary `xml:"edgePage>edgeSummary"`
+}
+
+func dataSourceNsxEdge() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceNsxEdgeRead,
+
+		Schema: map[string]*schema.Schema{
+			"edge_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"type": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"tenant_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"appliance_size": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"vnic": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"index": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"portgroup_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"is_connected": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"address_group": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"primary_address": &schema.Schema{
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"subnet_mask": &schema.Schema{
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"subnet_prefix_length": &schema.Schema{
+										Type:     schema.TypeInt,
+										Computed: true,
+									},
+									"secondary_addresses": &schema.Schema{
+										Type:     schema.TypeList,
+										Computed: true,
+										Elem:     &schema.Schema{Type: schema.TypeString},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceNsxEdgeRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	edgeId := d.Get("edge_id").(string)
+	name := d.Get("name").(string)
+
+	if edgeId == "" && name == "" {
+		return fmt.Errorf("One of 'edge_id' or 'name' must be set")
+	}
+
+	if edgeId == "" {
+		var err error
+		if edgeId, err = findEdgeIdByName(client, name); err != nil {
+			return err
+		}
+	}
+
+	edgeCfg, err := getEdge(client, edgeId)
+	if err != nil {
+		return err
+	}
+
+	if name != "" && edgeCfg.Name != name {
+		return fmt.Errorf("Edge '%s' is named '%s', not '%s'", edgeId, edgeCfg.Name, name)
+	}
+
+	vnics := make([]map[string]interface{}, 0)
+	for _, vnic := range edgeCfg.Vnics {
+
+		addrGroups := make([]map[string]interface{}, 0)
+		for _, addrGroup := range vnic.AddressGroups {
+			addrGroups = append(addrGroups, map[string]interface{}{
+				"primary_address":      addrGroup.PrimaryAddress,
+				"subnet_mask":          addrGroup.SubnetMask,
+				"subnet_prefix_length": addrGroup.SubnetPrefixLength,
+				"secondary_addresses":  addrGroup.SecondaryAddresses,
+			})
+		}
+
+		vnics = append(vnics, map[string]interface{}{
+			"index":         vnic.Index,
+			"type":          vnic.Type,
+			"portgroup_id":  vnic.PortgroupId,
+			"is_connected":  vnic.IsConnected,
+			"address_group": addrGroups,
+		})
+	}
+
+	d.SetId(edgeCfg.Id)
+	d.Set("edge_id", edgeCfg.Id)
+	d.Set("name", edgeCfg.Name)
+	d.Set("type", edgeCfg.Type)
+	d.Set("description", edgeCfg.Description)
+	d.Set("tenant_id", edgeCfg.Tenant)
+	d.Set("status", edgeCfg.Status)
+	d.Set("appliance_size", edgeCfg.Appliances.ApplianceSize)
+
+	if err := d.Set("vnic", vnics); err != nil {
+		return fmt.Errorf("Invalid vnics to set: %#v", vnics)
+	}
+
+	return nil
+}
+
+func listEdges(client *govnsx.Client) ([]edgeSummary, error) {
+
+	edges := []edgeSummary{}
+	for startIndex := 0; ; {
+
+		getUri := fmt.Sprintf(EdgeListUriFormat, client.MgrConfig.Uri, startIndex, listPageSize)
+
+		page := &pagedEdgeList{}
+		if err := nsxGet(client, getUri, page); err != nil {
+			log.Printf("[ERROR] Retriving Edges failed with error : '%v'", err)
+			return nil, err
+		}
+
+		edges = append(edges, page.EdgeSummarys...)
+		startIndex += len(page.EdgeSummarys)
+
+		if len(page.EdgeSummarys) == 0 || startIndex >= page.PagingInfo.TotalCount {
+			break
+		}
+	}
+
+	return edges, nil
+}
+
+func findEdgeIdByName(client *govnsx.Client, name string) (string, error) {
+
+	edges, err := listEdges(client)
+	if err != nil {
+		return "", err
+	}
+
+	edgeId := ""
+	for _, edge := range edges {
+		if edge.Name != name {
+			continue
+		}
+		if edgeId != "" {
+			return "", fmt.Errorf("More than one Edge named '%s' found: %s, %s",
+				name, edgeId, edge.ObjectId)
+		}
+		edgeId = edge.ObjectId
+	}
+
+	if edgeId == "" {
+		return "", fmt.Errorf("Edge '%s' not found", name)
+	}
+
+	log.Printf("[INFO] Edge '%s' has id: %s", name, edgeId)
+	return edgeId, nil
+}
@@ -0,0 +1,118 @@
+package nsx
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceNsxEdgeDLRInterfaces() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceNsxEdgeDLRInterfacesRead,
+
+		Schema: map[string]*schema.Schema{
+			"edge_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"interface": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"index": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ip": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"mask": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"prefix_length": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"secondary_ips": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"logical_switch_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"logical_switch_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceNsxEdgeDLRInterfacesRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+	edgeId := d.Get("edge_id").(string)
+
+	edgeType, err := getEdgeType(edgeId, meta)
+	if err != nil {
+		log.Printf("[ERROR] Unable to read Edge type %s", err)
+		return err
+	}
+
+	if edgeType != EdgeTypeDistributedRouter {
+		return fmt.Errorf("Edge '%s' is of type %s, only Edge type %s has DLR interfaces",
+			edgeId, edgeType, EdgeTypeDistributedRouter)
+	}
+
+	resp, err := getDLRInterfaces(client, edgeId)
+	if err != nil {
+		return err
+	}
+
+	ifaces := make([]map[string]interface{}, 0)
+	for _, curIface := range resp.Interfaces {
+
+		addrGroup := addressGroup{}
+		if len(curIface.AddressGroups) > 0 {
+			addrGroup = curIface.AddressGroups[0]
+		}
+
+		ifaces = append(ifaces, map[string]interface{}{
+			"index":               curIface.Index,
+			"name":                curIface.Name,
+			"type":                curIface.Type,
+			"ip":                  addrGroup.PrimaryAddress,
+			"mask":                addrGroup.SubnetMask,
+			"prefix_length":       addrGroup.SubnetPrefixLength,
+			"secondary_ips":       addrGroup.SecondaryAddresses,
+			"logical_switch_id":   curIface.ConnectedToId,
+			"logical_switch_name": curIface.ConnectedToName,
+		})
+	}
+
+	d.SetId(DLRResourceIdPrefix + edgeId)
+
+	if err := d.Set("interface", ifaces); err != nil {
+		return fmt.Errorf("Invalid interfaces to set: %#v", ifaces)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package nsx
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const testAccCheckEdgeDLRInterfacesDataSourceConf = `
+data "nsxv_edge_dlr_interfaces" "dlr" {
+    edge_id = "%s"
+}
+`
+
+// testDLRInterfacesNsxXML are the interfaces of a DLR as returned by NSX.
+const testDLRInterfacesNsxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<interfaces>
+  <interface>
+    <label>138900000002/vNic_2</label>
+    <name>Transit-Uplink</name>
+    <addressGroups>
+      <addressGroup>
+        <primaryAddress>192.168.10.2</primaryAddress>
+        <subnetMask>255.255.255.248</subnetMask>
+        <subnetPrefixLength>29</subnetPrefixLength>
+      </addressGroup>
+    </addressGroups>
+    <mtu>1500</mtu>
+    <type>uplink</type>
+    <isConnected>true</isConnected>
+    <isSharedNetwork>false</isSharedNetwork>
+    <index>2</index>
+    <connectedToId>virtualwire-3</connectedToId>
+    <connectedToName>Transit-Network-01</connectedToName>
+  </interface>
+  <interface>
+    <label>13890000000a</label>
+    <name>Web-Tier</name>
+    <addressGroups>
+      <addressGroup>
+        <primaryAddress>172.16.10.1</primaryAddress>
+        <secondaryAddresses>
+          <ipAddress>172.16.10.2</ipAddress>
+          <ipAddress>172.16.10.3</ipAddress>
+        </secondaryAddresses>
+        <subnetMask>255.255.255.0</subnetMask>
+        <subnetPrefixLength>24</subnetPrefixLength>
+      </addressGroup>
+    </addressGroups>
+    <mtu>1500</mtu>
+    <type>internal</type>
+    <isConnected>true</isConnected>
+    <isSharedNetwork>false</isSharedNetwork>
+    <index>10</index>
+    <connectedToId>virtualwire-1</connectedToId>
+    <connectedToName>Web-Tier-01</connectedToName>
+  </interface>
+</interfaces>
+`
+
+func TestAccNsxEdgeDLRInterfacesDataSource_Basic(t *testing.T) {
+
+	dataSourceName := "data.nsxv_edge_dlr_interfaces.dlr"
+	config := fmt.Sprintf(testAccCheckEdgeDLRConf, dlrEdgeId, lsId, lsId)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdgeDLR(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDLRDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+			},
+			resource.TestStep{
+				Config: config + fmt.Sprintf(testAccCheckEdgeDLRInterfacesDataSourceConf, dlrEdgeId),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "id",
+						DLRResourceIdPrefix+dlrEdgeId),
+					resource.TestCheckResourceAttr(dataSourceName, "interface.#", "2"),
+					testAccCheckDLRInterfacesDataSourceInterface(dataSourceName, "tf-acc-ipv4",
+						map[string]string{
+							"ip":                "10.30.0.1",
+							"mask":              "255.255.255.0",
+							"secondary_ips.#":   "1",
+							"secondary_ips.0":   "10.30.0.2",
+							"logical_switch_id": lsId,
+						}),
+					testAccCheckDLRInterfacesDataSourceInterface(dataSourceName, "tf-acc-ipv6",
+						map[string]string{
+							"ip":                "2001:db8:30::1",
+							"prefix_length":     "64",
+							"logical_switch_id": lsId,
+						}),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeDLRInterfacesDataSource_NotFound(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheckEdgeDLR(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckEdgeDLRInterfacesDataSourceConf, "edge-missing"),
+				ExpectError: regexp.MustCompile("404"),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckEdgeDLRInterfacesDataSourceConf, edgeId),
+				ExpectError: regexp.MustCompile("only Edge type distributedRouter has DLR interfaces"),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeDLRInterfacesDataSource_NsxXML(t *testing.T) {
+
+	if !isTestAccMock() {
+		t.Skip("Serving NSX documents requires the mock NSX Manager")
+	}
+	m := testAccMockNsxManager(t)
+	m.Fixtures["/api/4.0/edges/"+mockDLREdgeId+"/interfaces"] = testDLRInterfacesNsxXML
+
+	dataSourceName := "data.nsxv_edge_dlr_interfaces.dlr"
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckEdgeDLRInterfacesDataSourceConf, mockDLREdgeId),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "interface.#", "2"),
+					testAccCheckDLRInterfacesDataSourceInterface(dataSourceName, "Transit-Uplink",
+						map[string]string{
+							"index":               "2",
+							"type":                "uplink",
+							"ip":                  "192.168.10.2",
+							"mask":                "255.255.255.248",
+							"prefix_length":       "29",
+							"secondary_ips.#":     "0",
+							"logical_switch_id":   "virtualwire-3",
+							"logical_switch_name": "Transit-Network-01",
+						}),
+					testAccCheckDLRInterfacesDataSourceInterface(dataSourceName, "Web-Tier",
+						map[string]string{
+							"index":               "10",
+							"type":                "internal",
+							"ip":                  "172.16.10.1",
+							"secondary_ips.#":     "2",
+							"secondary_ips.0":     "172.16.10.2",
+							"secondary_ips.1":     "172.16.10.3",
+							"logical_switch_id":   "virtualwire-1",
+							"logical_switch_name": "Web-Tier-01",
+						}),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckDLRInterfacesDataSourceInterface checks the attributes of the
+// interface named ifaceName, NSX does not keep the order interfaces were
+// added in.
+func testAccCheckDLRInterfacesDataSourceInterface(dataSourceName string, ifaceName string,
+	expected map[string]string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		rs, ok := s.RootModule().Resources[dataSourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", dataSourceName)
+		}
+		attrs := rs.Primary.Attributes
+
+		count, _ := strconv.Atoi(attrs["interface.#"])
+		for i := 0; i < count; i++ {
+			prefix := fmt.Sprintf("interface.%d.", i)
+			if attrs[prefix+"name"] != ifaceName {
+				continue
+			}
+			for k, v := range expected {
+				if attrs[prefix+k] != v {
+					return fmt.Errorf("Interface '%s' has %s '%s', expected '%s'",
+						ifaceName, k, attrs[prefix+k], v)
+				}
+			}
+			return nil
+		}
+
+		return fmt.Errorf("Interface '%s' not found in %s", ifaceName, dataSourceName)
+	}
+}
@@ -0,0 +1,240 @@
+package nsx
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+const testAccCheckEdgeDataSourceConf = `
+data "nsxv_edge" "by_id" {
+    edge_id = "%s"
+}
+
+data "nsxv_edge" "by_name" {
+    name = "${data.nsxv_edge.by_id.name}"
+}
+`
+
+const testAccCheckEdgeDataSourceConf_name = `
+data "nsxv_edge" "edge" {
+    name = "%s"
+}
+`
+
+const testAccCheckEdgeDataSourceConf_id = `
+data "nsxv_edge" "edge" {
+    edge_id = "%s"
+    name = "%s"
+}
+`
+
+// testEdgeNsxXML is an edge as returned by NSX.
+const testEdgeNsxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<edge>
+  <id>edge-5</id>
+  <version>7</version>
+  <status>deployed</status>
+  <tenant>default</tenant>
+  <name>Perimeter-Gateway-01</name>
+  <description>Perimeter gateway</description>
+  <fqdn>perimeter-gw-01</fqdn>
+  <enableAesni>true</enableAesni>
+  <enableFips>false</enableFips>
+  <vseLogLevel>info</vseLogLevel>
+  <appliances>
+    <applianceSize>large</applianceSize>
+    <appliance>
+      <highAvailabilityIndex>0</highAvailabilityIndex>
+      <vcUuid>500c2d0e-2c8f-7e3a-5b5e-3c0b9d4c0f42</vcUuid>
+      <vmId>vm-143</vmId>
+      <resourcePoolId>domain-c7</resourcePoolId>
+      <resourcePoolName>Edge-Cluster</resourcePoolName>
+      <datastoreId>datastore-29</datastoreId>
+      <datastoreName>ds-site-a-nfs01</datastoreName>
+      <deployed>true</deployed>
+    </appliance>
+    <deployAppliances>true</deployAppliances>
+  </appliances>
+  <cliSettings>
+    <remoteAccess>false</remoteAccess>
+    <userName>admin</userName>
+    <sshLoginBannerText>Unauthorized access is prohibited</sshLoginBannerText>
+    <passwordExpiry>99999</passwordExpiry>
+  </cliSettings>
+  <features>
+    <featureConfig/>
+    <firewall>
+      <version>1</version>
+      <enabled>true</enabled>
+    </firewall>
+  </features>
+  <autoConfiguration>
+    <enabled>true</enabled>
+    <rulePriority>high</rulePriority>
+  </autoConfiguration>
+  <type>gatewayServices</type>
+  <isUniversal>false</isUniversal>
+  <hypervisorAssist>false</hypervisorAssist>
+  <queryDaemon>
+    <enabled>false</enabled>
+    <port>5666</port>
+  </queryDaemon>
+  <vnics>
+    <vnic>
+      <label>vNic_0</label>
+      <name>Uplink</name>
+      <addressGroups>
+        <addressGroup>
+          <primaryAddress>192.168.100.3</primaryAddress>
+          <secondaryAddresses>
+            <ipAddress>192.168.100.4</ipAddress>
+          </secondaryAddresses>
+          <subnetMask>255.255.255.0</subnetMask>
+          <subnetPrefixLength>24</subnetPrefixLength>
+        </addressGroup>
+      </addressGroups>
+      <mtu>1500</mtu>
+      <type>uplink</type>
+      <isConnected>true</isConnected>
+      <index>0</index>
+      <portgroupId>dvportgroup-39</portgroupId>
+      <portgroupName>Mgmt-Edge-Uplink</portgroupName>
+      <enableProxyArp>false</enableProxyArp>
+      <enableSendRedirects>true</enableSendRedirects>
+    </vnic>
+    <vnic>
+      <label>vNic_1</label>
+      <name>vnic1</name>
+      <addressGroups/>
+      <mtu>1500</mtu>
+      <type>internal</type>
+      <isConnected>false</isConnected>
+      <index>1</index>
+      <enableProxyArp>false</enableProxyArp>
+      <enableSendRedirects>true</enableSendRedirects>
+    </vnic>
+  </vnics>
+</edge>
+`
+
+func TestAccNsxEdgeDataSource_Basic(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheckEdgeDataSource(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckEdgeDataSourceConf, edgeId),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.nsxv_edge.by_id", "id", edgeId),
+					resource.TestCheckResourceAttr("data.nsxv_edge.by_id", "type",
+						EdgeTypeGatewayServices),
+					resource.TestCheckResourceAttrSet("data.nsxv_edge.by_id", "name"),
+					resource.TestCheckResourceAttrSet("data.nsxv_edge.by_id", "status"),
+					resource.TestCheckResourceAttr("data.nsxv_edge.by_name", "edge_id", edgeId),
+					resource.TestCheckResourceAttrPair(
+						"data.nsxv_edge.by_name", "vnic.#", "data.nsxv_edge.by_id", "vnic.#"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeDataSource_NotFound(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheckEdgeDataSource(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckEdgeDataSourceConf_name, "tf-acc-missing"),
+				ExpectError: regexp.MustCompile("Edge 'tf-acc-missing' not found"),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckEdgeDataSourceConf_id, "edge-missing", ""),
+				ExpectError: regexp.MustCompile("404"),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckEdgeDataSourceConf_id, edgeId, "tf-acc-missing"),
+				ExpectError: regexp.MustCompile("not 'tf-acc-missing'"),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeDataSource_AmbiguousName(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			if !isTestAccMock() {
+				t.Skip("Two Edges of the same name require the mock NSX Manager")
+			}
+			m := testAccMockNsxManager(t)
+			m.edges["edge-3"] = newMockEdge("edge-3", EdgeTypeGatewayServices,
+				&edgeConfig{Name: "mock-esg"})
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckEdgeDataSourceConf_name, "mock-esg"),
+				ExpectError: regexp.MustCompile("More than one Edge named 'mock-esg' found"),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeDataSource_NsxXML(t *testing.T) {
+
+	if !isTestAccMock() {
+		t.Skip("Serving NSX documents requires the mock NSX Manager")
+	}
+	m := testAccMockNsxManager(t)
+	m.Fixtures["/api/4.0/edges/edge-5"] = testEdgeNsxXML
+
+	dataSourceName := "data.nsxv_edge.edge"
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckEdgeDataSourceConf_id, "edge-5",
+					"Perimeter-Gateway-01"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "id", "edge-5"),
+					resource.TestCheckResourceAttr(dataSourceName, "type", EdgeTypeGatewayServices),
+					resource.TestCheckResourceAttr(dataSourceName, "description", "Perimeter gateway"),
+					resource.TestCheckResourceAttr(dataSourceName, "tenant_id", "default"),
+					resource.TestCheckResourceAttr(dataSourceName, "status", "deployed"),
+					resource.TestCheckResourceAttr(dataSourceName, "appliance_size", "large"),
+					resource.TestCheckResourceAttr(dataSourceName, "vnic.#", "2"),
+					resource.TestCheckResourceAttr(dataSourceName, "vnic.0.index", "0"),
+					resource.TestCheckResourceAttr(dataSourceName, "vnic.0.type", "uplink"),
+					resource.TestCheckResourceAttr(dataSourceName, "vnic.0.portgroup_id", "dvportgroup-39"),
+					resource.TestCheckResourceAttr(dataSourceName, "vnic.0.is_connected", "true"),
+					resource.TestCheckResourceAttr(dataSourceName,
+						"vnic.0.address_group.0.primary_address", "192.168.100.3"),
+					resource.TestCheckResourceAttr(dataSourceName,
+						"vnic.0.address_group.0.subnet_mask", "255.255.255.0"),
+					resource.TestCheckResourceAttr(dataSourceName,
+						"vnic.0.address_group.0.subnet_prefix_length", "24"),
+					resource.TestCheckResourceAttr(dataSourceName,
+						"vnic.0.address_group.0.secondary_addresses.0", "192.168.100.4"),
+					resource.TestCheckResourceAttr(dataSourceName, "vnic.1.is_connected", "false"),
+					resource.TestCheckResourceAttr(dataSourceName, "vnic.1.address_group.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func testAccPreCheckEdgeDataSource(t *testing.T) {
+
+	testAccPreCheck(t)
+
+	if edgeId == "" {
+		t.Fatal("NSX_EDGE_ID must be set for acceptance tests")
+	}
+}
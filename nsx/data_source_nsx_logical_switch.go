@@ -0,0 +1,127 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/IBM-tfproviders/govnsx/nsxtypes"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	VirtualWireListUriFormat = "%s/api/2.0/vdn/scopes/%s/virtualwires?startIndex=%d&pageSize=%d"
+	VirtualWireUriLocFormat  = "/api/2.0/vdn/virtualwires/%s"
+)
+
+type virtualWires struct {
+	XMLName      xml.Name               `xml:"virtualWires"`
+	PagingInfo   pagingInfo             `xml:"dataPage>pagingInfo"`
+	VirtualWires []nsxtypes.VirtualWire `xml:"dataPage>virtualWire"`
+}
+
+func dataSourceLogicalSwitch() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceLogicalSwitchRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"scope_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"tenant_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"control_plane_mode": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"virtual_wire_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"network_label": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceLogicalSwitchRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	name := d.Get("name").(string)
+	scopeId := d.Get("scope_id").(string)
+
+	vwires, err := listLogicalSwitches(client, scopeId)
+	if err != nil {
+		return err
+	}
+
+	var found *nsxtypes.VirtualWire
+	for i, vwire := range vwires {
+		if vwire.Name != name {
+			continue
+		}
+		if found != nil {
+			return fmt.Errorf("More than one Logical Switch named '%s' found in scope %s: %s, %s",
+				name, scopeId, found.ObjectId, vwire.ObjectId)
+		}
+		found = &vwires[i]
+	}
+
+	if found == nil {
+		return fmt.Errorf("Logical Switch '%s' not found in scope %s", name, scopeId)
+	}
+
+	log.Printf("[INFO] Logical Switch '%s' has id: %s", name, found.ObjectId)
+
+	// Same id as the Location the nsxv_logical_switch resource is keyed by.
+	d.SetId(fmt.Sprintf(VirtualWireUriLocFormat, found.ObjectId))
+	d.Set("virtual_wire_id", found.ObjectId)
+	d.Set("description", found.Description)
+	d.Set("tenant_id", found.TenantId)
+	d.Set("control_plane_mode", found.ControlPlaneMode)
+	d.Set("network_label", fmt.Sprintf(nsxtypes.NetworkLableFormat, found.SwitchOId,
+		found.ObjectId, found.VdnId, found.Name))
+
+	return nil
+}
+
+func listLogicalSwitches(client *govnsx.Client, scopeId string) ([]nsxtypes.VirtualWire, error) {
+
+	vwires := []nsxtypes.VirtualWire{}
+	for startIndex := 0; ; {
+
+		getUri := fmt.Sprintf(VirtualWireListUriFormat, client.MgrConfig.Uri, scopeId,
+			startIndex, listPageSize)
+
+		page := &virtualWires{}
+		if err := nsxGet(client, getUri, page); err != nil {
+			log.Printf("[ERROR] Retriving Logical Switches of scope '%s' failed with error : '%v'",
+				scopeId, err)
+			return nil, err
+		}
+
+		vwires = append(vwires, page.VirtualWires...)
+		startIndex += len(page.VirtualWires)
+
+		if len(page.VirtualWires) == 0 || startIndex >= page.PagingInfo.TotalCount {
+			break
+		}
+	}
+
+	return vwires, nil
+}
@@ -0,0 +1,176 @@
+package nsx
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+const testAccCheckLogicalSwitchDataSourceConf = `
+data "nsxv_logical_switch" "ls" {
+    name = "${nsxv_logical_switch.%s.name}"
+    scope_id = "%s"
+}
+`
+
+const testAccCheckLogicalSwitchDataSourceConf_name = `
+data "nsxv_logical_switch" "ls" {
+    name = "%s"
+    scope_id = "%s"
+}
+`
+
+// testVirtualWiresNsxXML is a page of the logical switches of a scope as
+// returned by NSX.
+const testVirtualWiresNsxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<virtualWires>
+  <dataPage>
+    <pagingInfo>
+      <pageSize>256</pageSize>
+      <startIndex>0</startIndex>
+      <totalCount>1</totalCount>
+      <sortOrderAscending>true</sortOrderAscending>
+      <sortBy>objectId</sortBy>
+    </pagingInfo>
+    <virtualWire>
+      <objectId>virtualwire-7</objectId>
+      <objectTypeName>VirtualWire</objectTypeName>
+      <vsmUuid>420C7C2A-1F08-2C2B-B4E4-5A6F1E7E0C11</vsmUuid>
+      <nodeId>a7bb8a17-0a45-4d0f-9d47-5a2e4b0a4b11</nodeId>
+      <revision>2</revision>
+      <type>
+        <typeName>VirtualWire</typeName>
+      </type>
+      <name>App-Tier-01</name>
+      <description>App tier</description>
+      <clientHandle></clientHandle>
+      <extendedAttributes/>
+      <isUniversal>false</isUniversal>
+      <universalRevision>0</universalRevision>
+      <isTemporal>false</isTemporal>
+      <tenantId>tenant-a</tenantId>
+      <vdnScopeId>vdnscope-1</vdnScopeId>
+      <vdsContextWithBacking>
+        <switch>
+          <objectId>dvs-35</objectId>
+          <objectTypeName>VmwareDistributedVirtualSwitch</objectTypeName>
+          <name>Compute_VDS</name>
+          <revision>12</revision>
+        </switch>
+        <mtu>1600</mtu>
+        <promiscuousMode>false</promiscuousMode>
+        <backingType>portgroup</backingType>
+        <backingValue>dvportgroup-181</backingValue>
+        <missingOnVc>false</missingOnVc>
+      </vdsContextWithBacking>
+      <vdnId>5007</vdnId>
+      <guestVlanAllowed>false</guestVlanAllowed>
+      <controlPlaneMode>HYBRID_MODE</controlPlaneMode>
+      <ctrlLsUuid>5f1b4a2e-8b0b-4c1e-9d0c-3b7c6a0e5d21</ctrlLsUuid>
+      <macLearningEnabled>false</macLearningEnabled>
+    </virtualWire>
+  </dataPage>
+</virtualWires>
+`
+
+func TestAccNsxLogicalSwitchDataSource_Basic(t *testing.T) {
+
+	lsName := "TFT_LS_DS"
+	config := fmt.Sprintf(testAccCheckLogicalSwitchConf, lsName, lsName, vdnScopeId, false) +
+		fmt.Sprintf(testAccCheckLogicalSwitchDataSourceConf, lsName, vdnScopeId)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckLogicalSwitchDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.nsxv_logical_switch.ls", "id", "nsxv_logical_switch."+lsName, "id"),
+					resource.TestCheckResourceAttrPair(
+						"data.nsxv_logical_switch.ls", "virtual_wire_id",
+						"nsxv_logical_switch."+lsName, "virtual_wire_id"),
+					resource.TestCheckResourceAttr(
+						"data.nsxv_logical_switch.ls", "tenant_id", "tf-acc"),
+					resource.TestCheckResourceAttr(
+						"data.nsxv_logical_switch.ls", "control_plane_mode", "UNICAST_MODE"),
+					resource.TestCheckResourceAttrSet(
+						"data.nsxv_logical_switch.ls", "network_label"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxLogicalSwitchDataSource_NotFound(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckLogicalSwitchDataSourceConf_name,
+					"TFT_LS_MISSING", vdnScopeId),
+				ExpectError: regexp.MustCompile("Logical Switch 'TFT_LS_MISSING' not found"),
+			},
+		},
+	})
+}
+
+func TestAccNsxLogicalSwitchDataSource_AmbiguousName(t *testing.T) {
+
+	lsName := "TFT_LS_DUP"
+	config := fmt.Sprintf(testAccCheckLogicalSwitchConf, lsName+"_1", lsName, vdnScopeId, false) +
+		fmt.Sprintf(testAccCheckLogicalSwitchConf, lsName+"_2", lsName, vdnScopeId, false)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckLogicalSwitchDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+			},
+			resource.TestStep{
+				Config: config + fmt.Sprintf(testAccCheckLogicalSwitchDataSourceConf_name,
+					lsName, vdnScopeId),
+				ExpectError: regexp.MustCompile("More than one Logical Switch named 'TFT_LS_DUP' found"),
+			},
+		},
+	})
+}
+
+func TestAccNsxLogicalSwitchDataSource_NsxXML(t *testing.T) {
+
+	if !isTestAccMock() {
+		t.Skip("Serving NSX documents requires the mock NSX Manager")
+	}
+	m := testAccMockNsxManager(t)
+	m.Fixtures["/api/2.0/vdn/scopes/"+mockScopeId+"/virtualwires"] = testVirtualWiresNsxXML
+
+	dataSourceName := "data.nsxv_logical_switch.ls"
+
+	resource.Test(t, resource.TestCase{
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckLogicalSwitchDataSourceConf_name,
+					"App-Tier-01", mockScopeId),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(dataSourceName, "id",
+						"/api/2.0/vdn/virtualwires/virtualwire-7"),
+					resource.TestCheckResourceAttr(dataSourceName, "virtual_wire_id", "virtualwire-7"),
+					resource.TestCheckResourceAttr(dataSourceName, "description", "App tier"),
+					resource.TestCheckResourceAttr(dataSourceName, "tenant_id", "tenant-a"),
+					resource.TestCheckResourceAttr(dataSourceName, "control_plane_mode", "HYBRID_MODE"),
+					resource.TestCheckResourceAttr(dataSourceName, "network_label",
+						"vxw-dvs-35-virtualwire-7-sid-5007-App-Tier-01"),
+				),
+			},
+		},
+	})
+}
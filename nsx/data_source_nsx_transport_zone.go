@@ -0,0 +1,74 @@
+package nsx
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceTransportZone() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceTransportZoneRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"control_plane_mode": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"cluster_ids": &schema.Schema{
+				Type:     schema.TypeSet,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceTransportZoneRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+	name := d.Get("name").(string)
+
+	scopes, err := listTransportZones(client)
+	if err != nil {
+		return err
+	}
+
+	var found *vdnScope
+	for i, scope := range scopes {
+		if scope.Name != name {
+			continue
+		}
+		if found != nil {
+			return fmt.Errorf("More than one Transport Zone named '%s' found: %s, %s",
+				name, found.ObjectId, scope.ObjectId)
+		}
+		found = &scopes[i]
+	}
+
+	if found == nil {
+		return fmt.Errorf("Transport Zone '%s' not found", name)
+	}
+
+	log.Printf("[INFO] Transport Zone '%s' has id: %s", name, found.ObjectId)
+
+	d.SetId(found.ObjectId)
+	d.Set("description", found.Description)
+	d.Set("control_plane_mode", found.ControlPlaneMode)
+	d.Set("cluster_ids", flattenVdnScopeClusters(found.Clusters))
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package nsx
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx/nsxtypes"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+const testAccCheckTransportZoneDataSourceConf = `
+data "nsxv_transport_zone" "tz" {
+    name = "${nsxv_transport_zone.tz.name}"
+}
+`
+
+const testAccCheckTransportZoneDataSourceConf_name = `
+data "nsxv_transport_zone" "tz" {
+    name = "%s"
+}
+`
+
+func TestAccNsxTransportZoneDataSource_Basic(t *testing.T) {
+
+	config := fmt.Sprintf(testAccCheckTransportZoneConf, "tf-acc-tz-ds", "data source",
+		"UNICAST_MODE", `"`+clusterId+`"`) + testAccCheckTransportZoneDataSourceConf
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckTransportZone(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckTransportZoneDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.nsxv_transport_zone.tz", "id", "nsxv_transport_zone.tz", "id"),
+					resource.TestCheckResourceAttr(
+						"data.nsxv_transport_zone.tz", "description", "data source"),
+					resource.TestCheckResourceAttr(
+						"data.nsxv_transport_zone.tz", "control_plane_mode", "UNICAST_MODE"),
+					resource.TestCheckResourceAttr(
+						"data.nsxv_transport_zone.tz", "cluster_ids.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxTransportZoneDataSource_NotFound(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckTransportZoneDataSourceConf_name, "tf-acc-missing"),
+				ExpectError: regexp.MustCompile("Transport Zone 'tf-acc-missing' not found"),
+			},
+		},
+	})
+}
+
+func TestAccNsxTransportZoneDataSource_AmbiguousName(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			if !isTestAccMock() {
+				t.Skip("Two Transport Zones of the same name require the mock NSX Manager")
+			}
+			m := testAccMockNsxManager(t)
+			m.scopes["vdnscope-2"] = &vdnScope{ObjectId: "vdnscope-2", Name: "mock-tz",
+				ControlPlaneMode: nsxtypes.CpmUnicastMode}
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckTransportZoneDataSourceConf_name, "mock-tz"),
+				ExpectError: regexp.MustCompile("More than one Transport Zone named 'mock-tz' found"),
+			},
+		},
+	})
+}
@@ -0,0 +1,439 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/IBM-tfproviders/govnsx/nsxtypes"
+)
+
+//
+// nsxtypes.Edge only models the DHCP feature of an edge. The types below
+// are a superset of it carrying the other settings managed by this provider,
+// so a read-modify-write of the edge sends them back to NSX unchanged.
+//
+
+const (
+	SyslogProtocolUDP = "udp"
+	SyslogProtocolTCP = "tcp"
+
+	EdgeCliSettingsUriFormat = "%s/api/4.0/edges/%s/clisettings"
+	EdgeRoutingUriFormat     = "%s/api/4.0/edges/%s/routing/config"
+	EdgeSyslogUriFormat      = "%s/api/4.0/edges/%s/syslog/config"
+	EdgeDnsUriFormat         = "%s/api/4.0/edges/%s/dns/config"
+	EdgeRoutingGlobalUri     = "%s/api/4.0/edges/%s/routing/config/global"
+	EdgeSummaryUriFormat     = "%s/api/4.0/edges/%s/summary"
+	EdgeActionUriFormat      = "%s/api/4.0/edges/%s?action=%s"
+	EdgeUniversalUri         = "%s/api/4.0/edges?isUniversal=true"
+	ManagerGlobalInfoUri     = "%s/api/1.0/appliance-management/global/info"
+
+	EdgeActionRedeploy = "redeploy"
+	EdgeActionUpgrade  = "upgrade"
+
+	DnsListenerAny = "any"
+	DnsDefaultView = "vsm-default-view"
+
+	RoutingLogLevelDefault = "info"
+
+	// Attempts to update an edge changed since it was read.
+	EdgeUpdateAttempts = 3
+)
+
+var syslogProtocolsList = []string{
+	string(SyslogProtocolUDP),
+	string(SyslogProtocolTCP),
+}
+
+var routingLogLevelsList = []string{
+	"emergency",
+	"alert",
+	"critical",
+	"error",
+	"warning",
+	"notice",
+	"info",
+	"debug",
+}
+
+type edgeSyslog struct {
+	XMLName         xml.Name `xml:"syslog"`
+	Version         string   `xml:"version,omitempty"`
+	Enabled         bool     `xml:"enabled"`
+	Protocol        string   `xml:"protocol,omitempty"`
+	ServerAddresses []string `xml:"serverAddresses>ipAddress,omitempty"`
+}
+
+// addressGroup extends nsxtypes.AddressGroup with the prefix length, the only
+// form of IPv6 netmask, and the secondary addresses.
+type addressGroup struct {
+	PrimaryAddress     string   `xml:"primaryAddress"`
+	SubnetMask         string   `xml:"subnetMask,omitempty"`
+	SubnetPrefixLength int      `xml:"subnetPrefixLength,omitempty"`
+	SecondaryAddresses []string `xml:"secondaryAddresses>ipAddress,omitempty"`
+}
+
+type edgeVnic struct {
+	Index         string         `xml:"index"`
+	PortgroupId   string         `xml:"portgroupId,omitempty"`
+	AddressGroups []addressGroup `xml:"addressGroups>addressGroup,omitempty"`
+	IsConnected   bool           `xml:"isConnected"`
+	Mtu           string         `xml:"mtu,omitempty"`
+	Type          string         `xml:"type,omitempty"`
+}
+
+// edgeAppliance extends nsxtypes.Appliance with the host and VM folder
+// placement of the appliance.
+type edgeAppliance struct {
+	ResourcePoolId string `xml:"resourcePoolId"`
+	DatastoreId    string `xml:"datastoreId"`
+	HostId         string `xml:"hostId,omitempty"`
+	VmFolderId     string `xml:"vmFolderId,omitempty"`
+	Deployed       bool   `xml:"deployed,omitempty"`
+}
+
+type edgeAppliances struct {
+	AppliancesList   []edgeAppliance `xml:"appliance"`
+	DeployAppliances bool            `xml:"deployAppliances"`
+	ApplianceSize    string          `xml:"applianceSize,omitempty"`
+}
+
+// edgeCliSettings are the credentials and SSH access of the edge appliances.
+// NSX never returns the password.
+type edgeCliSettings struct {
+	XMLName            xml.Name `xml:"cliSettings"`
+	UserName           string   `xml:"userName,omitempty"`
+	Password           string   `xml:"password,omitempty"`
+	RemoteAccess       bool     `xml:"remoteAccess"`
+	PasswordExpiry     int      `xml:"passwordExpiry,omitempty"` // days
+	SshLoginBannerText string   `xml:"sshLoginBannerText,omitempty"`
+}
+
+type managerVersionInfo struct {
+	MajorVersion string `xml:"majorVersion"`
+	MinorVersion string `xml:"minorVersion"`
+	PatchVersion string `xml:"patchVersion"`
+	BuildNumber  string `xml:"buildNumber"`
+}
+
+// managerGlobalInfo is the document returned by the NSX Manager appliance
+// management global info API.
+type managerGlobalInfo struct {
+	XMLName     xml.Name           `xml:"globalInfo"`
+	VersionInfo managerVersionInfo `xml:"versionInfo"`
+}
+
+type edgeDnsClient struct {
+	PrimaryDns   string `xml:"primaryDns,omitempty"`
+	SecondaryDns string `xml:"secondaryDns,omitempty"`
+	DomainName   string `xml:"domainName,omitempty"`
+}
+
+type edgeDnsViewMatch struct {
+	IpAddress []string `xml:"ipAddress,omitempty"`
+	Vnic      []string `xml:"vnic,omitempty"`
+}
+
+type edgeDnsView struct {
+	ViewId     string           `xml:"viewId,omitempty"`
+	Name       string           `xml:"name"`
+	Enabled    bool             `xml:"enabled"`
+	ViewMatch  edgeDnsViewMatch `xml:"viewMatch"`
+	Recursion  bool             `xml:"recursion"`
+	Forwarders []string         `xml:"forward>ipSet>ipAddress,omitempty"`
+}
+
+// edgeDns is the DNS forwarder service of an edge.
+type edgeDns struct {
+	XMLName   xml.Name      `xml:"dns"`
+	Version   string        `xml:"version,omitempty"`
+	Enabled   bool          `xml:"enabled"`
+	CacheSize int           `xml:"cacheSize,omitempty"` // MB
+	Listeners []string      `xml:"listeners>ipAddress,omitempty"`
+	DnsViews  []edgeDnsView `xml:"dnsViews>dnsView,omitempty"`
+}
+
+// edgeMgmtInterface is the HA interface of a distributed router, it
+// connects the control VMs to the management network.
+type edgeMgmtInterface struct {
+	ConnectedToId string         `xml:"connectedToId"`
+	AddressGroups []addressGroup `xml:"addressGroups>addressGroup,omitempty"`
+}
+
+type edgeRoutingLogging struct {
+	Enable   bool   `xml:"enable"`
+	LogLevel string `xml:"logLevel,omitempty"`
+}
+
+type edgeRoutingGlobalConfig struct {
+	XMLName  xml.Name            `xml:"routingGlobalConfig"`
+	RouterId string              `xml:"routerId,omitempty"`
+	Ecmp     bool                `xml:"ecmp"`
+	Logging  *edgeRoutingLogging `xml:"logging,omitempty"`
+	Other    []rawXML            `xml:",any"` // IP prefixes
+}
+
+// edgeRouting models the global configuration of the routing feature. The
+// static routes and the routing protocols are kept as they were received.
+type edgeRouting struct {
+	XMLName             xml.Name                 `xml:"routing"`
+	Version             string                   `xml:"version,omitempty"`
+	Enabled             bool                     `xml:"enabled"`
+	RoutingGlobalConfig *edgeRoutingGlobalConfig `xml:"routingGlobalConfig,omitempty"`
+	Other               []rawXML                 `xml:",any"`
+}
+
+type edgeFeatures struct {
+	Dhcp    nsxtypes.DHCPConfig `xml:"dhcp"`
+	Syslog  *edgeSyslog         `xml:"syslog,omitempty"`
+	Dns     *edgeDns            `xml:"dns,omitempty"`
+	Routing *edgeRouting        `xml:"routing,omitempty"`
+}
+
+// edgeConfig is the edge document exchanged with NSX Manager.
+type edgeConfig struct {
+	XMLName       xml.Name           `xml:"edge"`
+	Id            string             `xml:"id,omitempty"`
+	Version       string             `xml:"version,omitempty"`
+	Datacenter    string             `xml:"datacenterMoid,omitempty"`
+	Description   string             `xml:"description,omitempty"`
+	Status        string             `xml:"status,omitempty"`
+	Tenant        string             `xml:"tenant,omitempty"`
+	Name          string             `xml:"name,omitempty"`
+	Type          string             `xml:"type,omitempty"`
+	EnableFips    bool               `xml:"enableFips,omitempty"`
+	IsUniversal   bool               `xml:"isUniversal,omitempty"`
+	Appliances    edgeAppliances     `xml:"appliances"`
+	Vnics         []edgeVnic         `xml:"vnics>vnic,omitempty"`
+	MgmtInterface *edgeMgmtInterface `xml:"mgmtInterface,omitempty"`
+	CliSettings   *edgeCliSettings   `xml:"cliSettings,omitempty"`
+	DnsClient     *edgeDnsClient     `xml:"dnsClient,omitempty"`
+	Features      edgeFeatures       `xml:"features"`
+}
+
+func getEdge(client *govnsx.Client, edgeId string) (*edgeConfig, error) {
+
+	getUri := fmt.Sprintf(nsxtypes.EdgeUriLocFormat, client.MgrConfig.Uri, edgeId)
+
+	edgeCfg := &edgeConfig{}
+	if err := nsxGet(client, getUri, edgeCfg); err != nil {
+		log.Printf("[ERROR] Retriving Edge '%s' failed with error : '%v'", edgeId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] Edge details of '%s': '%v'", edgeId, edgeCfg)
+	return edgeCfg, nil
+}
+
+// updateEdge sends an edge back to NSX with the version it was read at, NSX
+// rejects the update when the edge was changed since.
+func updateEdge(client *govnsx.Client, edgeCfg *edgeConfig) error {
+
+	putUri := fmt.Sprintf(nsxtypes.EdgeUriLocFormat, client.MgrConfig.Uri, edgeCfg.Id)
+
+	// The status is read only.
+	edgeSpec := *edgeCfg
+	edgeSpec.Status = ""
+	// The CLI settings read back lack the password, they are changed
+	// through updateEdgeCliSettings only.
+	edgeSpec.CliSettings = nil
+
+	if err := nsxPut(client, putUri, &edgeSpec); err != nil {
+		log.Printf("[ERROR] Updating Edge '%s' failed with error : '%v'", edgeCfg.Id, err)
+		return err
+	}
+
+	log.Printf("[INFO] Updated Edge '%s'", edgeCfg.Id)
+	return nil
+}
+
+// modifyEdge reads an edge, changes it with modify and updates it. The edge
+// is read and changed again when it was changed by someone else in between.
+// The caller holds the edge lock, so the changes of this provider are
+// serialised already.
+func modifyEdge(client *govnsx.Client, edgeId string,
+	modify func(edgeCfg *edgeConfig) error) (*edgeConfig, error) {
+
+	for attempt := 1; ; attempt++ {
+		edgeCfg, err := getEdge(client, edgeId)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := modify(edgeCfg); err != nil {
+			return nil, err
+		}
+
+		err = updateEdge(client, edgeCfg)
+		if err == nil {
+			return edgeCfg, nil
+		}
+		if !isVersionConflictError(err) || attempt == EdgeUpdateAttempts {
+			return nil, err
+		}
+
+		log.Printf("[WARN] Edge '%s' changed since version %s was read, updating it again",
+			edgeId, edgeCfg.Version)
+	}
+}
+
+// isVersionConflictError reports whether NSX rejected an update of an
+// object changed since it was read.
+func isVersionConflictError(err error) bool {
+	if apiErr, ok := err.(*nsxAPIError); ok {
+		return apiErr.StatusCode == http.StatusConflict
+	}
+	return false
+}
+
+// createEdge creates an edge and returns its id and location.
+func createEdge(client *govnsx.Client, edgeCfg *edgeConfig) (string, string, error) {
+
+	postUri := fmt.Sprintf(nsxtypes.EdgeUriFormat, client.MgrConfig.Uri)
+	if edgeCfg.IsUniversal {
+		postUri = fmt.Sprintf(EdgeUniversalUri, client.MgrConfig.Uri)
+	}
+
+	location, _, err := nsxPost(client, postUri, edgeCfg)
+	if err != nil {
+		log.Printf("[ERROR] Edge Creation failed. %v", err)
+		return "", "", err
+	}
+
+	return path.Base(location), location, nil
+}
+
+func updateEdgeCliSettings(client *govnsx.Client, edgeId string,
+	cliSettings *edgeCliSettings) error {
+
+	putUri := fmt.Sprintf(EdgeCliSettingsUriFormat, client.MgrConfig.Uri, edgeId)
+
+	if err := nsxPut(client, putUri, cliSettings); err != nil {
+		log.Printf("[ERROR] Updating CLI settings of Edge '%s' failed with error : '%v'",
+			edgeId, err)
+		return err
+	}
+
+	log.Printf("[INFO] Updated CLI settings of Edge '%s'", edgeId)
+	return nil
+}
+
+func updateEdgeSyslog(client *govnsx.Client, edgeId string, syslog *edgeSyslog) error {
+
+	putUri := fmt.Sprintf(EdgeSyslogUriFormat, client.MgrConfig.Uri, edgeId)
+
+	if err := nsxPut(client, putUri, syslog); err != nil {
+		log.Printf("[ERROR] Updating syslog of Edge '%s' failed with error : '%v'",
+			edgeId, err)
+		return err
+	}
+
+	log.Printf("[INFO] Updated syslog of Edge '%s'", edgeId)
+	return nil
+}
+
+func updateEdgeDns(client *govnsx.Client, edgeId string, dns *edgeDns) error {
+
+	putUri := fmt.Sprintf(EdgeDnsUriFormat, client.MgrConfig.Uri, edgeId)
+
+	if err := nsxPut(client, putUri, dns); err != nil {
+		log.Printf("[ERROR] Updating DNS forwarder of Edge '%s' failed with error : '%v'",
+			edgeId, err)
+		return err
+	}
+
+	log.Printf("[INFO] Updated DNS forwarder of Edge '%s'", edgeId)
+	return nil
+}
+
+func getEdgeRouting(client *govnsx.Client, edgeId string) (*edgeRouting, error) {
+
+	getUri := fmt.Sprintf(EdgeRoutingUriFormat, client.MgrConfig.Uri, edgeId)
+
+	routing := &edgeRouting{}
+	if err := nsxGet(client, getUri, routing); err != nil {
+		log.Printf("[ERROR] Retriving routing of Edge '%s' failed with error : '%v'",
+			edgeId, err)
+		return nil, err
+	}
+
+	return routing, nil
+}
+
+func updateEdgeRouting(client *govnsx.Client, edgeId string, routing *edgeRouting) error {
+
+	putUri := fmt.Sprintf(EdgeRoutingUriFormat, client.MgrConfig.Uri, edgeId)
+
+	if err := nsxPut(client, putUri, routing); err != nil {
+		log.Printf("[ERROR] Updating routing of Edge '%s' failed with error : '%v'",
+			edgeId, err)
+		return err
+	}
+
+	log.Printf("[INFO] Updated routing of Edge '%s'", edgeId)
+	return nil
+}
+
+func updateEdgeRoutingGlobalConfig(client *govnsx.Client, edgeId string,
+	globalConfig *edgeRoutingGlobalConfig) error {
+
+	putUri := fmt.Sprintf(EdgeRoutingGlobalUri, client.MgrConfig.Uri, edgeId)
+
+	if err := nsxPut(client, putUri, globalConfig); err != nil {
+		log.Printf("[ERROR] Updating routing global config of Edge '%s' failed with error : '%v'",
+			edgeId, err)
+		return err
+	}
+
+	log.Printf("[INFO] Updated routing global config of Edge '%s'", edgeId)
+	return nil
+}
+
+func getEdgeSummary(client *govnsx.Client, edgeId string) (*edgeSummary, error) {
+
+	getUri := fmt.Sprintf(EdgeSummaryUriFormat, client.MgrConfig.Uri, edgeId)
+
+	summary := &edgeSummary{}
+	if err := nsxGet(client, getUri, summary); err != nil {
+		log.Printf("[ERROR] Retriving summary of Edge '%s' failed with error : '%v'",
+			edgeId, err)
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// getManagerVersion returns the NSX Manager version as major.minor.patch,
+// the format of the edge appliance versions.
+func getManagerVersion(client *govnsx.Client) (string, error) {
+
+	getUri := fmt.Sprintf(ManagerGlobalInfoUri, client.MgrConfig.Uri)
+
+	info := &managerGlobalInfo{}
+	if err := nsxGet(client, getUri, info); err != nil {
+		log.Printf("[ERROR] Retriving NSX Manager version failed with error : '%v'", err)
+		return "", err
+	}
+
+	v := info.VersionInfo
+	return fmt.Sprintf("%s.%s.%s", v.MajorVersion, v.MinorVersion, v.PatchVersion), nil
+}
+
+// edgeAction starts a lifecycle action, redeploy or upgrade, on an edge.
+func edgeAction(client *govnsx.Client, edgeId string, action string) error {
+
+	postUri := fmt.Sprintf(EdgeActionUriFormat, client.MgrConfig.Uri, edgeId, action)
+
+	log.Printf("[INFO] Starting action '%s' on Edge '%s'", action, edgeId)
+
+	if _, _, err := nsxPost(client, postUri, nil); err != nil {
+		log.Printf("[ERROR] Action '%s' on Edge '%s' failed with error : '%v'",
+			action, edgeId, err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/IBM-tfproviders/govnsx/nsxtypes"
+)
+
+// dlrInterface is nsxtypes.EdgeDLRInterface with IPv6 capable address groups.
+type dlrInterface struct {
+	Label           string         `xml:"label,omitempty"`
+	Name            string         `xml:"name,omitempty"`
+	AddressGroups   []addressGroup `xml:"addressGroups>addressGroup,omitempty"`
+	Mtu             string         `xml:"mtu,omitempty"`
+	Type            string         `xml:"type,omitempty"`
+	IsConnected     bool           `xml:"isConnected,omitempty"`
+	IsSharedNetwork bool           `xml:"isSharedNetwork,omitempty"`
+	Index           string         `xml:"index,omitempty"`
+	ConnectedToId   string         `xml:"connectedToId"`
+	ConnectedToName string         `xml:"connectedToName,omitempty"`
+}
+
+type dlrInterfaces struct {
+	XMLName    xml.Name       `xml:"interfaces"`
+	Interfaces []dlrInterface `xml:"interface"`
+}
+
+func getDLRInterfaces(client *govnsx.Client, edgeId string) (*dlrInterfaces, error) {
+
+	getUri := fmt.Sprintf(nsxtypes.EdgeDLRGetInterfaceUriFormat, client.MgrConfig.Uri, edgeId)
+
+	ifaces := &dlrInterfaces{}
+	if err := nsxGet(client, getUri, ifaces); err != nil {
+		log.Printf("[ERROR] Retriving Edge Interfaces %s failed with error : '%v'", edgeId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] Retrieved Edge Interfaces %v", ifaces)
+	return ifaces, nil
+}
+
+func addDLRInterfaces(client *govnsx.Client, edgeId string, ifaces []dlrInterface) error {
+
+	postUri := fmt.Sprintf(nsxtypes.EdgeDLRAddInterfacesUriFormat, client.MgrConfig.Uri, edgeId)
+
+	if _, _, err := nsxPost(client, postUri, &dlrInterfaces{Interfaces: ifaces}); err != nil {
+		log.Printf("[ERROR] Adding interfaces to Edge %s failed with error : %v", edgeId, err)
+		return err
+	}
+
+	return nil
+}
+
+// deleteDLRInterface deletes the interface index of the edge, all of them
+// when index is empty.
+func deleteDLRInterface(client *govnsx.Client, edgeId string, index string) error {
+
+	deleteUri := fmt.Sprintf(nsxtypes.EdgeDLRDelAllInterfacesUriFormat, client.MgrConfig.Uri, edgeId)
+	if index != "" {
+		deleteUri = fmt.Sprintf(nsxtypes.EdgeDLRDelbyIndexInterfacesUriFormat,
+			client.MgrConfig.Uri, edgeId, index)
+	}
+
+	if err := nsxDelete(client, deleteUri); err != nil {
+		log.Printf("[ERROR] Deleting interface '%s' of Edge %s failed with error : %v",
+			index, edgeId, err)
+		return err
+	}
+
+	return nil
+}
+
+// newAddressGroup builds the address group of a primary address, its
+// netmask given either as a dotted IPv4 mask or as a prefix length, and its
+// secondary addresses.
+func newAddressGroup(ip string, mask string, prefixLength int,
+	secondaryIPs []string) (addressGroup, error) {
+
+	addrGroup := addressGroup{PrimaryAddress: ip}
+
+	primaryIP := net.ParseIP(ip)
+	if primaryIP == nil {
+		return addrGroup, fmt.Errorf("IP '%s' is not valid.", ip)
+	}
+
+	maxPrefixLength := 8 * net.IPv6len
+	if isIPv4(primaryIP) {
+		maxPrefixLength = 8 * net.IPv4len
+	}
+
+	switch {
+	case mask != "" && prefixLength != 0:
+		return addrGroup, fmt.Errorf("IP '%s': only one of mask or prefix_length can be set.", ip)
+	case mask != "":
+		if !isIPv4(primaryIP) {
+			return addrGroup, fmt.Errorf("IP '%s': IPv6 addresses need a prefix_length, not a mask.", ip)
+		}
+		addrGroup.SubnetMask = mask
+	case prefixLength > 0 && prefixLength <= maxPrefixLength:
+		addrGroup.SubnetPrefixLength = prefixLength
+	default:
+		return addrGroup, fmt.Errorf("IP '%s': one of mask or a prefix_length "+
+			"between 1 and %d must be set.", ip, maxPrefixLength)
+	}
+
+	for _, secondaryIP := range secondaryIPs {
+		sip := net.ParseIP(secondaryIP)
+		if sip == nil || isIPv4(sip) != isIPv4(primaryIP) {
+			return addrGroup, fmt.Errorf("Secondary IP '%s' is not an address of the "+
+				"same IP version as '%s'.", secondaryIP, ip)
+		}
+		addrGroup.SecondaryAddresses = append(addrGroup.SecondaryAddresses, secondaryIP)
+	}
+
+	return addrGroup, nil
+}
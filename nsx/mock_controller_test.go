@@ -0,0 +1,141 @@
+package nsx
+
+import (
+	"net/http"
+)
+
+// Handlers of the mock NSX Manager for the controller endpoints.
+
+// removeController deletes the controller and releases its address.
+func (m *mockNsxManager) removeController(controller *nsxController) {
+
+	if pool := m.ipPools[m.ctrlPools[controller.Id]]; pool != nil {
+		m.releaseIPAddress(pool, controller.IpAddress)
+	}
+	delete(m.controllers, controller.Id)
+	delete(m.ctrlPools, controller.Id)
+}
+
+const (
+	mockControllerStatusDeploying = "DEPLOYING"
+	mockControllerStatusRemoving  = "REMOVING"
+)
+
+// mockControllerJob is a controller deployment job.
+type mockControllerJob struct {
+	controllerId string
+	pendingPolls int
+	failure      string
+}
+
+func (m *mockNsxManager) serveControllers(w http.ResponseWriter, r *http.Request,
+	parts []string, body []byte) {
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		list := &nsxControllerList{}
+		for _, controller := range m.controllers {
+			list.Controllers = append(list.Controllers, *controller)
+			// Removed controllers are listed once more, like NSX
+			// removes them asynchronously.
+			if controller.Status == mockControllerStatusRemoving {
+				m.removeController(controller)
+			}
+		}
+		writeXML(w, http.StatusOK, list)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		spec := &controllerSpec{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		pool, ok := m.ipPools[spec.IpPoolId]
+		if !ok {
+			writeXML(w, http.StatusBadRequest, &nsxError{ErrorCode: 120051,
+				Details: "IP pool " + spec.IpPoolId + " not found"})
+			return
+		}
+		allocated := m.allocateIPAddress(pool, &ipAddressRequest{
+			AllocationMode: IPAllocationModeAllocate})
+		if allocated == nil {
+			writeXML(w, http.StatusBadRequest, &nsxError{ErrorCode: 120054,
+				Details: "No IP address available in the IP pool"})
+			return
+		}
+
+		controllerId := m.newId("controller")
+		m.controllers[controllerId] = &nsxController{
+			Id:                 controllerId,
+			Name:               spec.Name,
+			Description:        spec.Description,
+			IpAddress:          allocated.IpAddress,
+			Status:             mockControllerStatusDeploying,
+			VirtualMachineInfo: objectRef{ObjectId: m.newId("vm")},
+			HostInfo:           objectRef{ObjectId: spec.HostId},
+			ResourcePoolInfo:   objectRef{ObjectId: spec.ResourcePoolId},
+			DatastoreInfo:      objectRef{ObjectId: spec.DatastoreId},
+			ConnectedToInfo:    objectRef{ObjectId: spec.NetworkId},
+		}
+		if spec.HostId == "" {
+			m.controllers[controllerId].HostInfo.ObjectId = "host-1"
+		}
+		m.ctrlPools[controllerId] = pool.ObjectId
+		m.ctrlPassword = spec.Password
+
+		jobId := m.newId("jobdata")
+		m.ctrlJobs[jobId] = &mockControllerJob{controllerId: controllerId,
+			pendingPolls: m.ControllerPolls, failure: m.ControllerFailure}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(jobId))
+	case len(parts) == 1 && parts[0] == "credential" && r.Method == http.MethodPut:
+		credential := &controllerCredential{}
+		if !readXML(w, body, credential) {
+			return
+		}
+		m.ctrlPassword = credential.ApiPassword
+		w.WriteHeader(http.StatusOK)
+	case len(parts) == 2 && parts[0] == "progress" && r.Method == http.MethodGet:
+		job, ok := m.ctrlJobs[parts[1]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		controller := m.controllers[job.controllerId]
+		info := &controllerDeploymentInfo{Status: "InProgress"}
+		if controller != nil {
+			info.VmId = controller.VirtualMachineInfo.ObjectId
+		}
+		switch {
+		case job.pendingPolls > 0:
+			job.pendingPolls--
+		case job.failure != "":
+			info.Status = ControllerJobStatusFailure
+			info.ExceptionMessage = job.failure
+			info.VmId = ""
+			if controller != nil {
+				m.removeController(controller)
+			}
+		default:
+			info.Status = ControllerJobStatusSuccess
+			if controller != nil && controller.Status == mockControllerStatusDeploying {
+				controller.Status = ControllerStatusRunning
+			}
+		}
+		writeXML(w, http.StatusOK, info)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		controller, ok := m.controllers[parts[0]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if len(m.controllers) == 1 && r.URL.Query().Get("forceRemoval") != "true" {
+			writeXML(w, http.StatusBadRequest, &nsxError{ErrorCode: 202033,
+				Details: "Cannot remove the last controller without forceRemoval"})
+			return
+		}
+		controller.Status = mockControllerStatusRemoving
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(m.newId("jobdata")))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,532 @@
+package nsx
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/IBM-tfproviders/govnsx/nsxtypes"
+)
+
+// Handlers of the mock NSX Manager for the edge and DLR endpoints.
+
+func newMockEdge(edgeId string, edgeType string, spec *edgeConfig) *edgeConfig {
+
+	edge := &edgeConfig{
+		Id:            edgeId,
+		Version:       "1",
+		Status:        EdgeStatusDeployed,
+		Type:          edgeType,
+		Name:          spec.Name,
+		Description:   spec.Description,
+		Tenant:        spec.Tenant,
+		EnableFips:    spec.EnableFips,
+		IsUniversal:   spec.IsUniversal,
+		Appliances:    spec.Appliances,
+		CliSettings:   spec.CliSettings,
+		DnsClient:     spec.DnsClient,
+		MgmtInterface: spec.MgmtInterface,
+		Features:      spec.Features,
+	}
+
+	if edge.CliSettings == nil {
+		edge.CliSettings = &edgeCliSettings{UserName: EdgeCliDefaultUserName,
+			PasswordExpiry: EdgeCliDefaultPasswordExpiry}
+	}
+
+	if edgeType == EdgeTypeGatewayServices {
+		for i := 0; i < mockVnicCount; i++ {
+			edge.Vnics = append(edge.Vnics, edgeVnic{
+				Index: strconv.Itoa(i),
+				Type:  "internal",
+			})
+		}
+		for _, vnic := range spec.Vnics {
+			if i, err := strconv.Atoi(vnic.Index); err == nil && i < mockVnicCount {
+				edge.Vnics[i] = vnic
+			}
+		}
+	}
+
+	return edge
+}
+
+func (m *mockNsxManager) serveEdges(w http.ResponseWriter, r *http.Request,
+	parts []string, body []byte) {
+
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			list := &pagedEdgeList{}
+			for _, edge := range m.edges {
+				list.EdgeSummarys = append(list.EdgeSummarys, edgeSummary{
+					ObjectId: edge.Id, Name: edge.Name, EdgeType: edge.Type})
+			}
+			list.PagingInfo.TotalCount = len(list.EdgeSummarys)
+			writeXML(w, http.StatusOK, list)
+		case http.MethodPost:
+			spec := &edgeConfig{}
+			if !readXML(w, body, spec) {
+				return
+			}
+			edgeType := spec.Type
+			if edgeType == "" {
+				edgeType = EdgeTypeGatewayServices
+			}
+			if msg := checkMockEdgeHaInterface(edgeType, spec); msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			spec.IsUniversal = r.URL.Query().Get("isUniversal") == "true"
+			if spec.IsUniversal {
+				if edgeType != EdgeTypeDistributedRouter {
+					http.Error(w, "only distributed routers can be universal",
+						http.StatusBadRequest)
+					return
+				}
+				if m.rejectUniversal(w) {
+					return
+				}
+			}
+			edgeId := m.newId("edge")
+			m.edges[edgeId] = newMockEdge(edgeId, edgeType, spec)
+			m.edgeVersions[edgeId] = m.ManagerVersion
+			m.pendingPolls[edgeId] = m.StatusPolls
+			w.Header().Set("Location", "/api/4.0/edges/"+edgeId)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	edge, ok := m.edges[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		m.pendingPolls[edge.Id] = m.StatusPolls
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			resp := *edge
+			cliSettings := *edge.CliSettings
+			cliSettings.Password = ""
+			resp.CliSettings = &cliSettings
+			writeXML(w, http.StatusOK, &resp)
+		case http.MethodPut:
+			spec := &edgeConfig{}
+			if !readXML(w, body, spec) {
+				return
+			}
+			if spec.Version != edge.Version {
+				writeXML(w, http.StatusConflict, &nsxError{
+					Details: fmt.Sprintf("Edge %s was changed since version %s, the current version is %s.",
+						edge.Id, spec.Version, edge.Version),
+					ModuleName: "vShield Edge",
+				})
+				return
+			}
+			if msg := checkMockEdgeHaInterface(edge.Type, spec); msg != "" {
+				http.Error(w, msg, http.StatusBadRequest)
+				return
+			}
+			if spec.Name != "" {
+				edge.Name = spec.Name
+			}
+			if spec.Description != "" {
+				edge.Description = spec.Description
+			}
+			if spec.Tenant != "" {
+				edge.Tenant = spec.Tenant
+			}
+			edge.Appliances = spec.Appliances
+			if edge.Type == EdgeTypeGatewayServices {
+				edge.Vnics = spec.Vnics
+			}
+			if spec.CliSettings != nil {
+				edge.CliSettings = spec.CliSettings
+			}
+			edge.DnsClient = spec.DnsClient
+			edge.MgmtInterface = spec.MgmtInterface
+			edge.Features = spec.Features
+			m.setDHCPPoolIds(&edge.Features.Dhcp)
+			edge.Version = strconv.Itoa(atoi(edge.Version) + 1)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodPost:
+			switch r.URL.Query().Get("action") {
+			case EdgeActionRedeploy:
+				m.redeploys[edge.Id]++
+			case EdgeActionUpgrade:
+				m.edgeVersions[edge.Id] = m.ManagerVersion
+			default:
+				http.Error(w, "unsupported action", http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			delete(m.edges, edge.Id)
+			delete(m.pendingPolls, edge.Id)
+			delete(m.dlrIfaces, edge.Id)
+			delete(m.dlrBridges, edge.Id)
+			delete(m.edgeVersions, edge.Id)
+			delete(m.redeploys, edge.Id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	// Every change made through the feature APIs is a new edge version.
+	if r.Method != http.MethodGet {
+		rec := &mockStatusRecorder{ResponseWriter: w}
+		w = rec
+		defer func() {
+			if rec.status >= 200 && rec.status < 300 {
+				edge.Version = strconv.Itoa(atoi(edge.Version) + 1)
+			}
+		}()
+	}
+
+	switch parts[1] {
+	case "status":
+		m.serveEdgeStatus(w, r, edge)
+	case "summary":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		summary := &edgeSummary{ObjectId: edge.Id, Name: edge.Name, EdgeType: edge.Type}
+		summary.AppliancesSummary.VmVersion = m.edgeVersions[edge.Id]
+		writeXML(w, http.StatusOK, summary)
+	case "dhcp":
+		m.serveEdgeDHCP(w, r, edge, parts[2:], body)
+	case "interfaces":
+		m.serveEdgeDLRInterfaces(w, r, edge, body)
+	case "bridging":
+		m.serveEdgeDLRBridging(w, r, edge, body)
+	case "routing":
+		m.serveEdgeRouting(w, r, edge, parts[2:], body)
+	case "syslog":
+		if len(parts) != 3 || parts[2] != "config" {
+			http.NotFound(w, r)
+			return
+		}
+		syslog := &edgeSyslog{}
+		if m.serveEdgeFeature(w, r, edge.Features.Syslog, syslog, body) {
+			edge.Features.Syslog = syslog
+		}
+	case "dns":
+		if len(parts) != 3 || parts[2] != "config" {
+			http.NotFound(w, r)
+			return
+		}
+		dns := &edgeDns{}
+		if m.serveEdgeFeature(w, r, edge.Features.Dns, dns, body) {
+			edge.Features.Dns = dns
+		}
+	case "clisettings":
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		cliSettings := &edgeCliSettings{}
+		if !readXML(w, body, cliSettings) {
+			return
+		}
+		edge.CliSettings = cliSettings
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveEdgeRouting serves the routing config of an edge, and its global
+// part.
+func (m *mockNsxManager) serveEdgeRouting(w http.ResponseWriter, r *http.Request,
+	edge *edgeConfig, parts []string, body []byte) {
+
+	if len(parts) == 0 || parts[0] != "config" || len(parts) > 2 ||
+		(len(parts) == 2 && parts[1] != "global") {
+		http.NotFound(w, r)
+		return
+	}
+
+	routing := edge.Features.Routing
+	if routing == nil {
+		routing = &edgeRouting{}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if len(parts) == 2 {
+			writeXML(w, http.StatusOK, routing.RoutingGlobalConfig)
+			return
+		}
+		writeXML(w, http.StatusOK, routing)
+		return
+	case http.MethodPut:
+		if len(parts) == 2 {
+			globalConfig := &edgeRoutingGlobalConfig{}
+			if !readXML(w, body, globalConfig) {
+				return
+			}
+			routing.RoutingGlobalConfig = globalConfig
+		} else {
+			spec := &edgeRouting{}
+			if !readXML(w, body, spec) {
+				return
+			}
+			routing = spec
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	routing.Version = strconv.Itoa(atoi(routing.Version) + 1)
+	edge.Features.Routing = routing
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveEdgeFeature serves the config of an edge feature. It returns true
+// when spec was read from a PUT and replaces the current config.
+func (m *mockNsxManager) serveEdgeFeature(w http.ResponseWriter, r *http.Request,
+	current interface{}, spec interface{}, body []byte) bool {
+
+	switch r.Method {
+	case http.MethodGet:
+		if reflect.ValueOf(current).IsNil() {
+			current = spec
+		}
+		writeXML(w, http.StatusOK, current)
+	case http.MethodPut:
+		if !readXML(w, body, spec) {
+			return false
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+	return false
+}
+
+// mockStatusRecorder keeps the status of a response.
+type mockStatusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *mockStatusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// checkMockEdgeHaInterface returns the error of NSX Manager for an HA
+// interface on a services gateway, or an HA pair of distributed router
+// control VMs without one.
+func checkMockEdgeHaInterface(edgeType string, spec *edgeConfig) string {
+
+	if edgeType != EdgeTypeDistributedRouter {
+		if spec.MgmtInterface != nil {
+			return "mgmtInterface is only supported on distributed routers"
+		}
+		return ""
+	}
+	if len(spec.Appliances.AppliancesList) > 1 && spec.MgmtInterface == nil {
+		return "HA interface must be configured for HA"
+	}
+	return ""
+}
+
+func (m *mockNsxManager) serveEdgeStatus(w http.ResponseWriter, r *http.Request,
+	edge *edgeConfig) {
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := &edgeStatus{
+		EdgeStatus:    "GREEN",
+		PublishStatus: EdgePublishStatusApplied,
+		Version:       edge.Version,
+	}
+	if m.pendingPolls[edge.Id] > 0 {
+		m.pendingPolls[edge.Id]--
+		status.PublishStatus = "PERSISTED"
+	}
+	writeXML(w, http.StatusOK, status)
+}
+
+func (m *mockNsxManager) setDHCPPoolIds(dhcp *nsxtypes.DHCPConfig) {
+	for i := range dhcp.IPPools {
+		if dhcp.IPPools[i].PoolId == "" {
+			dhcp.IPPools[i].PoolId = m.newId("pool")
+		}
+	}
+}
+
+func (m *mockNsxManager) serveEdgeDHCP(w http.ResponseWriter, r *http.Request,
+	edge *edgeConfig, parts []string, body []byte) {
+
+	dhcp := &edge.Features.Dhcp
+
+	switch {
+	case len(parts) == 1 && parts[0] == "config":
+		switch r.Method {
+		case http.MethodGet:
+			writeXML(w, http.StatusOK, dhcp)
+		case http.MethodPut:
+			spec := &nsxtypes.ConfigDHCPServiceSpec{}
+			if !readXML(w, body, spec) {
+				return
+			}
+			dhcp.Enabled = true
+			dhcp.IPPools = spec.IPPools
+			dhcp.Logging = spec.Logging
+			m.setDHCPPoolIds(dhcp)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			*dhcp = nsxtypes.DHCPConfig{}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+	case len(parts) == 2 && parts[0] == "config" && parts[1] == "ippools" &&
+		r.Method == http.MethodPost:
+		pool := nsxtypes.IPPool{}
+		if !readXML(w, body, &pool) {
+			return
+		}
+		pool.PoolId = m.newId("pool")
+		dhcp.IPPools = append(dhcp.IPPools, pool)
+		w.Header().Set("Location", fmt.Sprintf("/api/4.0/edges/%s/dhcp/config/ippools/%s",
+			edge.Id, pool.PoolId))
+		w.WriteHeader(http.StatusCreated)
+
+	case len(parts) == 3 && parts[0] == "config" && parts[1] == "ippools" &&
+		r.Method == http.MethodDelete:
+		for i, pool := range dhcp.IPPools {
+			if pool.PoolId == parts[2] {
+				dhcp.IPPools = append(dhcp.IPPools[:i], dhcp.IPPools[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		http.NotFound(w, r)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *mockNsxManager) serveEdgeDLRInterfaces(w http.ResponseWriter, r *http.Request,
+	edge *edgeConfig, body []byte) {
+
+	if edge.Type != EdgeTypeDistributedRouter {
+		http.Error(w, "interfaces are only supported on distributed routers",
+			http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeXML(w, http.StatusOK, &dlrInterfaces{Interfaces: m.dlrIfaces[edge.Id]})
+
+	case http.MethodPost:
+		if r.URL.Query().Get("action") != "patch" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		spec := &dlrInterfaces{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		added := []dlrInterface{}
+		for _, iface := range spec.Interfaces {
+			iface.Index = strconv.Itoa(m.nextId)
+			m.nextId++
+			if vwire, ok := m.virtualWires[iface.ConnectedToId]; ok {
+				iface.ConnectedToName = vwire.Name
+			}
+			added = append(added, iface)
+		}
+		m.dlrIfaces[edge.Id] = append(m.dlrIfaces[edge.Id], added...)
+		writeXML(w, http.StatusOK, &dlrInterfaces{Interfaces: added})
+
+	case http.MethodDelete:
+		index := r.URL.Query().Get("index")
+		if index == "" {
+			delete(m.dlrIfaces, edge.Id)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		ifaces := m.dlrIfaces[edge.Id]
+		for i, iface := range ifaces {
+			if iface.Index == index {
+				m.dlrIfaces[edge.Id] = append(ifaces[:i], ifaces[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		http.NotFound(w, r)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *mockNsxManager) serveEdgeDLRBridging(w http.ResponseWriter, r *http.Request,
+	edge *edgeConfig, body []byte) {
+
+	if edge.Type != EdgeTypeDistributedRouter {
+		http.Error(w, "bridging is only supported on distributed routers",
+			http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		bridges, ok := m.dlrBridges[edge.Id]
+		if !ok {
+			bridges = &dlrBridges{Version: "1"}
+		}
+		writeXML(w, http.StatusOK, bridges)
+
+	case http.MethodPut:
+		spec := &dlrBridges{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		for _, bridge := range spec.Bridges {
+			if _, ok := m.virtualWires[bridge.VirtualWire]; !ok {
+				http.Error(w, fmt.Sprintf("virtual wire %s not found", bridge.VirtualWire),
+					http.StatusBadRequest)
+				return
+			}
+		}
+		version := 1
+		if cur, ok := m.dlrBridges[edge.Id]; ok {
+			version = atoi(cur.Version)
+		}
+		spec.Version = strconv.Itoa(version + 1)
+		m.dlrBridges[edge.Id] = spec
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		delete(m.dlrBridges, edge.Id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
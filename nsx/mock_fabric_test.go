@@ -0,0 +1,191 @@
+package nsx
+
+import (
+	"net/http"
+)
+
+// Handlers of the mock NSX Manager for the network fabric endpoints.
+
+// mockFabricFeature is the state of a network fabric feature on a host.
+type mockFabricFeature struct {
+	installed    bool
+	pendingPolls int
+}
+
+func (m *mockNsxManager) serveNwFabric(w http.ResponseWriter, r *http.Request,
+	parts []string, body []byte) {
+
+	switch {
+	case parts[0] == "configure" && (r.Method == http.MethodPost ||
+		r.Method == http.MethodDelete):
+		config := &nwFabricFeatureConfig{}
+		if !readXML(w, body, config) {
+			return
+		}
+		featureId := config.FeatureId
+		if featureId == "" {
+			featureId = NwFabricFeatureHostPrep
+		}
+		if r.Method == http.MethodPost {
+			m.configureNwFabric(w, r, featureId, config)
+		} else {
+			m.unconfigureNwFabric(w, r, featureId, config)
+		}
+	case parts[0] == "status" && len(parts) == 1 && r.Method == http.MethodGet:
+		clusterId := r.URL.Query().Get("resource")
+		hosts, ok := m.clusterHosts[clusterId]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		status := nwFabricResourceStatus{Resource: inventoryObject{ObjectId: clusterId,
+			ObjectTypeName: InventoryTypeCluster}}
+		for _, featureId := range []string{NwFabricFeatureHostPrep, NwFabricFeatureVxlan} {
+			clusterStatus := nwFabricFeatureStatus{FeatureId: featureId,
+				FeatureVersion: m.ManagerVersion, Status: NwFabricStatusGreen}
+			for _, host := range hosts {
+				hostStatus := m.hostFeatureStatus(host.ObjectId, featureId, false)
+				clusterStatus.Installed = clusterStatus.Installed || hostStatus.Installed
+				if hostStatus.Status != NwFabricStatusGreen {
+					clusterStatus.Status = hostStatus.Status
+				}
+			}
+			status.FeatureStatuses = append(status.FeatureStatuses, clusterStatus)
+		}
+		writeXML(w, http.StatusOK, &nwFabricResourceStatuses{
+			Statuses: []nwFabricResourceStatus{status}})
+	case parts[0] == "status" && len(parts) == 3 && parts[1] == "child" &&
+		r.Method == http.MethodGet:
+		hosts, ok := m.clusterHosts[parts[2]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		statuses := &nwFabricResourceStatuses{}
+		for _, host := range hosts {
+			status := nwFabricResourceStatus{Resource: host}
+			for _, featureId := range []string{NwFabricFeatureHostPrep, NwFabricFeatureVxlan} {
+				status.FeatureStatuses = append(status.FeatureStatuses,
+					m.hostFeatureStatus(host.ObjectId, featureId, true))
+			}
+			statuses.Statuses = append(statuses.Statuses, status)
+		}
+		writeXML(w, http.StatusOK, statuses)
+	case parts[0] == "clusters" && len(parts) == 2 && r.Method == http.MethodGet:
+		spec, ok := m.vxlanConfigs[parts[1]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writeXML(w, http.StatusOK, &nwFabricFeatureConfig{
+			FeatureId: NwFabricFeatureVxlan,
+			ResourceConfigs: []nwFabricResourceConfig{
+				{ResourceId: parts[1], ConfigSpec: spec}},
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *mockNsxManager) configureNwFabric(w http.ResponseWriter, r *http.Request,
+	featureId string, config *nwFabricFeatureConfig) {
+
+	for _, rc := range config.ResourceConfigs {
+		if rc.ConfigSpec != nil && rc.ConfigSpec.Class == ConfigSpecClassVdsContext {
+			m.vdsContexts[rc.ResourceId] = &vdsContext{Switch: *rc.ConfigSpec.Switch,
+				Mtu: rc.ConfigSpec.Mtu, Teaming: rc.ConfigSpec.Teaming}
+			continue
+		}
+
+		hosts, ok := m.clusterHosts[rc.ResourceId]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		if featureId == NwFabricFeatureVxlan {
+			for _, host := range hosts {
+				if !m.hostFeatureStatus(host.ObjectId, NwFabricFeatureHostPrep, false).Installed {
+					writeXML(w, http.StatusBadRequest, &nsxError{ErrorCode: 201040,
+						Details: "Cluster " + rc.ResourceId + " is not prepared"})
+					return
+				}
+			}
+			m.vxlanConfigs[rc.ResourceId] = rc.ConfigSpec
+		}
+
+		for _, host := range hosts {
+			if m.hostFeatures[host.ObjectId] == nil {
+				m.hostFeatures[host.ObjectId] = make(map[string]*mockFabricFeature)
+			}
+			feature := m.hostFeatures[host.ObjectId][featureId]
+			if feature == nil || !feature.installed {
+				m.hostFeatures[host.ObjectId][featureId] = &mockFabricFeature{
+					installed: true, pendingPolls: m.FabricPolls}
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(m.newId("jobdata")))
+}
+
+func (m *mockNsxManager) unconfigureNwFabric(w http.ResponseWriter, r *http.Request,
+	featureId string, config *nwFabricFeatureConfig) {
+
+	for _, rc := range config.ResourceConfigs {
+		hosts, ok := m.clusterHosts[rc.ResourceId]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		if featureId == NwFabricFeatureHostPrep {
+			if _, ok := m.vxlanConfigs[rc.ResourceId]; ok {
+				writeXML(w, http.StatusBadRequest, &nsxError{ErrorCode: 201041,
+					Details: "Cluster " + rc.ResourceId + " is in use by the VXLAN configuration"})
+				return
+			}
+		} else {
+			delete(m.vxlanConfigs, rc.ResourceId)
+		}
+
+		for _, host := range hosts {
+			delete(m.hostFeatures[host.ObjectId], featureId)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// hostFeatureStatus returns the status of the feature on the host, a poll
+// advances a pending installation.
+func (m *mockNsxManager) hostFeatureStatus(hostId string, featureId string,
+	poll bool) nwFabricFeatureStatus {
+
+	status := nwFabricFeatureStatus{FeatureId: featureId, FeatureVersion: m.ManagerVersion,
+		Status: "UNKNOWN"}
+
+	feature := m.hostFeatures[hostId][featureId]
+	if feature == nil || !feature.installed {
+		return status
+	}
+
+	status.Installed = true
+	status.Enabled = true
+	switch {
+	case m.HostFailures[hostId] != "":
+		status.Status = NwFabricStatusRed
+		status.Message = m.HostFailures[hostId]
+	case feature.pendingPolls > 0:
+		if poll {
+			feature.pendingPolls--
+		}
+		status.Status = "YELLOW"
+		status.Message = "Installing"
+	default:
+		status.Status = NwFabricStatusGreen
+	}
+
+	return status
+}
@@ -0,0 +1,472 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/IBM-tfproviders/govnsx/nsxtypes"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+//
+// mockNsxManager is an in memory fake of the NSX-V REST API, so the
+// acceptance tests can run without a NSX Manager when TF_ACC_MOCK=1 is set.
+// It records the requests changing its state and can serve canned NSX
+// documents, see Fixtures. The handlers are in the mock_*_test.go files.
+//
+
+const (
+	testAccMockEnvVar = "TF_ACC_MOCK"
+
+	mockUser     = "admin"
+	mockPassword = "default"
+
+	// Objects every mock NSX Manager starts with.
+	mockScopeId          = "vdnscope-1"
+	mockUniversalScopeId = "universalvdnscope"
+	mockEdgeId           = "edge-1"
+	mockDLREdgeId        = "edge-2"
+	mockLogicalSwitchId  = "virtualwire-1"
+	mockClusterId        = "domain-c7"
+	mockSwitchId         = "dvs-1"
+
+	mockVnicCount = 10
+
+	mockManagerVersion = "6.4.10"
+)
+
+// mockRequest is a request received by the mock NSX Manager, with the body
+// as sent by the provider.
+type mockRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Body   string
+}
+
+type mockNsxManager struct {
+	sync.Mutex
+
+	Server *httptest.Server
+
+	// Delay is added to every request, to widen the window in which
+	// concurrent callers can interleave.
+	Delay time.Duration
+
+	// StatusPolls is the number of status polls for which an edge reports
+	// its last change as not yet published.
+	StatusPolls int
+
+	// ManagerVersion is the NSX Manager version, edges are deployed at the
+	// version current at the time and keep it until upgraded.
+	ManagerVersion string
+
+	// FabricPolls is the number of host status polls for which a host
+	// reports a network fabric feature as still being installed.
+	FabricPolls int
+
+	// HostFailures are the hosts on which installing a network fabric
+	// feature fails, with the message they report.
+	HostFailures map[string]string
+
+	// ControllerPolls is the number of job progress polls for which a
+	// controller deployment is reported in progress.
+	ControllerPolls int
+
+	// ControllerFailure is the error reported by the controller deployment
+	// jobs, when set.
+	ControllerFailure string
+
+	// Role is the universal sync role of the NSX Manager, universal
+	// objects can only be created on the primary one.
+	Role string
+
+	// Fixtures are canned NSX XML documents, by URL path, returned to GET
+	// requests instead of the state of the mock.
+	Fixtures map[string]string
+
+	requests     []mockRequest
+	nextId       int
+	edges        map[string]*edgeConfig
+	edgeVersions map[string]string
+	redeploys    map[string]int
+	inventory    []inventoryObject
+	pendingPolls map[string]int
+	scopes       map[string]*vdnScope
+	dlrIfaces    map[string][]dlrInterface
+	dlrBridges   map[string]*dlrBridges
+	virtualWires map[string]*nsxtypes.VirtualWire
+	vwFeatures   map[string]*networkFeatureConfig
+	hwBindings   map[string][]hwGatewayBinding
+	macSets      map[string]*macSet
+	policies     map[string]*securityPolicy
+	ipPools      map[string]*ipamAddressPool
+	ipAllocs     map[string][]allocatedIPAddress
+	segmentPools map[string]*segmentRange
+	mcastRanges  map[string]*multicastRange
+	clusterHosts map[string][]inventoryObject
+	hostFeatures map[string]map[string]*mockFabricFeature
+	vxlanConfigs map[string]*nwFabricConfigSpec
+	vdsContexts  map[string]*vdsContext
+	controllers  map[string]*nsxController
+	ctrlJobs     map[string]*mockControllerJob
+	ctrlPools    map[string]string
+	ctrlPassword string
+	certificates map[string]*trustCertificate
+	csrs         map[string]*trustCsr
+	crls         map[string]*trustCrl
+}
+
+func isTestAccMock() bool {
+	return os.Getenv(testAccMockEnvVar) == "1"
+}
+
+// testAccEnvOrMock returns the value of the environment variable env, or
+// mockValue when the acceptance tests run against the mock NSX Manager.
+func testAccEnvOrMock(env string, mockValue string) string {
+	if isTestAccMock() {
+		return mockValue
+	}
+	return os.Getenv(env)
+}
+
+func newMockNsxManager() *mockNsxManager {
+
+	m := &mockNsxManager{
+		ManagerVersion: mockManagerVersion,
+		Role:           UniversalSyncRoleStandalone,
+		Fixtures:       make(map[string]string),
+		nextId:         10,
+		edges:          make(map[string]*edgeConfig),
+		edgeVersions:   make(map[string]string),
+		redeploys:      make(map[string]int),
+		pendingPolls:   make(map[string]int),
+		scopes:         make(map[string]*vdnScope),
+		dlrIfaces:      make(map[string][]dlrInterface),
+		dlrBridges:     make(map[string]*dlrBridges),
+		virtualWires:   make(map[string]*nsxtypes.VirtualWire),
+		vwFeatures:     make(map[string]*networkFeatureConfig),
+		hwBindings:     make(map[string][]hwGatewayBinding),
+		macSets:        make(map[string]*macSet),
+		policies:       make(map[string]*securityPolicy),
+		ipPools:        make(map[string]*ipamAddressPool),
+		ipAllocs:       make(map[string][]allocatedIPAddress),
+		segmentPools:   make(map[string]*segmentRange),
+		mcastRanges:    make(map[string]*multicastRange),
+		HostFailures:   make(map[string]string),
+		clusterHosts:   make(map[string][]inventoryObject),
+		hostFeatures:   make(map[string]map[string]*mockFabricFeature),
+		vxlanConfigs:   make(map[string]*nwFabricConfigSpec),
+		vdsContexts:    make(map[string]*vdsContext),
+		controllers:    make(map[string]*nsxController),
+		ctrlJobs:       make(map[string]*mockControllerJob),
+		ctrlPools:      make(map[string]string),
+		certificates:   make(map[string]*trustCertificate),
+		csrs:           make(map[string]*trustCsr),
+		crls:           make(map[string]*trustCrl),
+	}
+
+	m.edges[mockEdgeId] = newMockEdge(mockEdgeId, EdgeTypeGatewayServices,
+		&edgeConfig{Name: "mock-esg"})
+	m.edges[mockDLREdgeId] = newMockEdge(mockDLREdgeId, EdgeTypeDistributedRouter,
+		&edgeConfig{Name: "mock-dlr"})
+	m.edgeVersions[mockEdgeId] = m.ManagerVersion
+	m.edgeVersions[mockDLREdgeId] = m.ManagerVersion
+	m.inventory = []inventoryObject{
+		{ObjectId: mockClusterId, ObjectTypeName: InventoryTypeCluster, Name: "mock-cluster"},
+		{ObjectId: "resgroup-1", ObjectTypeName: InventoryTypeResourcePool, Name: "mock-rp"},
+		{ObjectId: "datastore-1", ObjectTypeName: InventoryTypeDatastore, Name: "mock-ds"},
+		{ObjectId: "datastore-2", ObjectTypeName: InventoryTypeDatastore, Name: "mock-shared-ds"},
+		{ObjectId: "datastore-3", ObjectTypeName: InventoryTypeDatastore, Name: "mock-shared-ds"},
+		{ObjectId: "host-1", ObjectTypeName: InventoryTypeHost, Name: "mock-esx"},
+		{ObjectId: "host-2", ObjectTypeName: InventoryTypeHost, Name: "mock-esx-2"},
+		{ObjectId: "group-v1", ObjectTypeName: InventoryTypeFolder, Name: "mock-folder"},
+	}
+	for _, object := range m.inventory {
+		if object.ObjectTypeName == InventoryTypeHost {
+			m.clusterHosts[mockClusterId] = append(m.clusterHosts[mockClusterId], object)
+		}
+	}
+	m.scopes[mockScopeId] = &vdnScope{ObjectId: mockScopeId, Name: "mock-tz",
+		ControlPlaneMode: nsxtypes.CpmUnicastMode}
+	m.virtualWires[mockLogicalSwitchId] = &nsxtypes.VirtualWire{
+		ObjectId:         mockLogicalSwitchId,
+		Name:             "mock-ls",
+		TenantId:         "mock",
+		SwitchOId:        "dvs-1",
+		VdnId:            "5000",
+		ControlPlaneMode: nsxtypes.CpmUnicastMode,
+	}
+
+	m.Server = httptest.NewServer(m)
+	return m
+}
+
+// testAccMockNsxManager starts a mock NSX Manager for the duration of the
+// test and points the provider at it.
+func testAccMockNsxManager(t *testing.T) *mockNsxManager {
+
+	m := newMockNsxManager()
+	t.Cleanup(m.Server.Close)
+
+	t.Setenv("NSXV_USER", mockUser)
+	t.Setenv("NSXV_PASSWORD", mockPassword)
+	t.Setenv("NSXV_NSX_MANAGER_URI", m.Server.URL)
+
+	log.Printf("[INFO] Mock NSX Manager listening on %s", m.Server.URL)
+	return m
+}
+
+// newMockNsxClient starts a mock NSX Manager for the duration of the test
+// and returns a client connected to it, for tests calling the resource
+// functions directly.
+func newMockNsxClient(t *testing.T) (*mockNsxManager, *govnsx.Client) {
+
+	m := newMockNsxManager()
+	t.Cleanup(m.Server.Close)
+
+	config := &Config{
+		User:          mockUser,
+		Password:      mockPassword,
+		NsxManagerUri: m.Server.URL,
+	}
+	client, err := config.Client()
+	if err != nil {
+		t.Fatalf("Unable to create client: %s", err)
+	}
+	return m, client
+}
+
+func (m *mockNsxManager) newId(prefix string) string {
+	m.nextId++
+	return fmt.Sprintf("%s-%d", prefix, m.nextId)
+}
+
+func (m *mockNsxManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if user, password, ok := r.BasicAuth(); !ok || user != mockUser || password != mockPassword {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	time.Sleep(m.Delay)
+
+	m.Lock()
+	defer m.Unlock()
+
+	log.Printf("[DEBUG] Mock NSX Manager: %s %s", r.Method, r.URL.String())
+
+	if fixture, ok := m.Fixtures[r.URL.Path]; ok && r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(fixture))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		m.requests = append(m.requests, mockRequest{Method: r.Method, Path: r.URL.Path,
+			Query: r.URL.RawQuery, Body: string(body)})
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case hasPrefix(parts, "api", "4.0", "edges"):
+		m.serveEdges(w, r, parts[3:], body)
+	case hasPrefix(parts, "api", "2.0", "services", "usermgmt", "scopingobjects") &&
+		r.Method == http.MethodGet:
+		writeXML(w, http.StatusOK, &inventoryObjectList{Objects: m.inventory})
+	case hasPrefix(parts, "api", "1.0", "appliance-management", "global", "info") &&
+		r.Method == http.MethodGet:
+		version := strings.SplitN(m.ManagerVersion, ".", 3)
+		info := &managerGlobalInfo{}
+		info.VersionInfo.MajorVersion = version[0]
+		info.VersionInfo.MinorVersion = version[1]
+		info.VersionInfo.PatchVersion = version[2]
+		writeXML(w, http.StatusOK, info)
+	case hasPrefix(parts, "api", "2.0", "universalsync", "configuration", "role") &&
+		r.Method == http.MethodGet:
+		writeXML(w, http.StatusOK, &universalSyncRole{Role: m.Role})
+	case hasPrefix(parts, "api", "2.0", "vdn", "scopes") &&
+		(len(parts) <= 5 || len(parts) == 6 && parts[5] == "attributes"):
+		m.serveVdnScopes(w, r, parts[4:], body)
+	case hasPrefix(parts, "api", "2.0", "vdn", "scopes") && len(parts) == 6 &&
+		parts[5] == "virtualwires":
+		m.serveVirtualWires(w, r, parts[4], nil, body)
+	case hasPrefix(parts, "api", "2.0", "vdn", "virtualwires") && len(parts) > 4:
+		m.serveVirtualWires(w, r, "", parts[4:], body)
+	case hasPrefix(parts, "api", "2.0", "xvs", "networks") && len(parts) == 6 &&
+		parts[5] == "features":
+		m.serveNetworkFeatures(w, r, parts[4], body)
+	case hasPrefix(parts, "api", "2.0", "services", "macset") && len(parts) == 5:
+		m.serveMacSets(w, r, parts[4], body)
+	case hasPrefix(parts, "api", "2.0", "services", "policy", "securitypolicy") &&
+		len(parts) <= 6:
+		m.serveSecurityPolicies(w, r, parts[5:], body)
+	case hasPrefix(parts, "api", "2.0", "vdn", "config", "segments"):
+		m.serveSegmentPools(w, r, parts[5:], body)
+	case hasPrefix(parts, "api", "2.0", "vdn", "config", "multicasts"):
+		m.serveMulticastRanges(w, r, parts[5:], body)
+	case hasPrefix(parts, "api", "2.0", "nwfabric") && len(parts) > 3:
+		m.serveNwFabric(w, r, parts[3:], body)
+	case hasPrefix(parts, "api", "2.0", "vdn", "controller"):
+		m.serveControllers(w, r, parts[4:], body)
+	case hasPrefix(parts, "api", "2.0", "vdn", "switches"):
+		m.serveVdsContexts(w, r, parts[4:], body)
+	case hasPrefix(parts, "api", "2.0", "services", "ipam", "pools") && len(parts) > 5:
+		m.serveIPPools(w, r, parts[5:], body)
+	case hasPrefix(parts, "api", "2.0", "services", "truststore") && len(parts) == 6:
+		m.serveTrustStore(w, r, parts[4], parts[5], body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Requests returns the requests changing the state of the mock received on
+// path with method, oldest first.
+func (m *mockNsxManager) Requests(method string, path string) []mockRequest {
+	m.Lock()
+	defer m.Unlock()
+
+	requests := []mockRequest{}
+	for _, request := range m.requests {
+		if request.Method == method && request.Path == path {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+func hasPrefix(parts []string, prefix ...string) bool {
+	if len(parts) < len(prefix) {
+		return false
+	}
+	for i, p := range prefix {
+		if parts[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	out, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write(out)
+}
+
+func readXML(w http.ResponseWriter, body []byte, v interface{}) bool {
+	if err := xml.Unmarshal(body, v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// xmlElementValues returns the text of the elements of doc at path, element
+// names separated by '/' from the root element, in document order. The
+// requests are checked against the NSX documents this way, rather than
+// through the types of the provider.
+func xmlElementValues(doc string, path string) ([]string, error) {
+
+	values := []string{}
+	stack := []string{}
+	text := ""
+
+	decoder := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			text = ""
+		case xml.CharData:
+			text += string(t)
+		case xml.EndElement:
+			if strings.Join(stack, "/") == path {
+				values = append(values, strings.TrimSpace(text))
+			}
+			stack = stack[:len(stack)-1]
+			text = ""
+		}
+	}
+}
+
+// testAccCheckMockRequestXML checks that the last request received on path
+// with method has the expected values at each element path, in any order as
+// lists like the interfaces are not sent in the order of the configuration.
+func testAccCheckMockRequestXML(m *mockNsxManager, method string, path string,
+	expected map[string][]string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		requests := m.Requests(method, path)
+		if len(requests) == 0 {
+			return fmt.Errorf("No %s request received on %s", method, path)
+		}
+		body := requests[len(requests)-1].Body
+
+		for elemPath, want := range expected {
+			got, err := xmlElementValues(body, elemPath)
+			if err != nil {
+				return fmt.Errorf("Invalid XML sent to %s %s: %s", method, path, err)
+			}
+			sort.Strings(got)
+			want = append([]string{}, want...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				return fmt.Errorf("%s %s sent %s %v, expected %v\n%s",
+					method, path, elemPath, got, want, body)
+			}
+		}
+
+		return nil
+	}
+}
+
+// rejectUniversal fails the creation of a universal object on a NSX Manager
+// which is not the primary one.
+func (m *mockNsxManager) rejectUniversal(w http.ResponseWriter) bool {
+
+	if m.Role == UniversalSyncRolePrimary {
+		return false
+	}
+	http.Error(w, fmt.Sprintf("universal objects can only be created on the primary "+
+		"NSX Manager, this one is %s", m.Role), http.StatusBadRequest)
+	return true
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
@@ -0,0 +1,455 @@
+package nsx
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Handlers of the mock NSX Manager for the grouping object, security
+// policy, IPAM and truststore endpoints.
+
+func (m *mockNsxManager) serveMacSets(w http.ResponseWriter, r *http.Request,
+	id string, body []byte) {
+
+	if r.Method == http.MethodPost {
+		spec := &macSet{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		if id == UniversalScopeId && m.rejectUniversal(w) {
+			return
+		}
+		spec.ObjectId = m.newId("macset")
+		spec.ScopeId = id
+		spec.Revision = 1
+		m.macSets[spec.ObjectId] = spec
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(spec.ObjectId))
+		return
+	}
+
+	macSetCfg, ok := m.macSets[id]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeXML(w, http.StatusOK, macSetCfg)
+	case http.MethodPut:
+		spec := &macSet{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		if spec.Revision != macSetCfg.Revision {
+			http.Error(w, "object revision mismatch", http.StatusConflict)
+			return
+		}
+		spec.ObjectId = macSetCfg.ObjectId
+		spec.ScopeId = macSetCfg.ScopeId
+		spec.Revision = macSetCfg.Revision + 1
+		m.macSets[id] = spec
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(m.macSets, id)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *mockNsxManager) serveSecurityPolicies(w http.ResponseWriter, r *http.Request,
+	parts []string, body []byte) {
+
+	if len(parts) == 0 {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		spec := &securityPolicy{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		spec.ObjectId = m.newId("policy")
+		spec.Revision = 1
+		if err := m.setFirewallActionIds(spec, nil); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		m.policies[spec.ObjectId] = spec
+		w.Header().Set("Location", "/api/2.0/services/policy/securitypolicy/"+spec.ObjectId)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	policy, ok := m.policies[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeXML(w, http.StatusOK, policy)
+	case http.MethodPut:
+		spec := &securityPolicy{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		if spec.Revision != policy.Revision {
+			http.Error(w, "object revision mismatch", http.StatusConflict)
+			return
+		}
+		if err := m.setFirewallActionIds(spec, policy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		spec.ObjectId = policy.ObjectId
+		spec.Revision = policy.Revision + 1
+		m.policies[policy.ObjectId] = spec
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(m.policies, policy.ObjectId)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// setFirewallActionIds gives the new firewall actions of spec an object id,
+// the others must be actions of the current policy.
+func (m *mockNsxManager) setFirewallActionIds(spec *securityPolicy,
+	policy *securityPolicy) error {
+
+	current := make(map[string]bool)
+	if policy != nil {
+		actions, err := getFirewallActions(policy)
+		if err != nil {
+			return err
+		}
+		for _, action := range actions {
+			current[action.ObjectId] = true
+		}
+	}
+
+	for i, raw := range spec.ActionsByCategory {
+		category, err := decodeActionsByCategory(raw)
+		if err != nil {
+			return err
+		}
+		if category.Category != SecurityActionCategoryFirewall {
+			continue
+		}
+		for j := range category.Actions {
+			action := &category.Actions[j]
+			if action.ObjectId == "" {
+				action.ObjectId = m.newId("action")
+			} else if !current[action.ObjectId] {
+				return fmt.Errorf("action %s not found", action.ObjectId)
+			}
+		}
+		if spec.ActionsByCategory[i], err = encodeActionsByCategory(category); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *mockNsxManager) serveIPPools(w http.ResponseWriter, r *http.Request,
+	parts []string, body []byte) {
+
+	if len(parts) == 2 && parts[0] == "scope" && r.Method == http.MethodPost {
+		spec := &ipamAddressPool{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		spec.ObjectId = m.newId("ipaddresspool")
+		spec.Revision = 1
+		m.setIPRangeIds(spec)
+		m.ipPools[spec.ObjectId] = spec
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(spec.ObjectId))
+		return
+	}
+
+	pool, ok := m.ipPools[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) > 1 && parts[1] == "ipaddresses" {
+		m.serveIPPoolAddresses(w, r, pool, parts[2:], body)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		pool.TotalAddressCount = 0
+		for _, ipr := range pool.IPRanges {
+			size := new(big.Int).Sub(ipToInt(net.ParseIP(ipr.EndAddress)),
+				ipToInt(net.ParseIP(ipr.StartAddress)))
+			pool.TotalAddressCount += int(size.Int64()) + 1
+		}
+		pool.UsedAddressCount = len(m.ipAllocs[pool.ObjectId])
+		writeXML(w, http.StatusOK, pool)
+	case http.MethodPut:
+		spec := &ipamAddressPool{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		if spec.Revision != pool.Revision {
+			http.Error(w, "object revision mismatch", http.StatusConflict)
+			return
+		}
+		spec.ObjectId = pool.ObjectId
+		spec.Revision = pool.Revision + 1
+		m.setIPRangeIds(spec)
+		m.ipPools[pool.ObjectId] = spec
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		if len(m.ipAllocs[pool.ObjectId]) > 0 {
+			writeXML(w, http.StatusBadRequest, &nsxError{ErrorCode: 120052,
+				Details: "IP pool " + pool.ObjectId + " is in use"})
+			return
+		}
+		delete(m.ipPools, pool.ObjectId)
+		delete(m.ipAllocs, pool.ObjectId)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// setIPRangeIds sets the ids of the new ranges of the pool. Like NSX, the
+// ranges are then kept sorted by start address, not in the order sent.
+func (m *mockNsxManager) setIPRangeIds(pool *ipamAddressPool) {
+	for i := range pool.IPRanges {
+		if pool.IPRanges[i].Id == "" {
+			pool.IPRanges[i].Id = m.newId("iprange")
+		}
+	}
+	sort.Slice(pool.IPRanges, func(i, j int) bool {
+		return compareIP(net.ParseIP(pool.IPRanges[i].StartAddress),
+			net.ParseIP(pool.IPRanges[j].StartAddress)) < 0
+	})
+}
+
+func (m *mockNsxManager) serveIPPoolAddresses(w http.ResponseWriter, r *http.Request,
+	pool *ipamAddressPool, parts []string, body []byte) {
+
+	allocs := m.ipAllocs[pool.ObjectId]
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		writeXML(w, http.StatusOK, &allocatedIPAddressList{Addresses: allocs})
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		request := &ipAddressRequest{}
+		if !readXML(w, body, request) {
+			return
+		}
+
+		allocated := m.allocateIPAddress(pool, request)
+		if allocated == nil {
+			writeXML(w, http.StatusBadRequest, &nsxError{ErrorCode: 120054,
+				Details: "No IP address available in the IP pool"})
+			return
+		}
+		writeXML(w, http.StatusOK, allocated)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if !m.releaseIPAddress(pool, parts[0]) {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// allocateIPAddress allocates the address requested from the pool, it
+// returns nil when no address is available.
+func (m *mockNsxManager) allocateIPAddress(pool *ipamAddressPool,
+	request *ipAddressRequest) *allocatedIPAddress {
+
+	allocs := m.ipAllocs[pool.ObjectId]
+
+	isAllocated := func(ip net.IP) bool {
+		for _, a := range allocs {
+			if net.ParseIP(a.IpAddress).Equal(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var ip net.IP
+	for _, ipr := range pool.IPRanges {
+		r := ipRange{net.ParseIP(ipr.StartAddress), net.ParseIP(ipr.EndAddress)}
+		if request.AllocationMode == IPAllocationModeReserve {
+			reserved := net.ParseIP(request.IpAddress)
+			if checkIPInRange(r, reserved) && !isAllocated(reserved) {
+				ip = reserved
+			}
+		} else {
+			for cur := r.start; compareIP(cur, r.end) <= 0 && ip == nil; cur = addToIP(cur, 1) {
+				if !isAllocated(cur) {
+					ip = cur
+				}
+			}
+		}
+		if ip != nil {
+			break
+		}
+	}
+	if ip == nil {
+		return nil
+	}
+
+	allocated := allocatedIPAddress{
+		Id:           len(allocs) + 1,
+		IpAddress:    normalizeIP(ip).String(),
+		Gateway:      pool.Gateway,
+		PrefixLength: pool.PrefixLength,
+		DnsServer1:   pool.DnsServer1,
+		DnsServer2:   pool.DnsServer2,
+		DnsSuffix:    pool.DnsSuffix,
+	}
+	m.ipAllocs[pool.ObjectId] = append(allocs, allocated)
+	return &allocated
+}
+
+func (m *mockNsxManager) releaseIPAddress(pool *ipamAddressPool, ipAddress string) bool {
+
+	allocs := m.ipAllocs[pool.ObjectId]
+	for i, a := range allocs {
+		if net.ParseIP(a.IpAddress).Equal(net.ParseIP(ipAddress)) {
+			m.ipAllocs[pool.ObjectId] = append(allocs[:i], allocs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockNsxManager) serveTrustStore(w http.ResponseWriter, r *http.Request,
+	kind string, id string, body []byte) {
+
+	if r.Method == http.MethodPost {
+		// id is the scope: an edge, or the CSR a certificate was signed for.
+		_, isEdge := m.edges[id]
+		_, isCsr := m.csrs[id]
+		if !isEdge && !(isCsr && kind == "certificate") {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch kind {
+		case "certificate":
+			trustObj := &trustObject{}
+			if !readXML(w, body, trustObj) {
+				return
+			}
+			certs := &trustCertificates{}
+			rest := []byte(trustObj.PemEncoding)
+			for {
+				var block *pem.Block
+				if block, rest = pem.Decode(rest); block == nil {
+					break
+				}
+				x509Cert, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				cert := &trustCertificate{
+					ObjectId:    m.newId("certificate"),
+					PemEncoding: string(pem.EncodeToMemory(block)),
+					X509Certificate: x509Certificate{
+						SubjectCn: x509Cert.Subject.CommonName,
+						IssuerCn:  x509Cert.Issuer.CommonName,
+						NotBefore: x509Cert.NotBefore.UnixNano() / int64(time.Millisecond),
+						NotAfter:  x509Cert.NotAfter.UnixNano() / int64(time.Millisecond),
+					},
+				}
+				m.certificates[cert.ObjectId] = cert
+				certs.Certificates = append(certs.Certificates, *cert)
+			}
+			if len(certs.Certificates) == 0 {
+				http.Error(w, "no certificate found", http.StatusBadRequest)
+				return
+			}
+			if isCsr {
+				delete(m.csrs, id)
+			}
+			writeXML(w, http.StatusOK, certs)
+		case "csr":
+			csr := &trustCsr{}
+			if !readXML(w, body, csr) {
+				return
+			}
+			csr.ObjectId = m.newId("csr")
+			csr.PemEncoding = string(pem.EncodeToMemory(&pem.Block{
+				Type: "CERTIFICATE REQUEST", Bytes: []byte(csr.ObjectId)}))
+			m.csrs[csr.ObjectId] = csr
+			writeXML(w, http.StatusOK, csr)
+		case "crl":
+			trustObj := &trustObject{}
+			if !readXML(w, body, trustObj) {
+				return
+			}
+			certList, err := x509.ParseCRL([]byte(trustObj.PemEncoding))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			crl := &trustCrl{
+				ObjectId:    m.newId("crl"),
+				PemEncoding: trustObj.PemEncoding,
+				X509Crl: x509Crl{
+					IssuerCn:   certList.TBSCertList.Issuer.String(),
+					NextUpdate: certList.TBSCertList.NextUpdate.UnixNano() / int64(time.Millisecond),
+				},
+			}
+			m.crls[crl.ObjectId] = crl
+			writeXML(w, http.StatusOK, crl)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	var obj interface{}
+	var found bool
+	switch kind {
+	case "certificate":
+		obj, found = m.certificates[id]
+	case "csr":
+		obj, found = m.csrs[id]
+	case "crl":
+		obj, found = m.crls[id]
+	}
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeXML(w, http.StatusOK, obj)
+	case http.MethodDelete:
+		delete(m.certificates, id)
+		delete(m.csrs, id)
+		delete(m.crls, id)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
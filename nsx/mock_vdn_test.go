@@ -0,0 +1,394 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+
+	"github.com/IBM-tfproviders/govnsx/nsxtypes"
+)
+
+// Handlers of the mock NSX Manager for the transport zone, logical switch
+// and VXLAN configuration endpoints.
+
+func (m *mockNsxManager) serveVirtualWires(w http.ResponseWriter, r *http.Request,
+	scopeId string, parts []string, body []byte) {
+
+	if scopeId != "" {
+		switch r.Method {
+		case http.MethodGet:
+			list := &virtualWires{}
+			for _, vwire := range m.virtualWires {
+				list.VirtualWires = append(list.VirtualWires, *vwire)
+			}
+			list.PagingInfo.TotalCount = len(list.VirtualWires)
+			writeXML(w, http.StatusOK, list)
+		case http.MethodPost:
+			spec := &nsxtypes.VWCreateSpec{}
+			if !readXML(w, body, spec) {
+				return
+			}
+			if scope, ok := m.scopes[scopeId]; ok && scope.IsUniversal && m.rejectUniversal(w) {
+				return
+			}
+			// Like NSX, the mode of the transport zone is used when the
+			// spec has none.
+			if spec.ControlPlaneMode == "" {
+				if scope, ok := m.scopes[scopeId]; ok {
+					spec.ControlPlaneMode = scope.ControlPlaneMode
+				}
+			}
+			vwireId := m.newId("virtualwire")
+			m.virtualWires[vwireId] = &nsxtypes.VirtualWire{
+				ObjectId:         vwireId,
+				Name:             spec.Name,
+				Description:      spec.Description,
+				TenantId:         spec.TenantId,
+				SwitchOId:        "dvs-1",
+				VdnId:            strconv.Itoa(5000 + m.nextId),
+				ControlPlaneMode: spec.ControlPlaneMode,
+				GuestVlanAllowed: spec.GuestVlanAllowed,
+			}
+			w.Header().Set("Location", "/api/2.0/vdn/virtualwires/"+vwireId)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(vwireId))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	vwire, ok := m.virtualWires[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			writeXML(w, http.StatusOK, vwire)
+		case http.MethodPut:
+			spec := &nsxtypes.UpdateVirtualWire{}
+			if !readXML(w, body, spec) {
+				return
+			}
+			if spec.Name != "" {
+				vwire.Name = spec.Name
+			}
+			if spec.Description != "" {
+				vwire.Description = spec.Description
+			}
+			if spec.TenantId != "" {
+				vwire.TenantId = spec.TenantId
+			}
+			if spec.ControlPlaneMode != "" {
+				vwire.ControlPlaneMode = spec.ControlPlaneMode
+			}
+			w.WriteHeader(http.StatusOK)
+		case http.MethodDelete:
+			delete(m.virtualWires, vwire.ObjectId)
+			delete(m.vwFeatures, vwire.ObjectId)
+			delete(m.hwBindings, vwire.ObjectId)
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	if parts[1] != "hardwaregateways" {
+		http.NotFound(w, r)
+		return
+	}
+
+	bindings := m.hwBindings[vwire.ObjectId]
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		writeXML(w, http.StatusOK, &struct {
+			XMLName xml.Name `xml:"list"`
+			hwGatewayBindings
+		}{hwGatewayBindings: hwGatewayBindings{Bindings: bindings}})
+	case len(parts) == 2 && r.Method == http.MethodPost:
+		binding := hwGatewayBinding{}
+		if !readXML(w, body, &binding) {
+			return
+		}
+		binding.Id = m.newId("binding")
+		m.hwBindings[vwire.ObjectId] = append(bindings, binding)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(binding.Id))
+	case len(parts) == 3 && r.Method == http.MethodDelete:
+		for i, binding := range bindings {
+			if binding.Id == parts[2] {
+				m.hwBindings[vwire.ObjectId] = append(bindings[:i], bindings[i+1:]...)
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *mockNsxManager) serveNetworkFeatures(w http.ResponseWriter, r *http.Request,
+	vwireId string, body []byte) {
+
+	if _, ok := m.virtualWires[vwireId]; !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		features, ok := m.vwFeatures[vwireId]
+		if !ok {
+			features = &networkFeatureConfig{}
+		}
+		writeXML(w, http.StatusOK, features)
+	case http.MethodPut:
+		features := &networkFeatureConfig{}
+		if !readXML(w, body, features) {
+			return
+		}
+		m.vwFeatures[vwireId] = features
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *mockNsxManager) serveVdnScopes(w http.ResponseWriter, r *http.Request,
+	parts []string, body []byte) {
+
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			list := &vdnScopes{}
+			for _, scope := range m.scopes {
+				list.VdnScopes = append(list.VdnScopes, *scope)
+			}
+			writeXML(w, http.StatusOK, list)
+		case http.MethodPost:
+			spec := &vdnScope{}
+			if !readXML(w, body, spec) {
+				return
+			}
+			spec.IsUniversal = r.URL.Query().Get("isUniversal") == "true"
+			if spec.IsUniversal {
+				if m.rejectUniversal(w) {
+					return
+				}
+				if _, ok := m.scopes[mockUniversalScopeId]; ok {
+					http.Error(w, "a universal transport zone already exists",
+						http.StatusBadRequest)
+					return
+				}
+				spec.ObjectId = mockUniversalScopeId
+			} else {
+				spec.ObjectId = m.newId("vdnscope")
+			}
+			m.scopes[spec.ObjectId] = spec
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(spec.ObjectId))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	scope, ok := m.scopes[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		spec := &vdnScope{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		scope.Name = spec.Name
+		scope.Description = spec.Description
+		scope.ControlPlaneMode = spec.ControlPlaneMode
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeXML(w, http.StatusOK, scope)
+	case http.MethodPost:
+		spec := &vdnScope{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		switch r.URL.Query().Get("action") {
+		case VdnScopeActionExpand:
+			scope.Clusters = append(scope.Clusters, spec.Clusters...)
+		case VdnScopeActionShrink:
+			clusters := []vdnScopeCluster{}
+			for _, cluster := range scope.Clusters {
+				if !containsVdnScopeCluster(spec.Clusters, cluster) {
+					clusters = append(clusters, cluster)
+				}
+			}
+			if len(clusters) == 0 {
+				http.Error(w, "a transport zone needs at least one cluster",
+					http.StatusBadRequest)
+				return
+			}
+			scope.Clusters = clusters
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(m.scopes, scope.ObjectId)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func containsVdnScopeCluster(clusters []vdnScopeCluster, cluster vdnScopeCluster) bool {
+	for _, c := range clusters {
+		if c.Cluster.ObjectId == cluster.Cluster.ObjectId {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockNsxManager) serveSegmentPools(w http.ResponseWriter, r *http.Request,
+	parts []string, body []byte) {
+
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			list := &segmentRangeList{}
+			for _, pool := range m.segmentPools {
+				list.Ranges = append(list.Ranges, *pool)
+			}
+			writeXML(w, http.StatusOK, list)
+		case http.MethodPost:
+			spec := &segmentRange{}
+			if !readXML(w, body, spec) {
+				return
+			}
+			m.nextId++
+			spec.Id = strconv.Itoa(m.nextId)
+			m.segmentPools[spec.Id] = spec
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(spec.Id))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	pool, ok := m.segmentPools[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeXML(w, http.StatusOK, pool)
+	case http.MethodPut:
+		spec := &segmentRange{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		spec.Id = pool.Id
+		m.segmentPools[pool.Id] = spec
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(m.segmentPools, pool.Id)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *mockNsxManager) serveMulticastRanges(w http.ResponseWriter, r *http.Request,
+	parts []string, body []byte) {
+
+	if len(parts) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			list := &multicastRangeList{}
+			for _, mcastRange := range m.mcastRanges {
+				list.Ranges = append(list.Ranges, *mcastRange)
+			}
+			writeXML(w, http.StatusOK, list)
+		case http.MethodPost:
+			spec := &multicastRange{}
+			if !readXML(w, body, spec) {
+				return
+			}
+			m.nextId++
+			spec.Id = strconv.Itoa(m.nextId)
+			m.mcastRanges[spec.Id] = spec
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(spec.Id))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	mcastRange, ok := m.mcastRanges[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeXML(w, http.StatusOK, mcastRange)
+	case http.MethodPut:
+		spec := &multicastRange{}
+		if !readXML(w, body, spec) {
+			return
+		}
+		spec.Id = mcastRange.Id
+		m.mcastRanges[mcastRange.Id] = spec
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(m.mcastRanges, mcastRange.Id)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *mockNsxManager) serveVdsContexts(w http.ResponseWriter, r *http.Request,
+	parts []string, body []byte) {
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		context := &vdsContext{}
+		if !readXML(w, body, context) {
+			return
+		}
+		m.vdsContexts[context.Switch.ObjectId] = context
+		w.WriteHeader(http.StatusOK)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		context, ok := m.vdsContexts[parts[0]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writeXML(w, http.StatusOK, context)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
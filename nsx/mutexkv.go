@@ -0,0 +1,56 @@
+package nsx
+
+import (
+	"log"
+	"sync"
+)
+
+// edgeMutexKV serialises the read-modify-write operations done on the same
+// edge by different resources. Terraform walks the graph in parallel and
+// an edge is always updated as a whole, so without it two resources can
+// overwrite each other's vNIC and feature changes.
+var edgeMutexKV = newMutexKV()
+
+// controllerMutexKV serialises the controller deletions of an NSX Manager,
+// keyed by its URI. A controller is only removed without force_removal when
+// another one is left, which has to be checked and acted on atomically.
+var controllerMutexKV = newMutexKV()
+
+// mutexKV is a simple key/value store of mutexes, used to serialise the
+// changes of collaborators sharing the knowledge of the keys.
+type mutexKV struct {
+	lock  sync.Mutex
+	store map[string]*sync.Mutex
+}
+
+func newMutexKV() *mutexKV {
+	return &mutexKV{
+		store: make(map[string]*sync.Mutex),
+	}
+}
+
+// Lock locks the mutex of key, creating it on first use.
+func (m *mutexKV) Lock(key string) {
+	log.Printf("[DEBUG] Locking %q", key)
+	m.get(key).Lock()
+	log.Printf("[DEBUG] Locked %q", key)
+}
+
+// Unlock unlocks the mutex of key.
+func (m *mutexKV) Unlock(key string) {
+	log.Printf("[DEBUG] Unlocking %q", key)
+	m.get(key).Unlock()
+	log.Printf("[DEBUG] Unlocked %q", key)
+}
+
+func (m *mutexKV) get(key string) *sync.Mutex {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	mutex, ok := m.store[key]
+	if !ok {
+		mutex = &sync.Mutex{}
+		m.store[key] = mutex
+	}
+	return mutex
+}
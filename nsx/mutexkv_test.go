@@ -0,0 +1,170 @@
+package nsx
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/IBM-tfproviders/govnsx/nsxresource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestAccNsxMutexKV_Basic(t *testing.T) {
+
+	m := newMutexKV()
+
+	m.Lock("edge-1")
+	// A different key must not block.
+	m.Lock("edge-2")
+	m.Unlock("edge-2")
+
+	locked := make(chan struct{})
+	go func() {
+		m.Lock("edge-1")
+		close(locked)
+		m.Unlock("edge-1")
+	}()
+
+	select {
+	case <-locked:
+		t.Fatalf("Lock of edge-1 acquired while held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	m.Unlock("edge-1")
+	<-locked
+}
+
+func TestAccNsxMutexKV_ParallelEdgeUpdates(t *testing.T) {
+
+	m, client := newMockNsxClient(t)
+	m.Delay = 5 * time.Millisecond
+
+	const count = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, 3*count)
+
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		i := i
+
+		// DHCP resources sharing the gateway edge.
+		run(func() error {
+			d := schema.TestResourceDataRaw(t, resourceNsxEdgeDHCP().Schema,
+				map[string]interface{}{
+					"edge_id": mockEdgeId,
+					"logical_switch": []interface{}{
+						map[string]interface{}{
+							"id": fmt.Sprintf("virtualwire-%d", 100+i),
+							"subnet": []interface{}{
+								map[string]interface{}{
+									"cidr":          fmt.Sprintf("10.10.%d.0/24", i),
+									"auto_allocate": true,
+								},
+							},
+						},
+					},
+				})
+			return resourceNsxEdgeDHCPCreate(d, client)
+		})
+
+		// DLR interfaces added to the distributed router.
+		run(func() error {
+			d := schema.TestResourceDataRaw(t, resourceNsxEdgeDLR().Schema,
+				map[string]interface{}{
+					"edge_id": mockDLREdgeId,
+					"interface": []interface{}{
+						map[string]interface{}{
+							"name":              fmt.Sprintf("dlr-if-%d", i),
+							"ip":                fmt.Sprintf("10.20.%d.1", i),
+							"mask":              "255.255.255.0",
+							"logical_switch_id": mockLogicalSwitchId,
+						},
+					},
+				})
+			return resourceNsxEdgeDLRInterfaceCreate(d, client)
+		})
+	}
+
+	// An edge update racing with the DHCP resources.
+	run(func() error {
+		d := schema.TestResourceDataRaw(t, resourceNsxEdge().Schema,
+			map[string]interface{}{
+				"type":        EdgeTypeGatewayServices,
+				"name":        "mock-esg",
+				"description": "updated",
+				"appliances": []interface{}{
+					map[string]interface{}{
+						"size": EdgeApplianceSizeCompact,
+						"appliance": []interface{}{
+							map[string]interface{}{
+								"resource_pool_id": "resgroup-1",
+								"datastore_id":     "datastore-1",
+							},
+						},
+					},
+				},
+			})
+		d.Set("edge_id", mockEdgeId)
+		return resourceNsxEdgeUpdate(d, client)
+	})
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Parallel edge operation failed: %s", err)
+	}
+
+	testAccCheckParallelEdgeUpdates(t, client, count)
+}
+
+func testAccCheckParallelEdgeUpdates(t *testing.T, client *govnsx.Client, count int) {
+
+	edgeCfg, err := nsxresource.NewEdge(client).Get(mockEdgeId)
+	if err != nil {
+		t.Fatalf("Edge Get failed with error: %s", err)
+	}
+
+	if edgeCfg.Description != "updated" {
+		t.Fatalf("Edge description update lost: %q", edgeCfg.Description)
+	}
+
+	for i := 0; i < count; i++ {
+		lsId := fmt.Sprintf("virtualwire-%d", 100+i)
+		found := false
+		for _, vnic := range edgeCfg.Vnics {
+			if vnic.PortgroupId == lsId && vnic.IsConnected {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("vNIC of logical switch %s lost: %#v", lsId, edgeCfg.Vnics)
+		}
+	}
+
+	if len(edgeCfg.Features.Dhcp.IPPools) != count {
+		t.Fatalf("Expected %d DHCP IP pools, got %#v", count,
+			edgeCfg.Features.Dhcp.IPPools)
+	}
+
+	ifaces, err := getDLRInterfaces(client, mockDLREdgeId)
+	if err != nil {
+		t.Fatalf("DLR interfaces Get failed with error: %s", err)
+	}
+	if len(ifaces.Interfaces) != count {
+		t.Fatalf("Expected %d DLR interfaces, got %#v", count,
+			ifaces.Interfaces)
+	}
+}
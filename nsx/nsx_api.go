@@ -0,0 +1,150 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+
+	"github.com/IBM-tfproviders/govnsx"
+)
+
+// nsxAPIError is returned by the nsx* request helpers when NSX Manager
+// answers with a status code outside of 200-204.
+type nsxAPIError struct {
+	StatusCode int
+	Status     string
+	Uri        string
+	Body       string
+}
+
+func (e *nsxAPIError) Error() string {
+	return fmt.Sprintf("[ERROR] %d : %s,\n URI:%s\n Body:%s",
+		e.StatusCode, e.Status, e.Uri, e.Body)
+}
+
+// objectRef is the <objectId> reference NSX uses to point at other objects.
+type objectRef struct {
+	ObjectId string `xml:"objectId"`
+}
+
+// rawXML keeps an element as it was received, so the parts of an object
+// which are not managed by a resource can be sent back to NSX unchanged.
+type rawXML struct {
+	XMLName  xml.Name
+	InnerXML string `xml:",innerxml"`
+}
+
+func isNotFoundError(err error) bool {
+	if apiErr, ok := err.(*nsxAPIError); ok {
+		return apiErr.StatusCode == 404
+	}
+	return false
+}
+
+//
+// The helpers below cover the NSX APIs which are not wrapped by
+// govnsx/nsxresource. They follow the same XML request/response handling.
+//
+
+// nsxGet issues a GET on uri and decodes the XML response into out.
+func nsxGet(client *govnsx.Client, uri string, out interface{}) error {
+
+	resp, err := client.Rclient.R().Get(uri)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != 200 {
+		return &nsxAPIError{resp.StatusCode(), resp.Status(), uri, string(resp.Body())}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return xml.Unmarshal(resp.Body(), out)
+}
+
+// nsxPost issues a POST on uri with the XML encoding of in as body. A nil
+// in sends an empty body. It returns the Location header and the raw body.
+func nsxPost(client *govnsx.Client, uri string, in interface{}) (string, []byte, error) {
+
+	req := client.Rclient.R()
+	if in != nil {
+		outputXML, err := xml.MarshalIndent(in, "  ", "    ")
+		if err != nil {
+			return "", nil, err
+		}
+		req.SetBody(outputXML)
+	}
+
+	resp, err := req.Post(uri)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sc := resp.StatusCode()
+	if (sc < 200) || (sc > 204) {
+		return "", nil, &nsxAPIError{sc, resp.Status(), uri, string(resp.Body())}
+	}
+
+	log.Printf("[DEBUG] POST %s returned %d", uri, sc)
+
+	return resp.RawResponse.Header.Get("Location"), resp.Body(), nil
+}
+
+// nsxPut issues a PUT on uri with the XML encoding of in as body.
+func nsxPut(client *govnsx.Client, uri string, in interface{}) error {
+
+	req := client.Rclient.R()
+	if in != nil {
+		outputXML, err := xml.MarshalIndent(in, "  ", "    ")
+		if err != nil {
+			return err
+		}
+		req.SetBody(outputXML)
+	}
+
+	resp, err := req.Put(uri)
+	if err != nil {
+		return err
+	}
+
+	sc := resp.StatusCode()
+	if (sc < 200) || (sc > 204) {
+		return &nsxAPIError{sc, resp.Status(), uri, string(resp.Body())}
+	}
+
+	return nil
+}
+
+// nsxDelete issues a DELETE on uri.
+func nsxDelete(client *govnsx.Client, uri string) error {
+	return nsxDeleteWithBody(client, uri, nil)
+}
+
+// nsxDeleteWithBody issues a DELETE on uri with the XML encoding of in as
+// body, for the APIs which identify the object to remove in the body.
+func nsxDeleteWithBody(client *govnsx.Client, uri string, in interface{}) error {
+
+	req := client.Rclient.R()
+	if in != nil {
+		outputXML, err := xml.MarshalIndent(in, "  ", "    ")
+		if err != nil {
+			return err
+		}
+		req.SetBody(outputXML)
+	}
+
+	resp, err := req.Delete(uri)
+	if err != nil {
+		return err
+	}
+
+	sc := resp.StatusCode()
+	if (sc < 200) || (sc > 204) {
+		return &nsxAPIError{sc, resp.Status(), uri, string(resp.Body())}
+	}
+
+	return nil
+}
@@ -0,0 +1,335 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+//
+// Network fabric (host preparation and VXLAN) configuration of the vCenter
+// clusters. NSX Manager installs a feature asynchronously on every host of
+// a cluster, the status of each host is polled until it is done.
+//
+
+const (
+	NwFabricConfigureUri         = "%s/api/2.0/nwfabric/configure"
+	NwFabricStatusUriFormat      = "%s/api/2.0/nwfabric/status?resource=%s"
+	NwFabricChildStatusUriFormat = "%s/api/2.0/nwfabric/status/child/%s"
+
+	NwFabricFeatureHostPrep = "com.vmware.vshield.vsm.nwfabric.hostPrep"
+	NwFabricFeatureVxlan    = "com.vmware.vshield.vsm.vxlan"
+
+	NwFabricStatusGreen = "GREEN"
+	NwFabricStatusRed   = "RED"
+
+	nwFabricStatePending = "pending"
+	nwFabricStateReady   = "ready"
+)
+
+// Smallest interval between two host status polls. Installing the VIBs on
+// a host takes minutes.
+var nwFabricPollInterval = 10 * time.Second
+
+var nwFabricFeatureNames = map[string]string{
+	NwFabricFeatureHostPrep: "Host preparation",
+	NwFabricFeatureVxlan:    "VXLAN configuration",
+}
+
+// nwFabricConfigSpec holds the fields of both the clusterMappingSpec and
+// the vdsContext config specs, Class tells which one it is.
+type nwFabricConfigSpec struct {
+	Class       string     `xml:"class,attr"`
+	Switch      *objectRef `xml:"switch,omitempty"`
+	VlanId      *int       `xml:"vlanId,omitempty"`
+	VmknicCount int        `xml:"vmknicCount,omitempty"`
+	IpPoolId    string     `xml:"ipPoolId,omitempty"`
+	Mtu         int        `xml:"mtu,omitempty"`
+	Teaming     string     `xml:"teaming,omitempty"`
+}
+
+type nwFabricResourceConfig struct {
+	ResourceId string              `xml:"resourceId"`
+	ConfigSpec *nwFabricConfigSpec `xml:"configSpec,omitempty"`
+}
+
+type nwFabricFeatureConfig struct {
+	XMLName         xml.Name                 `xml:"nwFabricFeatureConfig"`
+	FeatureId       string                   `xml:"featureId,omitempty"`
+	ResourceConfigs []nwFabricResourceConfig `xml:"resourceConfig"`
+}
+
+type nwFabricFeatureStatus struct {
+	FeatureId       string `xml:"featureId"`
+	FeatureVersion  string `xml:"featureVersion,omitempty"`
+	UpdateAvailable bool   `xml:"updateAvailable"`
+	Status          string `xml:"status"`
+	Message         string `xml:"message,omitempty"`
+	Installed       bool   `xml:"installed"`
+	Enabled         bool   `xml:"enabled"`
+}
+
+type nwFabricResourceStatus struct {
+	Resource        inventoryObject         `xml:"resource"`
+	FeatureStatuses []nwFabricFeatureStatus `xml:"nwFabricFeatureStatus"`
+}
+
+type nwFabricResourceStatuses struct {
+	XMLName  xml.Name                 `xml:"resourceStatuses"`
+	Statuses []nwFabricResourceStatus `xml:"resourceStatus"`
+}
+
+// nwFabricHostStatus is the status of one feature on one host.
+type nwFabricHostStatus struct {
+	HostId    string
+	Name      string
+	Status    string
+	Message   string
+	Installed bool
+}
+
+func (h *nwFabricHostStatus) ready() bool {
+	return h.Installed && h.Status == NwFabricStatusGreen
+}
+
+// nwFabricHostStatusSchema is the computed per host status of a feature.
+func nwFabricHostStatusSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"host_id": &schema.Schema{
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"name": &schema.Schema{
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"status": &schema.Schema{
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"message": &schema.Schema{
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+// configureNwFabric starts the installation of a feature and returns the
+// id of the NSX job doing it.
+func configureNwFabric(client *govnsx.Client, config *nwFabricFeatureConfig) (string, error) {
+
+	postUri := fmt.Sprintf(NwFabricConfigureUri, client.MgrConfig.Uri)
+
+	_, body, err := nsxPost(client, postUri, config)
+	if err != nil {
+		log.Printf("[ERROR] Configuring network fabric feature '%s' failed. %v",
+			config.FeatureId, err)
+		return "", err
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+// unconfigureNwFabric starts the removal of a feature.
+func unconfigureNwFabric(client *govnsx.Client, config *nwFabricFeatureConfig) error {
+
+	deleteUri := fmt.Sprintf(NwFabricConfigureUri, client.MgrConfig.Uri)
+	return nsxDeleteWithBody(client, deleteUri, config)
+}
+
+// getNwFabricStatus returns the status of the feature on the resource, or
+// nil when NSX does not report it.
+func getNwFabricStatus(client *govnsx.Client, resourceId string,
+	featureId string) (*nwFabricFeatureStatus, error) {
+
+	getUri := fmt.Sprintf(NwFabricStatusUriFormat, client.MgrConfig.Uri, resourceId)
+
+	statuses := &nwFabricResourceStatuses{}
+	if err := nsxGet(client, getUri, statuses); err != nil {
+		log.Printf("[ERROR] Retriving network fabric status of '%s' failed with error : '%v'",
+			resourceId, err)
+		return nil, err
+	}
+
+	for _, s := range statuses.Statuses {
+		if s.Resource.ObjectId != resourceId {
+			continue
+		}
+		for i, f := range s.FeatureStatuses {
+			if f.FeatureId == featureId {
+				return &s.FeatureStatuses[i], nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// getNwFabricHostStatuses returns the status of the feature on every host
+// of the cluster, sorted by host id.
+func getNwFabricHostStatuses(client *govnsx.Client, clusterId string,
+	featureId string) ([]nwFabricHostStatus, error) {
+
+	getUri := fmt.Sprintf(NwFabricChildStatusUriFormat, client.MgrConfig.Uri, clusterId)
+
+	statuses := &nwFabricResourceStatuses{}
+	if err := nsxGet(client, getUri, statuses); err != nil {
+		log.Printf("[ERROR] Retriving network fabric status of the hosts of '%s' failed with error : '%v'",
+			clusterId, err)
+		return nil, err
+	}
+
+	hosts := []nwFabricHostStatus{}
+	for _, s := range statuses.Statuses {
+		host := nwFabricHostStatus{HostId: s.Resource.ObjectId, Name: s.Resource.Name}
+		for _, f := range s.FeatureStatuses {
+			if f.FeatureId == featureId {
+				host.Status = f.Status
+				host.Message = f.Message
+				host.Installed = f.Installed
+			}
+		}
+		hosts = append(hosts, host)
+	}
+
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].HostId < hosts[j].HostId })
+	return hosts, nil
+}
+
+func nwFabricHostsReady(hosts []nwFabricHostStatus) bool {
+	for _, host := range hosts {
+		if !host.ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// nwFabricHostsError describes the hosts on which the feature failed, it
+// returns nil when no host failed.
+func nwFabricHostsError(clusterId string, featureId string,
+	hosts []nwFabricHostStatus) error {
+
+	failures := []string{}
+	for _, host := range hosts {
+		if host.Status == NwFabricStatusRed {
+			failures = append(failures, fmt.Sprintf("%s (%s): %s",
+				host.Name, host.HostId, host.Message))
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%s of cluster '%s' failed on host(s) %s",
+		nwFabricFeatureNames[featureId], clusterId, strings.Join(failures, "; "))
+}
+
+func flattenNwFabricHostStatuses(hosts []nwFabricHostStatus) []map[string]interface{} {
+
+	hostList := []map[string]interface{}{}
+	for _, host := range hosts {
+		hostList = append(hostList, map[string]interface{}{
+			"host_id": host.HostId,
+			"name":    host.Name,
+			"status":  host.Status,
+			"message": host.Message,
+		})
+	}
+	return hostList
+}
+
+// nwFabricStateRefreshFunc reports the cluster as ready once the feature is
+// installed, or removed when installed is not set, on all of its hosts. A
+// host reporting a failure ends the wait.
+func nwFabricStateRefreshFunc(client *govnsx.Client, clusterId string, featureId string,
+	installed bool) resource.StateRefreshFunc {
+
+	return func() (interface{}, string, error) {
+
+		hosts, err := getNwFabricHostStatuses(client, clusterId, featureId)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if installed {
+			if err := nwFabricHostsError(clusterId, featureId, hosts); err != nil {
+				return nil, "", err
+			}
+		}
+
+		for _, host := range hosts {
+			log.Printf("[DEBUG] Host '%s' %s status: '%s', installed: %t, message: '%s'",
+				host.HostId, featureId, host.Status, host.Installed, host.Message)
+
+			if installed && !host.ready() || !installed && host.Installed {
+				return clusterId, nwFabricStatePending, nil
+			}
+		}
+
+		return clusterId, nwFabricStateReady, nil
+	}
+}
+
+// waitForNwFabricHosts polls the hosts of the cluster until the feature is
+// installed, or removed, on every one of them.
+func waitForNwFabricHosts(client *govnsx.Client, clusterId string, featureId string,
+	installed bool, timeout time.Duration) error {
+
+	log.Printf("[INFO] Waiting for %s of cluster '%s' on all hosts",
+		nwFabricFeatureNames[featureId], clusterId)
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{nwFabricStatePending},
+		Target:     []string{nwFabricStateReady},
+		Refresh:    nwFabricStateRefreshFunc(client, clusterId, featureId, installed),
+		Timeout:    timeout,
+		MinTimeout: nwFabricPollInterval,
+	}
+
+	if _, err := stateConf.WaitForState(); err != nil {
+		log.Printf("[ERROR] Waiting for cluster '%s' failed with error : '%v'", clusterId, err)
+		return fmt.Errorf("Error waiting for %s of cluster '%s': %s",
+			nwFabricFeatureNames[featureId], clusterId, err)
+	}
+
+	return nil
+}
+
+// readNwFabricHosts sets the host_status and ready attributes from the
+// status of the feature on the hosts of the cluster.
+func readNwFabricHosts(d *schema.ResourceData, client *govnsx.Client, clusterId string,
+	featureId string) error {
+
+	hosts, err := getNwFabricHostStatuses(client, clusterId, featureId)
+	if err != nil {
+		return err
+	}
+
+	for _, host := range hosts {
+		if !host.ready() {
+			log.Printf("[WARN] %s of cluster '%s' is not ready on host %s (%s): %s %s",
+				nwFabricFeatureNames[featureId], clusterId, host.Name, host.HostId,
+				host.Status, host.Message)
+		}
+	}
+
+	d.Set("host_status", flattenNwFabricHostStatuses(hosts))
+	d.Set("ready", nwFabricHostsReady(hosts))
+
+	return nil
+}
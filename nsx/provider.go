@@ -1,7 +1,7 @@
 package nsx
 
 import (
-	//"fmt"
+	"fmt"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
@@ -33,6 +33,13 @@ func Provider() terraform.ResourceProvider {
 				Description: "The NSX Manager URI for API operations.",
 			},
 
+			"primary_nsx_manager_uri": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("NSXV_PRIMARY_NSX_MANAGER_URI", ""),
+				Description: "The primary NSX Manager URI for universal objects, defaults to nsx_manager_uri.",
+			},
+
 			"allow_unverified_ssl": &schema.Schema{
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -67,13 +74,59 @@ func Provider() terraform.ResourceProvider {
 				Default:     "Terraform-Nsx-Provider",
 				Description: "NSX client user agent name",
 			},
+
+			"max_retries": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("NSXV_MAX_RETRIES", DefaultMaxRetries),
+				ValidateFunc: validateNonNegativeInt,
+				Description:  "Maximum number of retries of an NSX API call while NSX Manager is busy.",
+			},
+
+			"retry_min_delay": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("NSXV_RETRY_MIN_DELAY", DefaultRetryMinDelay),
+				ValidateFunc: validateNonNegativeInt,
+				Description:  "Minimum delay in milliseconds between retries of an NSX API call.",
+			},
+
+			"retry_max_delay": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("NSXV_RETRY_MAX_DELAY", DefaultRetryMaxDelay),
+				ValidateFunc: validateNonNegativeInt,
+				Description:  "Maximum delay in milliseconds between retries of an NSX API call.",
+			},
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"nsxv_logical_switch": resourceLogicalSwitch(),
-			"nsxv_edge":           resourceNsxEdge(),
-			"nsxv_edge_dhcp":      resourceNsxEdgeDHCP(),
-			"nsxv_edge_dlr":       resourceNsxEdgeDLR(),
+			"nsxv_logical_switch":       resourceLogicalSwitch(),
+			"nsxv_edge":                 resourceNsxEdge(),
+			"nsxv_edge_dhcp":            resourceNsxEdgeDHCP(),
+			"nsxv_edge_dlr":             resourceNsxEdgeDLR(),
+			"nsxv_edge_dlr_bridge":      resourceNsxEdgeDLRBridge(),
+			"nsxv_mac_set":              resourceMacSet(),
+			"nsxv_security_policy":      resourceSecurityPolicy(),
+			"nsxv_transport_zone":       resourceTransportZone(),
+			"nsxv_edge_certificate":     resourceNsxEdgeCertificate(),
+			"nsxv_edge_ca_certificate":  resourceNsxEdgeCACertificate(),
+			"nsxv_edge_crl":             resourceNsxEdgeCrl(),
+			"nsxv_edge_dns_forwarder":   resourceNsxEdgeDnsForwarder(),
+			"nsxv_ip_pool":              resourceIPPool(),
+			"nsxv_ip_pool_allocation":   resourceIPPoolAllocation(),
+			"nsxv_segment_id_pool":      resourceSegmentIdPool(),
+			"nsxv_multicast_range":      resourceMulticastRange(),
+			"nsxv_cluster_preparation":  resourceClusterPreparation(),
+			"nsxv_vxlan_cluster_config": resourceVxlanClusterConfig(),
+			"nsxv_controller":           resourceController(),
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"nsxv_transport_zone":      dataSourceTransportZone(),
+			"nsxv_edge":                dataSourceNsxEdge(),
+			"nsxv_logical_switch":      dataSourceLogicalSwitch(),
+			"nsxv_edge_dlr_interfaces": dataSourceNsxEdgeDLRInterfaces(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -83,14 +136,31 @@ func Provider() terraform.ResourceProvider {
 func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 
 	config := Config{
-		User:          d.Get("user").(string),
-		Password:      d.Get("password").(string),
-		NsxManagerUri: d.Get("nsx_manager_uri").(string),
-		UserAgentName: d.Get("user_agent_name").(string),
-		InsecureFlag:  d.Get("allow_unverified_ssl").(bool),
-		Debug:         d.Get("client_debug").(bool),
-		DebugPathRun:  d.Get("client_debug_path_run").(string),
-		DebugPath:     d.Get("client_debug_path").(string),
+		User:                 d.Get("user").(string),
+		Password:             d.Get("password").(string),
+		NsxManagerUri:        d.Get("nsx_manager_uri").(string),
+		PrimaryNsxManagerUri: d.Get("primary_nsx_manager_uri").(string),
+		UserAgentName:        d.Get("user_agent_name").(string),
+		InsecureFlag:         d.Get("allow_unverified_ssl").(bool),
+		Debug:                d.Get("client_debug").(bool),
+		DebugPathRun:         d.Get("client_debug_path_run").(string),
+		DebugPath:            d.Get("client_debug_path").(string),
+		MaxRetries:           d.Get("max_retries").(int),
+		RetryMinDelay:        d.Get("retry_min_delay").(int),
+		RetryMaxDelay:        d.Get("retry_max_delay").(int),
+	}
+
+	// Values taken from the NSXV_* environment variables are not validated
+	// by the schema.
+	for _, k := range []string{"max_retries", "retry_min_delay", "retry_max_delay"} {
+		if _, errs := validateNonNegativeInt(d.Get(k), k); len(errs) > 0 {
+			return nil, errs[0]
+		}
+	}
+
+	if config.RetryMinDelay > config.RetryMaxDelay {
+		return nil, fmt.Errorf("retry_min_delay (%d) must not be greater than retry_max_delay (%d)",
+			config.RetryMinDelay, config.RetryMaxDelay)
 	}
 
 	return config.Client()
@@ -2,6 +2,7 @@ package nsx
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/schema"
@@ -30,6 +31,11 @@ func TestProvider_impl(t *testing.T) {
 }
 
 func testAccPreCheck(t *testing.T) {
+	if isTestAccMock() {
+		testAccMockNsxManager(t)
+		return
+	}
+
 	if v := os.Getenv("NSXV_USER"); v == "" {
 		t.Fatal("NSXV_USER must be set for acceptance tests")
 	}
@@ -42,3 +48,38 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("NSXV_NSX_MANAGER_URI must be set for acceptance tests")
 	}
 }
+
+func TestProvider_ValidatorFunc(t *testing.T) {
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "retry settings", validatorFn: validateNonNegativeInt,
+			values: []attributeProperty{
+				{value: 0, successCase: true},
+				{value: 500, successCase: true},
+				{value: -1, expErr: "must not be negative"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+// The NSXV_* environment variables bypass the schema validation, a negative
+// value must still be rejected when the provider is configured.
+func TestProvider_NegativeRetrySettingFromEnv(t *testing.T) {
+
+	for _, env := range []string{"NSXV_MAX_RETRIES", "NSXV_RETRY_MIN_DELAY", "NSXV_RETRY_MAX_DELAY"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv(env, "-1")
+
+			raw := map[string]interface{}{
+				"user":            "admin",
+				"password":        "secret",
+				"nsx_manager_uri": "https://nsx.example.com",
+			}
+			err := Provider().Configure(terraform.NewResourceConfigRaw(raw))
+			if err == nil || !strings.Contains(err.Error(), "must not be negative") {
+				t.Fatalf("Expected a negative value error for %s, got: %v", env, err)
+			}
+		})
+	}
+}
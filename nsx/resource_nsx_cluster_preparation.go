@@ -0,0 +1,159 @@
+package nsx
+
+import (
+	"log"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceClusterPreparation() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceClusterPreparationCreate,
+		Read:   resourceClusterPreparationRead,
+		Update: resourceClusterPreparationUpdate,
+		Delete: resourceClusterPreparationDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(30 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"cluster_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			// Read sets it to false while a host of the cluster is not
+			// prepared, e.g. a host added to the cluster, so the next apply
+			// installs it.
+			"ready": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"feature_version": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"host_status": nwFabricHostStatusSchema(),
+		},
+	}
+}
+
+func expandClusterPreparation(clusterId string) *nwFabricFeatureConfig {
+	return &nwFabricFeatureConfig{
+		ResourceConfigs: []nwFabricResourceConfig{{ResourceId: clusterId}},
+	}
+}
+
+// prepareCluster installs the NSX VIBs on the hosts of the cluster which
+// do not have them and waits for all hosts to be ready.
+func prepareCluster(client *govnsx.Client, clusterId string, timeout time.Duration) error {
+
+	jobId, err := configureNwFabric(client, expandClusterPreparation(clusterId))
+	if err != nil {
+		log.Printf("[ERROR] Preparing cluster '%s' failed. %v", clusterId, err)
+		return err
+	}
+
+	log.Printf("[INFO] Preparation of cluster %s started, job id: %s", clusterId, jobId)
+
+	return waitForNwFabricHosts(client, clusterId, NwFabricFeatureHostPrep, true, timeout)
+}
+
+func resourceClusterPreparationCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	clusterId := d.Get("cluster_id").(string)
+
+	// The id is set before waiting, a failed host leaves the cluster
+	// partially prepared and tainted.
+	d.SetId(clusterId)
+
+	if err := prepareCluster(client, clusterId, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Cluster %s prepared", clusterId)
+
+	return resourceClusterPreparationRead(d, meta)
+}
+
+func resourceClusterPreparationRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	status, err := getNwFabricStatus(client, d.Id(), NwFabricFeatureHostPrep)
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Cluster '%s' not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	if status == nil || !status.Installed {
+		log.Printf("[WARN] Cluster '%s' is not prepared, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("cluster_id", d.Id())
+	d.Set("feature_version", status.FeatureVersion)
+
+	return readNwFabricHosts(d, client, d.Id(), NwFabricFeatureHostPrep)
+}
+
+func resourceClusterPreparationUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	if d.HasChange("ready") {
+		log.Printf("[INFO] Preparing the hosts of cluster %s which are not ready", d.Id())
+
+		if err := prepareCluster(client, d.Id(), d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
+	}
+
+	return resourceClusterPreparationRead(d, meta)
+}
+
+func resourceClusterPreparationDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	// NSX refuses to unprepare a cluster while VXLAN is configured on it.
+	err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return unconfigureNwFabric(client, expandClusterPreparation(d.Id()))
+	})
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Cluster '%s' not found", d.Id())
+			d.SetId("")
+			return nil
+		}
+		log.Printf("[ERROR] Unpreparing cluster '%s' failed with error : %v", d.Id(), err)
+		return err
+	}
+
+	if err := waitForNwFabricHosts(client, d.Id(), NwFabricFeatureHostPrep, false,
+		d.Timeout(schema.TimeoutDelete)); err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Cluster unprepared :%s", d.Id())
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,162 @@
+package nsx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+var clusterId = testAccEnvOrMock("NSX_CLUSTER_ID", mockClusterId)
+
+const testAccCheckClusterPreparationConf = `
+resource "nsxv_cluster_preparation" "cluster" {
+    cluster_id = "%s"
+}
+`
+
+func testAccPreCheckCluster(t *testing.T) {
+
+	testAccPreCheck(t)
+
+	if clusterId == "" {
+		t.Fatal("NSX_CLUSTER_ID must be set for acceptance tests")
+	}
+
+	if isTestAccMock() {
+		testAccNwFabricPollInterval(t)
+	}
+}
+
+func testAccNwFabricPollInterval(t *testing.T) {
+
+	interval := nwFabricPollInterval
+	nwFabricPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { nwFabricPollInterval = interval })
+}
+
+func TestAccNsxClusterPreparation_Basic(t *testing.T) {
+
+	resourceName := "nsxv_cluster_preparation.cluster"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckCluster(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckClusterPreparationDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckClusterPreparationConf, clusterId),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "ready", "true"),
+					resource.TestCheckResourceAttrSet(resourceName, "feature_version"),
+					resource.TestCheckResourceAttrSet(resourceName, "host_status.0.host_id"),
+					resource.TestCheckResourceAttr(resourceName, "host_status.0.status",
+						NwFabricStatusGreen),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccNsxClusterPreparation_Lifecycle(t *testing.T) {
+
+	m, client := newMockNsxClient(t)
+	testAccNwFabricPollInterval(t)
+	m.FabricPolls = 2
+
+	d := schema.TestResourceDataRaw(t, resourceClusterPreparation().Schema,
+		map[string]interface{}{"cluster_id": mockClusterId})
+
+	if err := resourceClusterPreparationCreate(d, client); err != nil {
+		t.Fatalf("Cluster preparation failed with error: %s", err)
+	}
+	if !d.Get("ready").(bool) || d.Get("host_status.#").(int) != 2 {
+		t.Fatalf("Cluster not prepared: %#v", d.State())
+	}
+
+	// A host added to the cluster is reported as not ready, and prepared
+	// by the next update.
+	m.clusterHosts[mockClusterId] = append(m.clusterHosts[mockClusterId],
+		inventoryObject{ObjectId: "host-3", ObjectTypeName: InventoryTypeHost, Name: "mock-esx-3"})
+
+	if err := resourceClusterPreparationRead(d, client); err != nil {
+		t.Fatalf("Cluster read failed with error: %s", err)
+	}
+	if d.Get("ready").(bool) || d.Get("host_status.2.status").(string) == NwFabricStatusGreen {
+		t.Fatalf("New host not detected: %#v", d.State())
+	}
+
+	d.Set("ready", true)
+	if err := resourceClusterPreparationUpdate(d, client); err != nil {
+		t.Fatalf("Cluster update failed with error: %s", err)
+	}
+	if !d.Get("ready").(bool) || d.Get("host_status.2.status").(string) != NwFabricStatusGreen {
+		t.Fatalf("New host not prepared: %#v", d.State())
+	}
+
+	if err := resourceClusterPreparationDelete(d, client); err != nil {
+		t.Fatalf("Cluster unpreparation failed with error: %s", err)
+	}
+	if d.Id() != "" || m.hostFeatureStatus("host-3", NwFabricFeatureHostPrep, false).Installed {
+		t.Fatalf("Cluster still prepared")
+	}
+}
+
+func TestAccNsxClusterPreparation_HostFailure(t *testing.T) {
+
+	m, client := newMockNsxClient(t)
+	testAccNwFabricPollInterval(t)
+	m.FabricPolls = 1
+	m.HostFailures["host-2"] = "VIB installation failed"
+
+	d := schema.TestResourceDataRaw(t, resourceClusterPreparation().Schema,
+		map[string]interface{}{"cluster_id": mockClusterId})
+
+	err := resourceClusterPreparationCreate(d, client)
+	expErr := "mock-esx-2 (host-2): VIB installation failed"
+	if err == nil || !strings.Contains(err.Error(), expErr) {
+		t.Fatalf("Expected error '%s', got '%v'", expErr, err)
+	}
+	if d.Id() != mockClusterId {
+		t.Fatalf("Partially prepared cluster not kept in state")
+	}
+
+	if err := resourceClusterPreparationRead(d, client); err != nil {
+		t.Fatalf("Cluster read failed with error: %s", err)
+	}
+	if d.Get("ready").(bool) || d.Get("host_status.1.status").(string) != NwFabricStatusRed ||
+		d.Get("host_status.1.message").(string) != m.HostFailures["host-2"] {
+		t.Fatalf("Host failure not reported: %#v", d.State())
+	}
+}
+
+func testAccCheckClusterPreparationDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_cluster_preparation" {
+			continue
+		}
+
+		status, err := getNwFabricStatus(client, rs.Primary.ID, NwFabricFeatureHostPrep)
+		if err != nil {
+			return err
+		}
+		if status != nil && status.Installed {
+			return fmt.Errorf("Cluster %s is still prepared", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,562 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	ControllersUriFormat          = "%s/api/2.0/vdn/controller"
+	ControllerUriFormat           = "%s/api/2.0/vdn/controller/%s?forceRemoval=%t"
+	ControllerProgressUriFormat   = "%s/api/2.0/vdn/controller/progress/%s"
+	ControllerCredentialUriFormat = "%s/api/2.0/vdn/controller/credential"
+
+	ControllerJobStatusSuccess = "Success"
+	ControllerJobStatusFailure = "Failure"
+
+	ControllerStatusRunning = "RUNNING"
+
+	controllerStatePending = "pending"
+	controllerStateReady   = "ready"
+	controllerStateDeleted = "deleted"
+)
+
+// Smallest interval between two controller status polls. Deploying a
+// controller appliance takes minutes.
+var controllerPollInterval = 10 * time.Second
+
+type controllerSpec struct {
+	XMLName        xml.Name `xml:"controllerSpec"`
+	Name           string   `xml:"name"`
+	Description    string   `xml:"description,omitempty"`
+	IpPoolId       string   `xml:"ipPoolId"`
+	ResourcePoolId string   `xml:"resourcePoolId"`
+	HostId         string   `xml:"hostId,omitempty"`
+	DatastoreId    string   `xml:"datastoreId"`
+	NetworkId      string   `xml:"networkId"`
+	Password       string   `xml:"password"`
+}
+
+// controllerDeploymentInfo is the progress of a controller deployment job.
+type controllerDeploymentInfo struct {
+	XMLName          xml.Name `xml:"controllerDeploymentInfo"`
+	VmId             string   `xml:"vmId"`
+	Status           string   `xml:"status"`
+	ExceptionMessage string   `xml:"exceptionMessage,omitempty"`
+}
+
+type nsxController struct {
+	Id                 string    `xml:"id"`
+	Name               string    `xml:"name"`
+	Description        string    `xml:"description,omitempty"`
+	IpAddress          string    `xml:"ipAddress"`
+	Status             string    `xml:"status"`
+	VirtualMachineInfo objectRef `xml:"virtualMachineInfo"`
+	HostInfo           objectRef `xml:"hostInfo"`
+	ResourcePoolInfo   objectRef `xml:"resourcePoolInfo"`
+	DatastoreInfo      objectRef `xml:"datastoreInfo"`
+	ConnectedToInfo    objectRef `xml:"connectedToInfo"`
+}
+
+type nsxControllerList struct {
+	XMLName     xml.Name        `xml:"controllers"`
+	Controllers []nsxController `xml:"controller"`
+}
+
+type controllerCredential struct {
+	XMLName     xml.Name `xml:"controllerCredential"`
+	ApiPassword string   `xml:"apiPassword"`
+}
+
+func resourceController() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceControllerCreate,
+		Read:   resourceControllerRead,
+		Update: resourceControllerUpdate,
+		Delete: resourceControllerDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(15 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			// NSX does not return the pool of a controller, it is kept from
+			// the configuration.
+			"ip_pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"resource_pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"host_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"datastore_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			// The portgroup the controller is connected to.
+			"network_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			// NSX uses the same password for all its controllers, changing
+			// it changes the password of the other controllers too.
+			"password": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				Sensitive:    true,
+				ValidateFunc: validateCliPassword,
+			},
+
+			// The last controller of NSX Manager is only removed when set,
+			// the logical switches stop working without a controller.
+			"force_removal": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"ip_address": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"vm_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			// The job deploying the controller. It is the id of the
+			// resource until the controller is known.
+			"deployment_job_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceControllerCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	spec := &controllerSpec{
+		Name:           d.Get("name").(string),
+		Description:    d.Get("description").(string),
+		IpPoolId:       d.Get("ip_pool_id").(string),
+		ResourcePoolId: d.Get("resource_pool_id").(string),
+		HostId:         d.Get("host_id").(string),
+		DatastoreId:    d.Get("datastore_id").(string),
+		NetworkId:      d.Get("network_id").(string),
+		Password:       d.Get("password").(string),
+	}
+
+	postUri := fmt.Sprintf(ControllersUriFormat, client.MgrConfig.Uri)
+
+	_, body, err := nsxPost(client, postUri, spec)
+	if err != nil {
+		log.Printf("[ERROR] Controller creation failed. %v", err)
+		return err
+	}
+
+	jobId := strings.TrimSpace(string(body))
+	log.Printf("[INFO] Deployment of controller %s started, job id: %s", spec.Name, jobId)
+
+	// The job id is the id until the controller is known, a deployment
+	// which fails or times out is tainted and the VM it deployed is
+	// removed with it.
+	d.SetId(jobId)
+	d.Set("deployment_job_id", jobId)
+
+	vmId, err := waitForControllerJob(client, jobId, d.Timeout(schema.TimeoutCreate))
+	if err != nil {
+		return err
+	}
+
+	controller, err := findController(client, func(c *nsxController) bool {
+		return c.VirtualMachineInfo.ObjectId == vmId
+	})
+	if err != nil {
+		return err
+	}
+	if controller == nil {
+		return fmt.Errorf("No controller found for the VM '%s' deployed by job '%s'",
+			vmId, jobId)
+	}
+
+	// The id is set before waiting, a controller which does not come up
+	// is tainted.
+	d.SetId(controller.Id)
+	log.Printf("[INFO] Controller %s created with id: %s", spec.Name, d.Id())
+
+	if err := waitForControllerRunning(client, d.Id(), d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
+	return resourceControllerRead(d, meta)
+}
+
+func resourceControllerRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	if isControllerJobId(d) {
+		controller, pending, err := getJobController(client, d.Id())
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case controller != nil:
+			d.SetId(controller.Id)
+		case pending:
+			log.Printf("[INFO] Controller deployment job '%s' still in progress", d.Id())
+			return nil
+		default:
+			log.Printf("[WARN] Controller deployment job '%s' deployed no controller, "+
+				"removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+	}
+
+	controller, err := getController(client, d.Id())
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Controller '%s' not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", controller.Name)
+	d.Set("description", controller.Description)
+	d.Set("resource_pool_id", controller.ResourcePoolInfo.ObjectId)
+	d.Set("host_id", controller.HostInfo.ObjectId)
+	d.Set("datastore_id", controller.DatastoreInfo.ObjectId)
+	d.Set("network_id", controller.ConnectedToInfo.ObjectId)
+	d.Set("ip_address", controller.IpAddress)
+	d.Set("vm_id", controller.VirtualMachineInfo.ObjectId)
+	d.Set("status", controller.Status)
+
+	return nil
+}
+
+func resourceControllerUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	if d.HasChange("password") {
+		log.Printf("[INFO] Updating the password of the controllers")
+
+		putUri := fmt.Sprintf(ControllerCredentialUriFormat, client.MgrConfig.Uri)
+		credential := &controllerCredential{ApiPassword: d.Get("password").(string)}
+		if err := nsxPut(client, putUri, credential); err != nil {
+			log.Printf("[ERROR] Updating the password of the controllers failed with error : '%v'",
+				err)
+			return err
+		}
+	}
+
+	return resourceControllerRead(d, meta)
+}
+
+func resourceControllerDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	if isControllerJobId(d) {
+		// Let a deployment still in progress finish, to remove the VM it
+		// deploys. A failed deployment may have left a controller too.
+		if _, err := waitForControllerJob(client, d.Id(), d.Timeout(schema.TimeoutDelete)); err != nil {
+			log.Printf("[WARN] Controller deployment job '%s' did not succeed: %v", d.Id(), err)
+		}
+
+		controller, _, err := getJobController(client, d.Id())
+		if err != nil {
+			return err
+		}
+		if controller == nil {
+			log.Printf("[INFO] Controller deployment job '%s' deployed no controller", d.Id())
+			d.SetId("")
+			return nil
+		}
+		d.SetId(controller.Id)
+	}
+
+	force := d.Get("force_removal").(bool)
+
+	// The other controllers are checked and the controller removed under
+	// the lock, parallel deletions must not remove all the controllers.
+	controllerMutexKV.Lock(client.MgrConfig.Uri)
+	defer controllerMutexKV.Unlock(client.MgrConfig.Uri)
+
+	if !force {
+		last, err := findController(client, func(c *nsxController) bool {
+			return c.Id != d.Id()
+		})
+		if err != nil {
+			return err
+		}
+		if last == nil {
+			return fmt.Errorf("Controller '%s' is the last controller of NSX Manager, "+
+				"set force_removal to delete it.", d.Id())
+		}
+	}
+
+	deleteUri := fmt.Sprintf(ControllerUriFormat, client.MgrConfig.Uri, d.Id(), force)
+	if err := nsxDelete(client, deleteUri); err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Controller '%s' not found", d.Id())
+			d.SetId("")
+			return nil
+		}
+		log.Printf("[ERROR] Deleting Controller '%s' failed with error : %v", d.Id(), err)
+		return err
+	}
+
+	if err := waitForControllerDeleted(client, d.Id(), d.Timeout(schema.TimeoutDelete)); err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Controller deleted :%s", d.Id())
+	d.SetId("")
+	return nil
+}
+
+// findController returns the first controller of NSX Manager matching, or
+// nil when none does.
+func findController(client *govnsx.Client,
+	match func(*nsxController) bool) (*nsxController, error) {
+
+	getUri := fmt.Sprintf(ControllersUriFormat, client.MgrConfig.Uri)
+
+	list := &nsxControllerList{}
+	if err := nsxGet(client, getUri, list); err != nil {
+		log.Printf("[ERROR] Retriving Controllers failed with error : '%v'", err)
+		return nil, err
+	}
+
+	for i := range list.Controllers {
+		if match(&list.Controllers[i]) {
+			return &list.Controllers[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func getController(client *govnsx.Client, controllerId string) (*nsxController, error) {
+
+	controller, err := findController(client, func(c *nsxController) bool {
+		return c.Id == controllerId
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if controller == nil {
+		return nil, &nsxAPIError{StatusCode: 404, Status: "404 Not Found",
+			Uri:  fmt.Sprintf(ControllersUriFormat, client.MgrConfig.Uri),
+			Body: "Controller " + controllerId + " not found"}
+	}
+
+	log.Printf("[DEBUG] Controller details of '%s': '%v'", controllerId, controller)
+	return controller, nil
+}
+
+// isControllerJobId reports whether the id of the resource is still the id of
+// the job deploying the controller.
+func isControllerJobId(d *schema.ResourceData) bool {
+	return d.Id() != "" && d.Id() == d.Get("deployment_job_id").(string)
+}
+
+func getControllerJob(client *govnsx.Client, jobId string) (*controllerDeploymentInfo, error) {
+
+	getUri := fmt.Sprintf(ControllerProgressUriFormat, client.MgrConfig.Uri, jobId)
+
+	info := &controllerDeploymentInfo{}
+	if err := nsxGet(client, getUri, info); err != nil {
+		log.Printf("[ERROR] Retriving controller deployment job '%s' failed with error : '%v'",
+			jobId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] Controller deployment job '%s' status: '%s'", jobId, info.Status)
+	return info, nil
+}
+
+// getJobController returns the controller deployed by the job, or nil when
+// there is none. pending reports whether the job is still in progress.
+func getJobController(client *govnsx.Client, jobId string) (*nsxController, bool, error) {
+
+	info, err := getControllerJob(client, jobId)
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	if info.VmId != "" {
+		controller, err := findController(client, func(c *nsxController) bool {
+			return c.VirtualMachineInfo.ObjectId == info.VmId
+		})
+		if err != nil || controller != nil {
+			return controller, false, err
+		}
+	}
+
+	pending := info.Status != ControllerJobStatusSuccess &&
+		info.Status != ControllerJobStatusFailure
+	return nil, pending, nil
+}
+
+// waitForControllerJob polls the deployment job until it is done and
+// returns the id of the VM deployed.
+func waitForControllerJob(client *govnsx.Client, jobId string,
+	timeout time.Duration) (string, error) {
+
+	log.Printf("[INFO] Waiting for controller deployment job '%s'", jobId)
+
+	stateConf := &resource.StateChangeConf{
+		Pending: []string{controllerStatePending},
+		Target:  []string{controllerStateReady},
+		Refresh: func() (interface{}, string, error) {
+			info, err := getControllerJob(client, jobId)
+			if err != nil {
+				return nil, "", err
+			}
+
+			switch info.Status {
+			case ControllerJobStatusSuccess:
+				return info, controllerStateReady, nil
+			case ControllerJobStatusFailure:
+				return nil, "", fmt.Errorf("Controller deployment job '%s' failed: %s",
+					jobId, info.ExceptionMessage)
+			}
+			return info, controllerStatePending, nil
+		},
+		Timeout:    timeout,
+		MinTimeout: controllerPollInterval,
+	}
+
+	info, err := stateConf.WaitForState()
+	if err != nil {
+		log.Printf("[ERROR] Waiting for controller deployment job '%s' failed with error : '%v'",
+			jobId, err)
+		return "", fmt.Errorf("Error waiting for controller deployment: %s", err)
+	}
+
+	return info.(*controllerDeploymentInfo).VmId, nil
+}
+
+// waitForControllerRunning polls the controller until it is RUNNING.
+func waitForControllerRunning(client *govnsx.Client, controllerId string,
+	timeout time.Duration) error {
+
+	log.Printf("[INFO] Waiting for Controller '%s' to be running", controllerId)
+
+	stateConf := &resource.StateChangeConf{
+		Pending: []string{controllerStatePending},
+		Target:  []string{controllerStateReady},
+		Refresh: func() (interface{}, string, error) {
+			controller, err := getController(client, controllerId)
+			if err != nil {
+				return nil, "", err
+			}
+
+			log.Printf("[DEBUG] Controller '%s' status: '%s'", controllerId, controller.Status)
+
+			if controller.Status == ControllerStatusRunning {
+				return controller, controllerStateReady, nil
+			}
+			return controller, controllerStatePending, nil
+		},
+		Timeout:    timeout,
+		MinTimeout: controllerPollInterval,
+	}
+
+	if _, err := stateConf.WaitForState(); err != nil {
+		log.Printf("[ERROR] Waiting for Controller '%s' failed with error : '%v'", controllerId, err)
+		return fmt.Errorf("Error waiting for Controller '%s' to be running: %s", controllerId, err)
+	}
+
+	return nil
+}
+
+// waitForControllerDeleted polls the controllers until NSX Manager no
+// longer knows the controller.
+func waitForControllerDeleted(client *govnsx.Client, controllerId string,
+	timeout time.Duration) error {
+
+	log.Printf("[INFO] Waiting for Controller '%s' to be deleted", controllerId)
+
+	stateConf := &resource.StateChangeConf{
+		Pending: []string{controllerStatePending},
+		Target:  []string{controllerStateDeleted},
+		Refresh: func() (interface{}, string, error) {
+			_, err := getController(client, controllerId)
+			if isNotFoundError(err) {
+				return controllerId, controllerStateDeleted, nil
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			return controllerId, controllerStatePending, nil
+		},
+		Timeout:    timeout,
+		MinTimeout: controllerPollInterval,
+	}
+
+	if _, err := stateConf.WaitForState(); err != nil {
+		log.Printf("[ERROR] Waiting for Controller '%s' deletion failed with error : '%v'",
+			controllerId, err)
+		return fmt.Errorf("Error waiting for Controller '%s' to be deleted: %s", controllerId, err)
+	}
+
+	return nil
+}
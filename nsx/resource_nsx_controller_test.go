@@ -0,0 +1,300 @@
+package nsx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+var portgroupId = testAccEnvOrMock("NSX_PORTGROUP_ID", "dvportgroup-1")
+
+const testAccCheckControllerConf = `
+resource "nsxv_ip_pool" "controllers" {
+    name = "TFT_CONTROLLERS"
+    prefix_length = 24
+    gateway = "10.20.40.1"
+    ip_ranges = ["10.20.40.10-10.20.40.12"]
+}
+
+resource "nsxv_controller" "controller" {
+    name = "tf-acc-controller"
+    ip_pool_id = "${nsxv_ip_pool.controllers.id}"
+    resource_pool_id = "%s"
+    datastore_id = "%s"
+    network_id = "%s"
+    password = "%s"
+    force_removal = true
+}
+`
+
+func testAccPreCheckController(t *testing.T) {
+
+	testAccPreCheck(t)
+
+	if isTestAccMock() {
+		testAccControllerPollInterval(t)
+	}
+}
+
+func testAccControllerPollInterval(t *testing.T) {
+
+	interval := controllerPollInterval
+	controllerPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { controllerPollInterval = interval })
+}
+
+func TestAccNsxController_Basic(t *testing.T) {
+
+	resourceName := "nsxv_controller.controller"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckController(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckControllerDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckControllerConf, resourcePoolId, datastoreId,
+					portgroupId, "Tf-Acc-Passw0rd"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "status", ControllerStatusRunning),
+					resource.TestCheckResourceAttr(resourceName, "ip_address", "10.20.40.10"),
+					resource.TestCheckResourceAttrSet(resourceName, "vm_id"),
+					resource.TestCheckResourceAttrSet(resourceName, "host_id"),
+					resource.TestCheckResourceAttr("nsxv_ip_pool.controllers",
+						"used_address_count", "0"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckControllerConf, resourcePoolId, datastoreId,
+					portgroupId, "Tf-Acc-Passw0rd-2"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "password", "Tf-Acc-Passw0rd-2"),
+					resource.TestCheckResourceAttr("nsxv_ip_pool.controllers",
+						"used_address_count", "1"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{"ip_pool_id", "password",
+					"force_removal", "deployment_job_id"},
+			},
+		},
+	})
+}
+
+// newMockControllerPool adds an IP pool for the controllers to the mock.
+func newMockControllerPool(m *mockNsxManager) {
+	m.ipPools["ipaddresspool-1"] = &ipamAddressPool{ObjectId: "ipaddresspool-1",
+		PrefixLength: 24, IPRanges: []ipamIPRange{
+			{StartAddress: "10.20.40.10", EndAddress: "10.20.40.12"}}}
+}
+
+func testControllerResourceData(t *testing.T, r *schema.Resource, name string) *schema.ResourceData {
+
+	d := r.Data(nil)
+	for k, v := range map[string]interface{}{
+		"name":             name,
+		"ip_pool_id":       "ipaddresspool-1",
+		"resource_pool_id": "resgroup-1",
+		"datastore_id":     "datastore-1",
+		"network_id":       "dvportgroup-1",
+		"password":         "Tf-Acc-Passw0rd",
+	} {
+		if err := d.Set(k, v); err != nil {
+			t.Fatalf("Unable to set %s: %s", k, err)
+		}
+	}
+	return d
+}
+
+// createMockControllers deploys count controllers on the mock.
+func createMockControllers(t *testing.T, client *govnsx.Client, count int) []*schema.ResourceData {
+
+	controllers := []*schema.ResourceData{}
+	for i := 1; i <= count; i++ {
+		d := testControllerResourceData(t, resourceController(), fmt.Sprintf("controller-%d", i))
+		if err := resourceControllerCreate(d, client); err != nil {
+			t.Fatalf("Controller creation failed with error: %s", err)
+		}
+		if d.Get("status").(string) != ControllerStatusRunning ||
+			d.Get("ip_address").(string) != fmt.Sprintf("10.20.40.1%d", i-1) {
+			t.Fatalf("Unexpected controller: %#v", d.State())
+		}
+		controllers = append(controllers, d)
+	}
+	return controllers
+}
+
+func TestAccNsxController_LastNode(t *testing.T) {
+
+	m, client := newMockNsxClient(t)
+	testAccControllerPollInterval(t)
+	m.ControllerPolls = 2
+	newMockControllerPool(m)
+
+	controllers := createMockControllers(t, client, 2)
+
+	if err := resourceControllerDelete(controllers[0], client); err != nil {
+		t.Fatalf("Controller deletion failed with error: %s", err)
+	}
+
+	err := resourceControllerDelete(controllers[1], client)
+	if err == nil || !strings.Contains(err.Error(), "is the last controller") {
+		t.Fatalf("Expected the last controller deletion to fail, got '%v'", err)
+	}
+	if _, ok := m.controllers[controllers[1].Id()]; !ok {
+		t.Fatalf("Last controller deleted without force_removal")
+	}
+
+	controllers[1].Set("force_removal", true)
+	if err := resourceControllerDelete(controllers[1], client); err != nil {
+		t.Fatalf("Controller deletion failed with error: %s", err)
+	}
+	if len(m.controllers) != 0 || len(m.ipAllocs["ipaddresspool-1"]) != 0 {
+		t.Fatalf("Controllers not deleted: %v, %v", m.controllers, m.ipAllocs)
+	}
+}
+
+// A destroy deletes the controllers in parallel, the last one must still
+// be kept without force_removal.
+func TestAccNsxController_ParallelDelete(t *testing.T) {
+
+	m, client := newMockNsxClient(t)
+	testAccControllerPollInterval(t)
+	newMockControllerPool(m)
+
+	controllers := createMockControllers(t, client, 3)
+
+	errs := make(chan error, len(controllers))
+	for _, d := range controllers {
+		go func(d *schema.ResourceData) {
+			errs <- resourceControllerDelete(d, client)
+		}(d)
+	}
+
+	failed := 0
+	for range controllers {
+		if err := <-errs; err != nil {
+			if !strings.Contains(err.Error(), "is the last controller") {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			failed++
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if failed != 1 || len(m.controllers) != 1 {
+		t.Fatalf("Expected the last controller to be kept, %d deletions failed, "+
+			"controllers left: %v", failed, m.controllers)
+	}
+}
+
+func TestAccNsxController_DeploymentFailure(t *testing.T) {
+
+	m, client := newMockNsxClient(t)
+	testAccControllerPollInterval(t)
+	m.ControllerPolls = 1
+	m.ControllerFailure = "Insufficient disk space on datastore"
+	newMockControllerPool(m)
+
+	d := testControllerResourceData(t, resourceController(), "controller")
+
+	err := resourceControllerCreate(d, client)
+	if err == nil || !strings.Contains(err.Error(), m.ControllerFailure) {
+		t.Fatalf("Expected error '%s', got '%v'", m.ControllerFailure, err)
+	}
+	if d.Id() != d.Get("deployment_job_id").(string) || len(m.controllers) != 0 {
+		t.Fatalf("Unexpected failed controller: %s, %v", d.Id(), m.controllers)
+	}
+
+	// The failed job deployed nothing, the refresh removes it from state.
+	if err := resourceControllerRead(d, client); err != nil {
+		t.Fatalf("Controller read failed with error: %s", err)
+	}
+	if d.Id() != "" {
+		t.Fatalf("Failed controller kept in state: %s", d.Id())
+	}
+}
+
+// A deployment timing out keeps the job id. The refresh switches to the id
+// of the controller once its VM is known, the deletion of the tainted
+// resource removes the controller deployed by the job.
+func TestAccNsxController_DeploymentTimeout(t *testing.T) {
+
+	m, client := newMockNsxClient(t)
+	testAccControllerPollInterval(t)
+	m.ControllerPolls = 1000
+	newMockControllerPool(m)
+
+	r := resourceController()
+	timeout := 50 * time.Millisecond
+	r.Timeouts.Create = &timeout
+
+	controllers := []*schema.ResourceData{}
+	for _, name := range []string{"controller-1", "controller-2"} {
+		d := testControllerResourceData(t, r, name)
+		d.Set("force_removal", true)
+
+		err := resourceControllerCreate(d, client)
+		if err == nil || !strings.Contains(err.Error(), "timeout") {
+			t.Fatalf("Expected a timeout, got '%v'", err)
+		}
+		if jobId := d.Get("deployment_job_id").(string); jobId == "" || d.Id() != jobId {
+			t.Fatalf("Expected the job id as id, got '%s'", d.Id())
+		}
+		controllers = append(controllers, d)
+	}
+
+	jobId := controllers[0].Id()
+	if err := resourceControllerRead(controllers[0], client); err != nil {
+		t.Fatalf("Controller read failed with error: %s", err)
+	}
+	if _, ok := m.controllers[controllers[0].Id()]; !ok || controllers[0].Get("vm_id") == "" {
+		t.Fatalf("Expected the controller deployed by job %s, got '%s'", jobId,
+			controllers[0].Id())
+	}
+
+	m.Lock()
+	m.ctrlJobs[controllers[1].Id()].pendingPolls = 0
+	m.Unlock()
+
+	for _, d := range controllers {
+		if err := resourceControllerDelete(d, client); err != nil {
+			t.Fatalf("Controller deletion failed with error: %s", err)
+		}
+	}
+	if len(m.controllers) != 0 || len(m.ipAllocs["ipaddresspool-1"]) != 0 {
+		t.Fatalf("Controllers deployed by the jobs not deleted: %v, %v", m.controllers,
+			m.ipAllocs)
+	}
+}
+
+func testAccCheckControllerDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_controller" {
+			continue
+		}
+
+		_, err := getController(client, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Controller %s still exists", rs.Primary.ID)
+		}
+		if !isNotFoundError(err) {
+			return err
+		}
+	}
+
+	return testAccCheckIPPoolDestroy(s)
+}
@@ -3,11 +3,13 @@ package nsx
 import (
 	"fmt"
 	"log"
+	"net"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/IBM-tfproviders/govnsx"
 	"github.com/IBM-tfproviders/govnsx/nsxresource"
-	"github.com/IBM-tfproviders/govnsx/nsxtypes"
 	"github.com/hashicorp/terraform/helper/schema"
 )
 
@@ -19,6 +21,10 @@ const (
 	EdgeApplianceSizeLarge     = "large"
 	EdgeApplianceSizeQuadLarge = "quadlarge"
 	EdgeApplianceSizeXtraLarge = "xlarge"
+
+	EdgeCliDefaultUserName       = "admin"
+	EdgeCliDefaultPasswordExpiry = 99999 // days
+	EdgeCliPasswordMinLength     = 12
 )
 
 var edgeTypesList = []string{
@@ -33,6 +39,13 @@ var edgeApplianceSizeList = []string{
 	string(EdgeApplianceSizeXtraLarge),
 }
 
+// Appliance sizes an HA pair of appliances can be deployed with.
+var edgeApplianceHASizeList = []string{
+	string(EdgeApplianceSizeLarge),
+	string(EdgeApplianceSizeQuadLarge),
+	string(EdgeApplianceSizeXtraLarge),
+}
+
 type mgmtInterfaceCfg struct {
 	portgroup string
 	ip        string
@@ -45,9 +58,15 @@ type appliances struct {
 }
 
 type applianceCfg struct {
-	resourcePoolId string
-	datastoreId    string
-	mgmtInterface  mgmtInterfaceCfg
+	resourcePoolId   string
+	resourcePoolName string
+	datastoreId      string
+	datastoreName    string
+	hostId           string
+	hostName         string
+	folderId         string
+	folderName       string
+	mgmtInterface    mgmtInterfaceCfg
 }
 
 type nsxEdge struct {
@@ -56,7 +75,14 @@ type nsxEdge struct {
 	description string
 	tenantId    string
 	folder      string
+	fipsEnabled bool
+	universal   bool
 	appliances  appliances
+	cliSettings *edgeCliSettings
+	syslog      *edgeSyslog
+	dnsClient   *edgeDnsClient
+	haInterface *edgeMgmtInterface
+	routing     *edgeRouting
 }
 
 func resourceNsxEdge() *schema.Resource {
@@ -66,6 +92,14 @@ func resourceNsxEdge() *schema.Resource {
 		Update: resourceNsxEdgeUpdate,
 		Delete: resourceNsxEdgeDelete,
 
+		CustomizeDiff: resourceNsxEdgeCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"type": &schema.Schema{
 				Type:         schema.TypeString,
@@ -123,11 +157,39 @@ func resourceNsxEdge() *schema.Resource {
 								Schema: map[string]*schema.Schema{
 									"resource_pool_id": &schema.Schema{
 										Type:     schema.TypeString,
-										Required: true,
+										Optional: true,
+										Computed: true,
+									},
+									"resource_pool_name": &schema.Schema{
+										Type:     schema.TypeString,
+										Optional: true,
 									},
 									"datastore_id": &schema.Schema{
 										Type:     schema.TypeString,
-										Required: true,
+										Optional: true,
+										Computed: true,
+									},
+									"datastore_name": &schema.Schema{
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"host_id": &schema.Schema{
+										Type:     schema.TypeString,
+										Optional: true,
+										Computed: true,
+									},
+									"host_name": &schema.Schema{
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"folder_id": &schema.Schema{
+										Type:     schema.TypeString,
+										Optional: true,
+										Computed: true,
+									},
+									"folder_name": &schema.Schema{
+										Type:     schema.TypeString,
+										Optional: true,
 									},
 									"mgmt_interface": &schema.Schema{
 										Type:     schema.TypeList,
@@ -147,7 +209,7 @@ func resourceNsxEdge() *schema.Resource {
 												"mask": &schema.Schema{
 													Type:         schema.TypeString,
 													Required:     true,
-													ValidateFunc: validateIP,
+													ValidateFunc: validateNetmask,
 												},
 											},
 										},
@@ -158,6 +220,173 @@ func resourceNsxEdge() *schema.Resource {
 					},
 				},
 			},
+			"redeploy_trigger": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"upgrade_to_manager_version": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"appliance_version": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"fips_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+			// Only distributed routers can be universal.
+			"universal": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+			"cli_settings": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Computed: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"username": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  EdgeCliDefaultUserName,
+						},
+						"password": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							Sensitive:    true,
+							ValidateFunc: validateCliPassword,
+						},
+						"remote_access": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						"password_expiry": &schema.Schema{
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      EdgeCliDefaultPasswordExpiry,
+							ValidateFunc: validatePasswordExpiry,
+						},
+						"banner": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			// The NTP servers of the appliances are not managed.
+			"syslog": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"server_addresses": &schema.Schema{
+							Type:     schema.TypeList,
+							Required: true,
+							MinItems: 1,
+							MaxItems: 2,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validateIP,
+							},
+						},
+						"protocol": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      SyslogProtocolUDP,
+							ValidateFunc: validateSyslogProtocol,
+						},
+					},
+				},
+			},
+			"dns_client": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"primary_dns": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateIP,
+						},
+						"secondary_dns": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateIP,
+						},
+						"domain_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			// The HA interface of the control VMs of a distributed router,
+			// their path to the management network.
+			"ha_interface": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"portgroup": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"ip": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateIP,
+						},
+						"mask": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateNetmask,
+						},
+					},
+				},
+			},
+			// Global routing configuration of a distributed router.
+			"routing_global_config": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"router_id": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateRouterId,
+						},
+						"ecmp": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						"log_enabled": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						"log_level": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      RoutingLogLevelDefault,
+							ValidateFunc: validateRoutingLogLevel,
+						},
+					},
+				},
+			},
 		},
 	}
 }
@@ -168,43 +397,57 @@ func resourceNsxEdgeCreate(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[INFO] Creating NSX Edge: %#v", edgeCfg)
 
-	client := meta.(*govnsx.Client)
+	client := nsxClient(meta, edgeCfg.universal)
 
-	edge := nsxresource.NewEdge(client)
+	if err := resolveAppliances(client, &edgeCfg.appliances); err != nil {
+		return err
+	}
 
-	edgeInstallSpec := &nsxtypes.EdgeInstallSpec{
+	edgeSpec := &edgeConfig{
 		Name:        edgeCfg.edgeName,
 		Type:        edgeCfg.edgeType,
 		Description: edgeCfg.description,
 		Tenant:      edgeCfg.tenantId,
+		EnableFips:  edgeCfg.fipsEnabled,
+		IsUniversal: edgeCfg.universal,
 		Appliances:  createAppliancesSpec(edgeCfg.appliances),
+		CliSettings: edgeCfg.cliSettings,
+		DnsClient:   edgeCfg.dnsClient,
 	}
+	edgeSpec.Features.Syslog = edgeCfg.syslog
+	edgeSpec.MgmtInterface = edgeCfg.haInterface
+	edgeSpec.Features.Routing = edgeCfg.routing
 
-	resp, err := edge.Post(edgeInstallSpec)
+	edgeId, location, err := createEdge(client, edgeSpec)
 
 	if err != nil {
-		log.Printf("[ERROR] Edge Creation failed. %v", err)
 		return err
 	}
 
-	log.Printf("[INFO] Created NSX Edge: %s", resp.EdgeId)
+	log.Printf("[INFO] Created NSX Edge: %s", edgeId)
 
-	d.SetId(resp.Location)
-	d.Set("edge_id", resp.EdgeId)
+	d.SetId(location)
+	d.Set("edge_id", edgeId)
+	if err := d.Set("appliances", flattenAppliances(edgeCfg.appliances)); err != nil {
+		return fmt.Errorf("Invalid appliances to set: %s", err)
+	}
+
+	if _, err := waitForEdgeReady(client, edgeId,
+		edgeSpec.Appliances.DeployAppliances,
+		d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
 
 	return resourceNsxEdgeRead(d, meta)
 }
 
 func resourceNsxEdgeRead(d *schema.ResourceData, meta interface{}) error {
 
-	client := meta.(*govnsx.Client)
+	client := nsxClient(meta, d.Get("universal").(bool))
 	edgeId := d.Get("edge_id").(string)
 
-	edge := nsxresource.NewEdge(client)
-
-	retEdge, err := edge.Get(edgeId)
+	retEdge, err := getEdge(client, edgeId)
 	if err != nil {
-		log.Printf("[ERROR] Retriving Edge '%s' failed with error : '%v'", edgeId, err)
 		d.SetId("")
 		d.Set("edge_id", "")
 		return err
@@ -212,66 +455,198 @@ func resourceNsxEdgeRead(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[INFO] The Edge: %v", retEdge)
 
+	d.Set("fips_enabled", retEdge.EnableFips)
+	d.Set("universal", retEdge.IsUniversal)
+
+	summary, err := getEdgeSummary(client, edgeId)
+	if err != nil {
+		return err
+	}
+	vmVersion := summary.AppliancesSummary.VmVersion
+	d.Set("appliance_version", vmVersion)
+
+	// Report an outdated edge as not upgraded, the next apply upgrades it.
+	if d.Get("upgrade_to_manager_version").(bool) && vmVersion != "" {
+		managerVersion, err := getManagerVersion(client)
+		if err != nil {
+			return err
+		}
+		if vmVersion != managerVersion {
+			log.Printf("[INFO] Edge '%s' version '%s' differs from NSX Manager version '%s'",
+				edgeId, vmVersion, managerVersion)
+			d.Set("upgrade_to_manager_version", false)
+		}
+	}
+
+	// The password is not returned by NSX, keep the configured one.
+	password := ""
+	if cliSettings := expandEdgeCliSettings(d.Get("cli_settings").([]interface{})); cliSettings != nil {
+		password = cliSettings.Password
+	}
+	if err := d.Set("cli_settings",
+		flattenEdgeCliSettings(retEdge.CliSettings, password)); err != nil {
+		return fmt.Errorf("Invalid cli_settings to set: %s", err)
+	}
+
+	if err := d.Set("syslog", flattenEdgeSyslog(retEdge.Features.Syslog)); err != nil {
+		return fmt.Errorf("Invalid syslog to set: %s", err)
+	}
+	if err := d.Set("dns_client", flattenEdgeDnsClient(retEdge.DnsClient)); err != nil {
+		return fmt.Errorf("Invalid dns_client to set: %s", err)
+	}
+
+	// The routing of a services gateway is not managed by this resource.
+	if retEdge.Type == EdgeTypeDistributedRouter {
+		if err := d.Set("ha_interface",
+			flattenEdgeHaInterface(retEdge.MgmtInterface)); err != nil {
+			return fmt.Errorf("Invalid ha_interface to set: %s", err)
+		}
+		if err := d.Set("routing_global_config",
+			flattenEdgeRouting(retEdge.Features.Routing)); err != nil {
+			return fmt.Errorf("Invalid routing_global_config to set: %s", err)
+		}
+	}
+
 	return nil
 }
 
 func resourceNsxEdgeUpdate(d *schema.ResourceData, meta interface{}) error {
 
-	client := meta.(*govnsx.Client)
-	edge := nsxresource.NewEdge(client)
+	client := nsxClient(meta, d.Get("universal").(bool))
 
 	edgeId := d.Get("edge_id").(string)
 
-	retEdge, err := edge.Get(edgeId)
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
+
+	if d.HasChange("cli_settings") {
+		cliSettings := expandEdgeCliSettings(d.Get("cli_settings").([]interface{}))
+		if cliSettings != nil {
+			log.Printf("[DEBUG] Updating NsxEdge %s : cli_settings: user '%s', remote access '%t'",
+				edgeId, cliSettings.UserName, cliSettings.RemoteAccess)
+			if err := updateEdgeCliSettings(client, edgeId, cliSettings); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The placement is resolved once, modifyEdge may change the edge more
+	// than once.
+	var newAppliances appliances
+	if d.HasChange("appliances") {
+		newAppliances = parseAppliances(d)
+		if err := resolveAppliances(client, &newAppliances); err != nil {
+			return err
+		}
+		if err := d.Set("appliances", flattenAppliances(newAppliances)); err != nil {
+			return fmt.Errorf("Invalid appliances to set: %s", err)
+		}
+	}
+
+	edgeCfg, err := modifyEdge(client, edgeId, func(edgeCfg *edgeConfig) error {
+
+		if d.HasChange("name") {
+			_, v := d.GetChange("name")
+			edgeCfg.Name = v.(string)
+			log.Printf("[DEBUG] Updating NsxEdge %s : name: '%s'", edgeId, v)
+		}
+
+		if d.HasChange("description") {
+			_, v := d.GetChange("description")
+			edgeCfg.Description = v.(string)
+			log.Printf("[DEBUG] Updating NsxEdge %s : description: '%s'", edgeId, v)
+		}
+
+		if d.HasChange("appliances") {
+			deployAppliances := edgeCfg.Appliances.DeployAppliances
+			edgeCfg.Appliances = createAppliancesSpec(newAppliances)
+			edgeCfg.Appliances.DeployAppliances = deployAppliances
+			log.Printf("[DEBUG] Updating NsxEdge %s : Appliances: '%s'", edgeId,
+				newAppliances)
+		}
+
+		if d.HasChange("dns_client") {
+			dnsClient := expandEdgeDnsClient(d.Get("dns_client").([]interface{}))
+			if dnsClient == nil {
+				dnsClient = &edgeDnsClient{}
+			}
+			edgeCfg.DnsClient = dnsClient
+			log.Printf("[DEBUG] Updating NsxEdge %s : dns_client: '%#v'", edgeId, dnsClient)
+		}
 
+		if d.HasChange("ha_interface") {
+			edgeCfg.MgmtInterface = expandEdgeHaInterface(d.Get("ha_interface").([]interface{}))
+			log.Printf("[DEBUG] Updating NsxEdge %s : ha_interface: '%#v'", edgeId,
+				edgeCfg.MgmtInterface)
+		}
+
+		return nil
+	})
 	if err != nil {
-		log.Printf("[ERROR] Retriving Edge '%s' failed with error : '%v'", edgeId, err)
 		return err
 	}
 
-	edgeInstallSpec := &nsxtypes.EdgeInstallSpec{
-		Name:        retEdge.Name,
-		Description: retEdge.Description,
-		Tenant:      retEdge.Tenant,
-		Appliances:  retEdge.Appliances,
+	if d.HasChange("syslog") {
+		syslog := expandEdgeSyslog(d.Get("syslog").([]interface{}))
+		if syslog == nil {
+			syslog = &edgeSyslog{Enabled: false}
+		}
+		log.Printf("[DEBUG] Updating NsxEdge %s : syslog: '%#v'", edgeId, syslog)
+		if err := updateEdgeSyslog(client, edgeId, syslog); err != nil {
+			return err
+		}
 	}
 
-	if d.HasChange("name") {
-		_, v := d.GetChange("name")
-		edgeInstallSpec.Name = v.(string)
-		log.Printf("[DEBUG] Updating NsxEdge %s : name: '%s'", edgeId, v)
+	// The routing API keeps the static routes and routing protocols.
+	if d.HasChange("routing_global_config") {
+		routing := expandEdgeRouting(d.Get("routing_global_config").([]interface{}))
+		log.Printf("[DEBUG] Updating NsxEdge %s : routing_global_config: '%#v'", edgeId,
+			routing)
+		if err := setEdgeRouting(client, edgeId, routing); err != nil {
+			return err
+		}
 	}
 
-	if d.HasChange("description") {
-		_, v := d.GetChange("description")
-		edgeInstallSpec.Description = v.(string)
-		log.Printf("[DEBUG] Updating NsxEdge %s : description: '%s'", edgeId, v)
+	if _, err := waitForEdgeReady(client, edgeId,
+		edgeCfg.Appliances.DeployAppliances,
+		d.Timeout(schema.TimeoutUpdate)); err != nil {
+		return err
 	}
 
-	if d.HasChange("appliances") {
-		appliances := parseAppliances(d)
-		edgeInstallSpec.Appliances = createAppliancesSpec(appliances)
-		log.Printf("[DEBUG] Updating NsxEdge %s : Appliances: '%s'", edgeId,
-			appliances)
+	if d.HasChange("upgrade_to_manager_version") &&
+		d.Get("upgrade_to_manager_version").(bool) {
+		if err := upgradeEdge(client, edgeId, edgeCfg.Appliances.DeployAppliances,
+			d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
 	}
 
-	err = edge.Put(edgeInstallSpec, edgeId)
-
-	if err != nil {
-		log.Printf("[ERROR] Updating Edge '%s' failed with error : '%v'", edgeId, err)
-		return err
+	if d.HasChange("redeploy_trigger") {
+		log.Printf("[DEBUG] Updating NsxEdge %s : redeploy_trigger: '%s'", edgeId,
+			d.Get("redeploy_trigger").(string))
+		if err := edgeAction(client, edgeId, EdgeActionRedeploy); err != nil {
+			return err
+		}
+		if _, err := waitForEdgeReady(client, edgeId,
+			edgeCfg.Appliances.DeployAppliances,
+			d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return resourceNsxEdgeRead(d, meta)
 }
 
 func resourceNsxEdgeDelete(d *schema.ResourceData, meta interface{}) error {
 
-	client := meta.(*govnsx.Client)
+	client := nsxClient(meta, d.Get("universal").(bool))
 	edge := nsxresource.NewEdge(client)
 
 	edgeId := d.Get("edge_id").(string)
 
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
+
 	log.Printf("[INFO] Deleting NSX Edge: %s", edgeId)
 	err := edge.Delete(edgeId)
 	if err != nil {
@@ -279,6 +654,160 @@ func resourceNsxEdgeDelete(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 
+	return waitForEdgeDeleted(client, edgeId, d.Timeout(schema.TimeoutDelete))
+}
+
+// resourceNsxEdgeCustomizeDiff checks the management addresses, the HA
+// pair of appliances and the settings of the edge type, which NSX only
+// rejects once the edge is deployed.
+func resourceNsxEdgeCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+
+	edgeType := d.Get("type").(string)
+	isDLR := edgeType == EdgeTypeDistributedRouter
+
+	haL := d.Get("ha_interface").([]interface{})
+	if edgeType != "" && !isDLR {
+		for _, k := range []string{"ha_interface", "routing_global_config"} {
+			if len(d.Get(k).([]interface{})) > 0 {
+				return fmt.Errorf("%s: only supported on %s edges", k,
+					EdgeTypeDistributedRouter)
+			}
+		}
+		if d.Get("universal").(bool) {
+			return fmt.Errorf("universal: only supported on %s edges",
+				EdgeTypeDistributedRouter)
+		}
+	}
+
+	if err := checkUniversalChange(d, meta); err != nil {
+		return err
+	}
+
+	if len(haL) > 0 && haL[0] != nil {
+		ha := haL[0].(map[string]interface{})
+		ip, mask := ha["ip"].(string), ha["mask"].(string)
+		if ip != "" && mask != "" {
+			if _, err := validateHostAddress(ip, mask); err != nil {
+				return fmt.Errorf("ha_interface: %s", err)
+			}
+		} else if (ip != "" || mask != "") && d.NewValueKnown("ha_interface.0.ip") &&
+			d.NewValueKnown("ha_interface.0.mask") {
+			return fmt.Errorf("ha_interface: ip and mask must be set together")
+		}
+	}
+
+	vL := d.Get("appliances").([]interface{})
+	if len(vL) == 0 || vL[0] == nil {
+		return nil
+	}
+	appliances := vL[0].(map[string]interface{})
+	applianceList := appliances["appliance"].([]interface{})
+
+	// The control VMs of a distributed router exchange their heartbeats on
+	// the HA interface, whatever their size.
+	if isDLR && len(applianceList) > 1 && len(haL) == 0 {
+		return fmt.Errorf("appliances: an HA pair of %s control VMs needs an ha_interface",
+			EdgeTypeDistributedRouter)
+	}
+
+	if size := appliances["size"].(string); !isDLR && len(applianceList) > 1 && size != "" {
+		found := false
+		for _, t := range edgeApplianceHASizeList {
+			if t == size {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("appliances: size '%s' does not support HA, "+
+				"Supported values are %s", size, strings.Join(edgeApplianceHASizeList, ", "))
+		}
+	}
+
+	var haSubnet *net.IPNet
+	mgmtIPs := map[string]int{}
+	for i, value := range applianceList {
+
+		appliance, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		mgmtL, _ := appliance["mgmt_interface"].([]interface{})
+
+		if isDLR && len(mgmtL) > 0 {
+			return fmt.Errorf("appliance %d: mgmt_interface is not supported on %s edges, "+
+				"use ha_interface", i, EdgeTypeDistributedRouter)
+		}
+
+		for _, value := range mgmtL {
+			mgmt, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ip, mask := mgmt["ip"].(string), mgmt["mask"].(string)
+			// Unknown until apply.
+			if ip == "" || mask == "" {
+				continue
+			}
+
+			subnet, err := validateHostAddress(ip, mask)
+			if err != nil {
+				return fmt.Errorf("appliance %d: mgmt_interface: %s", i, err)
+			}
+
+			if j, ok := mgmtIPs[ip]; ok {
+				return fmt.Errorf("appliances %d and %d: mgmt_interface IP '%s' is not unique",
+					j, i, ip)
+			}
+			mgmtIPs[ip] = i
+
+			if haSubnet == nil {
+				haSubnet = subnet
+			} else if haSubnet.String() != subnet.String() {
+				return fmt.Errorf("appliance %d: mgmt_interface subnet '%s' differs "+
+					"from the HA peer subnet '%s'", i, subnet, haSubnet)
+			}
+		}
+	}
+
+	return nil
+}
+
+// upgradeEdge upgrades the edge appliances to the NSX Manager version and
+// waits for the edge to be back.
+func upgradeEdge(client *govnsx.Client, edgeId string, deployed bool,
+	timeout time.Duration) error {
+
+	managerVersion, err := getManagerVersion(client)
+	if err != nil {
+		return err
+	}
+
+	summary, err := getEdgeSummary(client, edgeId)
+	if err != nil {
+		return err
+	}
+	if summary.AppliancesSummary.VmVersion == managerVersion {
+		log.Printf("[INFO] Edge '%s' already at version '%s'", edgeId, managerVersion)
+		return nil
+	}
+
+	if err := edgeAction(client, edgeId, EdgeActionUpgrade); err != nil {
+		return err
+	}
+	if _, err := waitForEdgeReady(client, edgeId, deployed, timeout); err != nil {
+		return err
+	}
+
+	summary, err = getEdgeSummary(client, edgeId)
+	if err != nil {
+		return err
+	}
+	if summary.AppliancesSummary.VmVersion != managerVersion {
+		return fmt.Errorf("Edge '%s' is at version '%s' after upgrade to '%s'",
+			edgeId, summary.AppliancesSummary.VmVersion, managerVersion)
+	}
+
+	log.Printf("[INFO] Upgraded Edge '%s' to version '%s'", edgeId, managerVersion)
 	return nil
 }
 
@@ -295,7 +824,14 @@ func parseResourceData(d *schema.ResourceData) *nsxEdge {
 		edge.folder = v.(string)
 	}
 
+	edge.fipsEnabled = d.Get("fips_enabled").(bool)
+	edge.universal = d.Get("universal").(bool)
 	edge.appliances = parseAppliances(d)
+	edge.cliSettings = expandEdgeCliSettings(d.Get("cli_settings").([]interface{}))
+	edge.syslog = expandEdgeSyslog(d.Get("syslog").([]interface{}))
+	edge.dnsClient = expandEdgeDnsClient(d.Get("dns_client").([]interface{}))
+	edge.haInterface = expandEdgeHaInterface(d.Get("ha_interface").([]interface{}))
+	edge.routing = expandEdgeRouting(d.Get("routing_global_config").([]interface{}))
 
 	return edge
 }
@@ -321,7 +857,13 @@ func parseAppliances(d *schema.ResourceData) appliances {
 			appliance := value.(map[string]interface{})
 
 			newAppliance.resourcePoolId = appliance["resource_pool_id"].(string)
+			newAppliance.resourcePoolName = appliance["resource_pool_name"].(string)
 			newAppliance.datastoreId = appliance["datastore_id"].(string)
+			newAppliance.datastoreName = appliance["datastore_name"].(string)
+			newAppliance.hostId = appliance["host_id"].(string)
+			newAppliance.hostName = appliance["host_name"].(string)
+			newAppliance.folderId = appliance["folder_id"].(string)
+			newAppliance.folderName = appliance["folder_name"].(string)
 
 			if vL, ok := appliance["mgmt_interface"]; ok && vL != nil {
 
@@ -342,6 +884,100 @@ func parseAppliances(d *schema.ResourceData) appliances {
 	return newAppliances
 }
 
+// resolveAppliances sets the ids of the placement objects given by name.
+// A name takes precedence over the id, which holds the last resolved value.
+func resolveAppliances(client *govnsx.Client, appInfo *appliances) error {
+
+	var objects []inventoryObject
+	lookup := func(types []string, name string) (string, error) {
+		if objects == nil {
+			var err error
+			if objects, err = listInventoryObjects(client); err != nil {
+				return "", err
+			}
+		}
+		return findInventoryObject(objects, types, name)
+	}
+
+	for i := range appInfo.applianceList {
+		appliance := &appInfo.applianceList[i]
+
+		var err error
+		if appliance.resourcePoolName != "" {
+			appliance.resourcePoolId, err = lookup([]string{InventoryTypeResourcePool,
+				InventoryTypeCluster}, appliance.resourcePoolName)
+			if err != nil {
+				return err
+			}
+		}
+		if appliance.datastoreName != "" {
+			appliance.datastoreId, err = lookup([]string{InventoryTypeDatastore},
+				appliance.datastoreName)
+			if err != nil {
+				return err
+			}
+		}
+		if appliance.hostName != "" {
+			appliance.hostId, err = lookup([]string{InventoryTypeHost},
+				appliance.hostName)
+			if err != nil {
+				return err
+			}
+		}
+		if appliance.folderName != "" {
+			appliance.folderId, err = lookup([]string{InventoryTypeFolder},
+				appliance.folderName)
+			if err != nil {
+				return err
+			}
+		}
+
+		if appliance.resourcePoolId == "" {
+			return fmt.Errorf("appliance %d: one of resource_pool_id or resource_pool_name must be set", i)
+		}
+		if appliance.datastoreId == "" {
+			return fmt.Errorf("appliance %d: one of datastore_id or datastore_name must be set", i)
+		}
+	}
+
+	return nil
+}
+
+func flattenAppliances(appInfo appliances) []map[string]interface{} {
+
+	applianceList := []map[string]interface{}{}
+	for _, value := range appInfo.applianceList {
+
+		appliance := map[string]interface{}{
+			"resource_pool_id":   value.resourcePoolId,
+			"resource_pool_name": value.resourcePoolName,
+			"datastore_id":       value.datastoreId,
+			"datastore_name":     value.datastoreName,
+			"host_id":            value.hostId,
+			"host_name":          value.hostName,
+			"folder_id":          value.folderId,
+			"folder_name":        value.folderName,
+		}
+		if value.mgmtInterface.portgroup != "" {
+			appliance["mgmt_interface"] = []map[string]interface{}{
+				{
+					"portgroup": value.mgmtInterface.portgroup,
+					"ip":        value.mgmtInterface.ip,
+					"mask":      value.mgmtInterface.mask,
+				},
+			}
+		}
+		applianceList = append(applianceList, appliance)
+	}
+
+	return []map[string]interface{}{
+		{
+			"size":      appInfo.applianceSize,
+			"appliance": applianceList,
+		},
+	}
+}
+
 func validateEdgeType(v interface{}, k string) (ws []string, errors []error) {
 	value := v.(string)
 	found := false
@@ -376,19 +1012,308 @@ func validateEdgeApplianceSize(v interface{}, k string) (ws []string, errors []e
 	return
 }
 
-func createAppliancesSpec(appInfo appliances) nsxtypes.Appliances {
+func createAppliancesSpec(appInfo appliances) edgeAppliances {
 
-	applianceList := []nsxtypes.Appliance{}
+	applianceList := []edgeAppliance{}
 	for _, value := range appInfo.applianceList {
 
-		appliance := nsxtypes.Appliance{ResourcePoolId: value.resourcePoolId,
-			DatastoreId: value.datastoreId}
+		appliance := edgeAppliance{ResourcePoolId: value.resourcePoolId,
+			DatastoreId: value.datastoreId, HostId: value.hostId,
+			VmFolderId: value.folderId}
 
 		applianceList = append(applianceList, appliance)
 	}
 
-	appliances := nsxtypes.Appliances{ApplianceSize: appInfo.applianceSize,
+	appliances := edgeAppliances{ApplianceSize: appInfo.applianceSize,
 		DeployAppliances: false, AppliancesList: applianceList}
 
 	return appliances
 }
+
+func expandEdgeCliSettings(vL []interface{}) *edgeCliSettings {
+
+	if len(vL) == 0 || vL[0] == nil {
+		return nil
+	}
+	cliMap := vL[0].(map[string]interface{})
+
+	return &edgeCliSettings{
+		UserName:           cliMap["username"].(string),
+		Password:           cliMap["password"].(string),
+		RemoteAccess:       cliMap["remote_access"].(bool),
+		PasswordExpiry:     cliMap["password_expiry"].(int),
+		SshLoginBannerText: cliMap["banner"].(string),
+	}
+}
+
+func flattenEdgeCliSettings(cliSettings *edgeCliSettings,
+	password string) []map[string]interface{} {
+
+	if cliSettings == nil {
+		return []map[string]interface{}{}
+	}
+
+	return []map[string]interface{}{
+		{
+			"username":        cliSettings.UserName,
+			"password":        password,
+			"remote_access":   cliSettings.RemoteAccess,
+			"password_expiry": cliSettings.PasswordExpiry,
+			"banner":          cliSettings.SshLoginBannerText,
+		},
+	}
+}
+
+func expandEdgeSyslog(vL []interface{}) *edgeSyslog {
+
+	if len(vL) == 0 || vL[0] == nil {
+		return nil
+	}
+	syslogMap := vL[0].(map[string]interface{})
+
+	syslog := &edgeSyslog{
+		Enabled:  true,
+		Protocol: syslogMap["protocol"].(string),
+	}
+	for _, addr := range syslogMap["server_addresses"].([]interface{}) {
+		syslog.ServerAddresses = append(syslog.ServerAddresses, addr.(string))
+	}
+
+	return syslog
+}
+
+func flattenEdgeSyslog(syslog *edgeSyslog) []map[string]interface{} {
+
+	if syslog == nil || !syslog.Enabled {
+		return []map[string]interface{}{}
+	}
+
+	return []map[string]interface{}{
+		{
+			"server_addresses": syslog.ServerAddresses,
+			"protocol":         syslog.Protocol,
+		},
+	}
+}
+
+func expandEdgeDnsClient(vL []interface{}) *edgeDnsClient {
+
+	if len(vL) == 0 || vL[0] == nil {
+		return nil
+	}
+	dnsMap := vL[0].(map[string]interface{})
+
+	return &edgeDnsClient{
+		PrimaryDns:   dnsMap["primary_dns"].(string),
+		SecondaryDns: dnsMap["secondary_dns"].(string),
+		DomainName:   dnsMap["domain_name"].(string),
+	}
+}
+
+func flattenEdgeDnsClient(dnsClient *edgeDnsClient) []map[string]interface{} {
+
+	if dnsClient == nil || dnsClient.PrimaryDns == "" {
+		return []map[string]interface{}{}
+	}
+
+	return []map[string]interface{}{
+		{
+			"primary_dns":   dnsClient.PrimaryDns,
+			"secondary_dns": dnsClient.SecondaryDns,
+			"domain_name":   dnsClient.DomainName,
+		},
+	}
+}
+
+func expandEdgeHaInterface(vL []interface{}) *edgeMgmtInterface {
+
+	if len(vL) == 0 || vL[0] == nil {
+		return nil
+	}
+	haMap := vL[0].(map[string]interface{})
+
+	haInterface := &edgeMgmtInterface{ConnectedToId: haMap["portgroup"].(string)}
+	if ip := haMap["ip"].(string); ip != "" {
+		haInterface.AddressGroups = []addressGroup{
+			{PrimaryAddress: ip, SubnetMask: haMap["mask"].(string)},
+		}
+	}
+
+	return haInterface
+}
+
+func flattenEdgeHaInterface(haInterface *edgeMgmtInterface) []map[string]interface{} {
+
+	if haInterface == nil || haInterface.ConnectedToId == "" {
+		return []map[string]interface{}{}
+	}
+
+	haMap := map[string]interface{}{"portgroup": haInterface.ConnectedToId}
+	if len(haInterface.AddressGroups) > 0 {
+		haMap["ip"] = haInterface.AddressGroups[0].PrimaryAddress
+		haMap["mask"] = haInterface.AddressGroups[0].SubnetMask
+	}
+
+	return []map[string]interface{}{haMap}
+}
+
+func expandEdgeRouting(vL []interface{}) *edgeRouting {
+
+	if len(vL) == 0 || vL[0] == nil {
+		return nil
+	}
+	routingMap := vL[0].(map[string]interface{})
+
+	return &edgeRouting{
+		Enabled: true,
+		RoutingGlobalConfig: &edgeRoutingGlobalConfig{
+			RouterId: routingMap["router_id"].(string),
+			Ecmp:     routingMap["ecmp"].(bool),
+			Logging: &edgeRoutingLogging{
+				Enable:   routingMap["log_enabled"].(bool),
+				LogLevel: routingMap["log_level"].(string),
+			},
+		},
+	}
+}
+
+// setEdgeRouting applies the global configuration of routing to an edge,
+// or disables its routing when nil.
+func setEdgeRouting(client *govnsx.Client, edgeId string, routing *edgeRouting) error {
+
+	current, err := getEdgeRouting(client, edgeId)
+	if err != nil {
+		return err
+	}
+
+	if routing == nil {
+		if !current.Enabled {
+			return nil
+		}
+		current.Enabled = false
+		return updateEdgeRouting(client, edgeId, current)
+	}
+
+	globalConfig := routing.RoutingGlobalConfig
+	if current.RoutingGlobalConfig != nil {
+		globalConfig.Other = current.RoutingGlobalConfig.Other
+	}
+
+	if !current.Enabled {
+		current.Enabled = true
+		current.RoutingGlobalConfig = globalConfig
+		return updateEdgeRouting(client, edgeId, current)
+	}
+
+	return updateEdgeRoutingGlobalConfig(client, edgeId, globalConfig)
+}
+
+func flattenEdgeRouting(routing *edgeRouting) []map[string]interface{} {
+
+	if routing == nil || !routing.Enabled || routing.RoutingGlobalConfig == nil {
+		return []map[string]interface{}{}
+	}
+	globalConfig := routing.RoutingGlobalConfig
+
+	routingMap := map[string]interface{}{
+		"router_id":   globalConfig.RouterId,
+		"ecmp":        globalConfig.Ecmp,
+		"log_enabled": false,
+		"log_level":   RoutingLogLevelDefault,
+	}
+	if globalConfig.Logging != nil {
+		routingMap["log_enabled"] = globalConfig.Logging.Enable
+		if globalConfig.Logging.LogLevel != "" {
+			routingMap["log_level"] = globalConfig.Logging.LogLevel
+		}
+	}
+
+	return []map[string]interface{}{routingMap}
+}
+
+func validateSyslogProtocol(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	found := false
+
+	for _, t := range syslogProtocolsList {
+		if t == value {
+			found = true
+		}
+	}
+	if !found {
+		errors = append(errors, fmt.Errorf(
+			"%s: Supported values are %s", k, strings.Join(syslogProtocolsList, ", ")))
+	}
+
+	return
+}
+
+func validateRoutingLogLevel(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	found := false
+
+	for _, t := range routingLogLevelsList {
+		if t == value {
+			found = true
+		}
+	}
+	if !found {
+		errors = append(errors, fmt.Errorf(
+			"%s: Supported values are %s", k, strings.Join(routingLogLevelsList, ", ")))
+	}
+
+	return
+}
+
+// validateRouterId checks the router id is an IPv4 address, the form of the
+// 32 bit id of the routing protocols.
+func validateRouterId(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+		errors = append(errors, fmt.Errorf(
+			"%s: '%s' is not a valid IPv4 address", k, value))
+	}
+
+	return
+}
+
+// validateCliPassword enforces the password policy of the edge and controller
+// appliances.
+func validateCliPassword(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	var upper, lower, digit, special bool
+	for _, c := range value {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			special = true
+		}
+	}
+
+	if len(value) < EdgeCliPasswordMinLength || !upper || !lower || !digit || !special {
+		errors = append(errors, fmt.Errorf(
+			"%s: must be at least %d characters long and contain an upper case letter, "+
+				"a lower case letter, a digit and a special character",
+			k, EdgeCliPasswordMinLength))
+	}
+
+	return
+}
+
+func validatePasswordExpiry(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(int)
+
+	if value < 1 || value > EdgeCliDefaultPasswordExpiry {
+		errors = append(errors, fmt.Errorf(
+			"%s: must be between 1 and %d days", k, EdgeCliDefaultPasswordExpiry))
+	}
+
+	return
+}
@@ -0,0 +1,112 @@
+package nsx
+
+import (
+	"log"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceNsxEdgeCACertificate() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceNsxEdgeCACertificateCreate,
+		Read:   resourceNsxEdgeCACertificateRead,
+		Delete: resourceNsxEdgeCACertificateDelete,
+
+		CustomizeDiff: resourceNsxEdgeCACertificateCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"edge_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"certificate": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validatePemCertificate,
+			},
+			"subject_cn": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"issuer_cn": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"not_after": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"expired": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceNsxEdgeCACertificateCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	certs, err := importTrustCertificate(client, d.Get("edge_id").(string), &trustObject{
+		PemEncoding: d.Get("certificate").(string),
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(certs[0].ObjectId)
+
+	return resourceNsxEdgeCACertificateRead(d, meta)
+}
+
+func resourceNsxEdgeCACertificateRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	cert, err := getTrustCertificate(client, d.Id())
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] CA Certificate '%s' not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	expired := isCertificateExpired(cert)
+	if expired {
+		log.Printf("[WARN] CA Certificate '%s' expired on %s, it will be replaced",
+			d.Id(), formatNsxTime(cert.X509Certificate.NotAfter))
+	}
+
+	d.Set("subject_cn", cert.X509Certificate.SubjectCn)
+	d.Set("issuer_cn", cert.X509Certificate.IssuerCn)
+	d.Set("not_after", formatNsxTime(cert.X509Certificate.NotAfter))
+	d.Set("expired", expired)
+
+	return nil
+}
+
+func resourceNsxEdgeCACertificateCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	return forceNewWhenExpired(d)
+}
+
+func resourceNsxEdgeCACertificateDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	if err := deleteTrustCertificate(client, d.Id(), d.Timeout(schema.TimeoutDelete)); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,537 @@
+package nsx
+
+import (
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	TrustStoreCertificateUriFormat = "%s/api/2.0/services/truststore/certificate/%s"
+	TrustStoreCsrUriFormat         = "%s/api/2.0/services/truststore/csr/%s"
+	TrustStoreCrlUriFormat         = "%s/api/2.0/services/truststore/crl/%s"
+
+	CsrAlgorithmRSA = "RSA"
+	CsrAlgorithmDSA = "DSA"
+
+	PemTypeCertificate = "CERTIFICATE"
+	PemTypeCrl         = "X509 CRL"
+)
+
+var csrAlgorithmsList = []string{
+	string(CsrAlgorithmRSA),
+	string(CsrAlgorithmDSA),
+}
+
+var csrKeySizesList = []int{2048, 3072, 4096}
+
+// trustObject is the document used to import certificates and CRLs into
+// the truststore of an edge.
+type trustObject struct {
+	XMLName     xml.Name `xml:"trustObject"`
+	PemEncoding string   `xml:"pemEncoding"`
+	PrivateKey  string   `xml:"privateKey,omitempty"`
+	Passphrase  string   `xml:"passphrase,omitempty"`
+}
+
+type x509Certificate struct {
+	SubjectCn    string `xml:"subjectCn"`
+	IssuerCn     string `xml:"issuerCn"`
+	SerialNumber string `xml:"serialNumber"`
+	NotBefore    int64  `xml:"notBefore"` // milliseconds since the epoch
+	NotAfter     int64  `xml:"notAfter"`  // milliseconds since the epoch
+}
+
+type trustCertificate struct {
+	XMLName         xml.Name        `xml:"certificate"`
+	ObjectId        string          `xml:"objectId"`
+	PemEncoding     string          `xml:"pemEncoding"`
+	CertificateType string          `xml:"certificateType"`
+	X509Certificate x509Certificate `xml:"x509Certificate"`
+}
+
+type trustCertificates struct {
+	XMLName      xml.Name           `xml:"certificates"`
+	Certificates []trustCertificate `xml:"certificate"`
+}
+
+type csrAttribute struct {
+	Key   string `xml:"key"`
+	Value string `xml:"value"`
+}
+
+type trustCsr struct {
+	XMLName     xml.Name       `xml:"csr"`
+	ObjectId    string         `xml:"objectId,omitempty"`
+	Subject     []csrAttribute `xml:"subject>attribute"`
+	Algorithm   string         `xml:"algorithm"`
+	KeySize     int            `xml:"keySize"`
+	PemEncoding string         `xml:"pemEncoding,omitempty"`
+}
+
+func resourceNsxEdgeCertificate() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceNsxEdgeCertificateCreate,
+		Read:          resourceNsxEdgeCertificateRead,
+		Update:        resourceNsxEdgeCertificateUpdate,
+		Delete:        resourceNsxEdgeCertificateDelete,
+		CustomizeDiff: resourceNsxEdgeCertificateCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"edge_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"certificate": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"csr"},
+				ValidateFunc:  validatePemCertificate,
+			},
+			"private_key": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"csr"},
+			},
+			"passphrase": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"csr"},
+			},
+			"csr": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"common_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"organization": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"organization_unit": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"locality": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"state": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"country": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"algorithm": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      CsrAlgorithmRSA,
+							ValidateFunc: validateCsrAlgorithm,
+						},
+						"key_size": &schema.Schema{
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      2048,
+							ValidateFunc: validateCsrKeySize,
+						},
+					},
+				},
+			},
+			"signed_certificate": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"certificate"},
+				ValidateFunc:  validatePemCertificate,
+			},
+			"csr_pem": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"certificate_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"subject_cn": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"issuer_cn": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"not_after": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"expired": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceNsxEdgeCertificateCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+	edgeId := d.Get("edge_id").(string)
+
+	if v, ok := d.GetOk("certificate"); ok {
+
+		certs, err := importTrustCertificate(client, edgeId, &trustObject{
+			PemEncoding: v.(string),
+			PrivateKey:  d.Get("private_key").(string),
+			Passphrase:  d.Get("passphrase").(string),
+		})
+		if err != nil {
+			return err
+		}
+
+		d.SetId(certs[0].ObjectId)
+		d.Set("certificate_id", certs[0].ObjectId)
+
+		return resourceNsxEdgeCertificateRead(d, meta)
+	}
+
+	csrList, ok := d.GetOk("csr")
+	if !ok {
+		return fmt.Errorf("One of certificate or csr must be configured")
+	}
+
+	csrSpec := expandTrustCsr(csrList.([]interface{})[0].(map[string]interface{}))
+
+	postUri := fmt.Sprintf(TrustStoreCsrUriFormat, client.MgrConfig.Uri, edgeId)
+
+	_, body, err := nsxPost(client, postUri, csrSpec)
+	if err != nil {
+		log.Printf("[ERROR] CSR creation on Edge '%s' failed. %v", edgeId, err)
+		return err
+	}
+
+	csr := &trustCsr{}
+	if err := xml.Unmarshal(body, csr); err != nil {
+		return err
+	}
+
+	d.SetId(csr.ObjectId)
+	log.Printf("[INFO] CSR %s created on Edge '%s'", csr.ObjectId, edgeId)
+
+	if v, ok := d.GetOk("signed_certificate"); ok {
+		if err := importSignedCertificate(d, client, v.(string)); err != nil {
+			return err
+		}
+	}
+
+	return resourceNsxEdgeCertificateRead(d, meta)
+}
+
+func resourceNsxEdgeCertificateRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	certId := d.Get("certificate_id").(string)
+	if certId == "" {
+		// CSR waiting for its signed certificate.
+		csr := &trustCsr{}
+		getUri := fmt.Sprintf(TrustStoreCsrUriFormat, client.MgrConfig.Uri, d.Id())
+		if err := nsxGet(client, getUri, csr); err != nil {
+			if isNotFoundError(err) {
+				log.Printf("[WARN] CSR '%s' not found, removing from state", d.Id())
+				d.SetId("")
+				return nil
+			}
+			log.Printf("[ERROR] Retriving CSR '%s' failed with error : '%v'", d.Id(), err)
+			return err
+		}
+
+		d.Set("csr_pem", csr.PemEncoding)
+		return nil
+	}
+
+	cert, err := getTrustCertificate(client, certId)
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Certificate '%s' not found, removing from state", certId)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	setTrustCertificate(d, cert)
+
+	return nil
+}
+
+func resourceNsxEdgeCertificateUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	// Changing a signed certificate forces a new resource, only the first
+	// import of the signed certificate of the CSR gets here.
+	if d.HasChange("signed_certificate") {
+		if err := importSignedCertificate(d, client,
+			d.Get("signed_certificate").(string)); err != nil {
+			return err
+		}
+	}
+
+	return resourceNsxEdgeCertificateRead(d, meta)
+}
+
+func resourceNsxEdgeCertificateDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	if certId := d.Get("certificate_id").(string); certId != "" {
+		if err := deleteTrustCertificate(client, certId,
+			d.Timeout(schema.TimeoutDelete)); err != nil {
+			return err
+		}
+	}
+
+	// The CSR is consumed by the import of its signed certificate.
+	if _, ok := d.GetOk("csr"); ok {
+		deleteUri := fmt.Sprintf(TrustStoreCsrUriFormat, client.MgrConfig.Uri, d.Id())
+		err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+			return nsxDelete(client, deleteUri)
+		})
+		if err != nil && !isNotFoundError(err) {
+			log.Printf("[ERROR] Deleting CSR '%s' failed with error : %v", d.Id(), err)
+			return err
+		}
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func resourceNsxEdgeCertificateCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+
+	_, hasCert := d.GetOk("certificate")
+	_, hasCsr := d.GetOk("csr")
+	if !hasCert && !hasCsr && d.NewValueKnown("certificate") {
+		return fmt.Errorf("One of certificate or csr must be configured")
+	}
+
+	if d.HasChange("signed_certificate") {
+		if old, _ := d.GetChange("signed_certificate"); old.(string) != "" {
+			return d.ForceNew("signed_certificate")
+		}
+	}
+
+	return forceNewWhenExpired(d)
+}
+
+func importSignedCertificate(d *schema.ResourceData, client *govnsx.Client, pemCert string) error {
+
+	certs, err := importTrustCertificate(client, d.Id(), &trustObject{
+		PemEncoding: pemCert,
+	})
+	if err != nil {
+		return err
+	}
+
+	d.Set("certificate_id", certs[0].ObjectId)
+	return nil
+}
+
+// importTrustCertificate imports a certificate into the truststore scope
+// scopeId, an edge or the CSR the certificate was signed for. NSX returns
+// one object per certificate of the chain, the first one is the leaf.
+func importTrustCertificate(client *govnsx.Client, scopeId string,
+	trustObj *trustObject) ([]trustCertificate, error) {
+
+	postUri := fmt.Sprintf(TrustStoreCertificateUriFormat, client.MgrConfig.Uri, scopeId)
+
+	_, body, err := nsxPost(client, postUri, trustObj)
+	if err != nil {
+		log.Printf("[ERROR] Certificate import into '%s' failed. %v", scopeId, err)
+		return nil, err
+	}
+
+	certs := &trustCertificates{}
+	if err := xml.Unmarshal(body, certs); err != nil {
+		return nil, err
+	}
+	if len(certs.Certificates) == 0 {
+		return nil, fmt.Errorf("No certificate returned by the import into '%s'", scopeId)
+	}
+
+	log.Printf("[INFO] Certificate %s imported into '%s'", certs.Certificates[0].ObjectId, scopeId)
+	return certs.Certificates, nil
+}
+
+func getTrustCertificate(client *govnsx.Client, certId string) (*trustCertificate, error) {
+
+	getUri := fmt.Sprintf(TrustStoreCertificateUriFormat, client.MgrConfig.Uri, certId)
+
+	cert := &trustCertificate{}
+	if err := nsxGet(client, getUri, cert); err != nil {
+		log.Printf("[ERROR] Retriving Certificate '%s' failed with error : '%v'", certId, err)
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+func deleteTrustCertificate(client *govnsx.Client, certId string, timeout time.Duration) error {
+
+	deleteUri := fmt.Sprintf(TrustStoreCertificateUriFormat, client.MgrConfig.Uri, certId)
+	err := retryWhileInUse(timeout, func() error {
+		return nsxDelete(client, deleteUri)
+	})
+	if err != nil && !isNotFoundError(err) {
+		log.Printf("[ERROR] Deleting Certificate '%s' failed with error : %v", certId, err)
+		return err
+	}
+
+	log.Printf("[INFO] Certificate deleted :%s", certId)
+	return nil
+}
+
+func setTrustCertificate(d *schema.ResourceData, cert *trustCertificate) {
+	d.Set("certificate_id", cert.ObjectId)
+	d.Set("subject_cn", cert.X509Certificate.SubjectCn)
+	d.Set("issuer_cn", cert.X509Certificate.IssuerCn)
+	d.Set("not_after", formatNsxTime(cert.X509Certificate.NotAfter))
+
+	expired := isCertificateExpired(cert)
+	if expired {
+		log.Printf("[WARN] Certificate '%s' expired on %s, it will be replaced",
+			cert.ObjectId, formatNsxTime(cert.X509Certificate.NotAfter))
+	}
+	d.Set("expired", expired)
+}
+
+func expandTrustCsr(csrMap map[string]interface{}) *trustCsr {
+
+	csr := &trustCsr{
+		Algorithm: csrMap["algorithm"].(string),
+		KeySize:   csrMap["key_size"].(int),
+	}
+
+	subjectKeys := []struct{ key, attr string }{
+		{"CN", "common_name"},
+		{"O", "organization"},
+		{"OU", "organization_unit"},
+		{"L", "locality"},
+		{"ST", "state"},
+		{"C", "country"},
+	}
+	for _, s := range subjectKeys {
+		if v := csrMap[s.attr].(string); v != "" {
+			csr.Subject = append(csr.Subject, csrAttribute{Key: s.key, Value: v})
+		}
+	}
+
+	return csr
+}
+
+func isCertificateExpired(cert *trustCertificate) bool {
+	notAfter := cert.X509Certificate.NotAfter
+	return notAfter > 0 && time.Now().After(nsxTime(notAfter))
+}
+
+// forceNewWhenExpired replaces a certificate or CRL expired on the edge, so
+// the expired one is deleted from the edge by the apply.
+func forceNewWhenExpired(d *schema.ResourceDiff) error {
+
+	if d.Id() == "" || !d.Get("expired").(bool) {
+		return nil
+	}
+
+	if err := d.SetNew("expired", false); err != nil {
+		return err
+	}
+	return d.ForceNew("expired")
+}
+
+// NSX reports certificate dates in milliseconds since the epoch.
+func nsxTime(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+func formatNsxTime(ms int64) string {
+	if ms == 0 {
+		return ""
+	}
+	return nsxTime(ms).Format(time.RFC3339)
+}
+
+func validatePem(v interface{}, k string, pemType string) (ws []string, errors []error) {
+
+	value := strings.TrimSpace(v.(string))
+	if value == "" {
+		return
+	}
+
+	block, _ := pem.Decode([]byte(value))
+	if block == nil || block.Type != pemType {
+		errors = append(errors, fmt.Errorf(
+			"%s: must be a PEM encoded %s", k, pemType))
+	}
+	return
+}
+
+func validatePemCertificate(v interface{}, k string) (ws []string, errors []error) {
+	return validatePem(v, k, PemTypeCertificate)
+}
+
+func validatePemCrl(v interface{}, k string) (ws []string, errors []error) {
+	return validatePem(v, k, PemTypeCrl)
+}
+
+func validateCsrAlgorithm(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	found := false
+
+	for _, t := range csrAlgorithmsList {
+		if t == value {
+			found = true
+		}
+	}
+
+	if !found {
+		errors = append(errors, fmt.Errorf(
+			"%s: Supported values are %s", k, strings.Join(csrAlgorithmsList, ", ")))
+	}
+
+	return
+}
+
+func validateCsrKeySize(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(int)
+
+	for _, size := range csrKeySizesList {
+		if size == value {
+			return
+		}
+	}
+
+	errors = append(errors, fmt.Errorf(
+		"%s: Supported values are %s", k, strings.Trim(fmt.Sprint(csrKeySizesList), "[]")))
+	return
+}
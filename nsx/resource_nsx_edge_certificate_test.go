@@ -0,0 +1,349 @@
+package nsx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const testAccCheckEdgeCertificateConf = `
+resource "nsxv_edge_certificate" "cert" {
+    edge_id = "%s"
+    certificate = <<EOT
+%sEOT
+    private_key = <<EOT
+%sEOT
+}
+
+resource "nsxv_edge_ca_certificate" "ca" {
+    edge_id = "%s"
+    certificate = <<EOT
+%sEOT
+}
+
+resource "nsxv_edge_crl" "crl" {
+    edge_id = "%s"
+    crl = <<EOT
+%sEOT
+}
+`
+
+type testCertificates struct {
+	caPEM   string
+	certPEM string
+	keyPEM  string
+	crlPEM  string
+}
+
+// testAccGenerateCertificates builds a CA, a server certificate signed by
+// it, valid for validity, and an empty CRL of the CA.
+func testAccGenerateCertificates(t *testing.T, validity time.Duration) *testCertificates {
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tf-acc-ca"},
+		NotBefore:             time.Now().Add(-2 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Unable to create CA certificate: %s", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "tf-acc-edge"},
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     time.Now().Add(validity),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Unable to create certificate: %s", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Unable to marshal key: %s", err)
+	}
+	crlDER, err := ca.CreateCRL(rand.Reader, caKey, nil, time.Now(), time.Now().Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Unable to create CRL: %s", err)
+	}
+
+	return &testCertificates{
+		caPEM:   string(pem.EncodeToMemory(&pem.Block{Type: PemTypeCertificate, Bytes: caDER})),
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: PemTypeCertificate, Bytes: certDER})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+		crlPEM:  string(pem.EncodeToMemory(&pem.Block{Type: PemTypeCrl, Bytes: crlDER})),
+	}
+}
+
+func TestAccNsxEdgeCertificate_ValidatorFunc(t *testing.T) {
+
+	certs := testAccGenerateCertificates(t, time.Hour)
+
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "certificate", validatorFn: validatePemCertificate,
+			values: []attributeProperty{
+				{value: certs.certPEM, successCase: true},
+				{value: certs.crlPEM, expErr: "must be a PEM encoded CERTIFICATE"},
+				{value: "not a certificate", expErr: "must be a PEM encoded CERTIFICATE"},
+			},
+		},
+		{name: "crl", validatorFn: validatePemCrl,
+			values: []attributeProperty{
+				{value: certs.crlPEM, successCase: true},
+				{value: certs.caPEM, expErr: "must be a PEM encoded X509 CRL"},
+			},
+		},
+		{name: "algorithm", validatorFn: validateCsrAlgorithm,
+			values: []attributeProperty{
+				{value: CsrAlgorithmRSA, successCase: true},
+				{value: CsrAlgorithmDSA, successCase: true},
+				{value: "ECDSA", expErr: "Supported values are"},
+			},
+		},
+		{name: "key_size", validatorFn: validateCsrKeySize,
+			values: []attributeProperty{
+				{value: 2048, successCase: true},
+				{value: 4096, successCase: true},
+				{value: 1024, expErr: "Supported values are"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+func TestAccNsxEdgeCertificate_Basic(t *testing.T) {
+
+	certs := testAccGenerateCertificates(t, 12*time.Hour)
+
+	config := fmt.Sprintf(testAccCheckEdgeCertificateConf,
+		edgeId, certs.certPEM, certs.keyPEM,
+		edgeId, certs.caPEM,
+		edgeId, certs.crlPEM)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdgeCertificate(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeCertificateDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"nsxv_edge_certificate.cert", "subject_cn", "tf-acc-edge"),
+					resource.TestCheckResourceAttr(
+						"nsxv_edge_certificate.cert", "issuer_cn", "tf-acc-ca"),
+					resource.TestCheckResourceAttrSet(
+						"nsxv_edge_certificate.cert", "certificate_id"),
+					resource.TestCheckResourceAttrSet(
+						"nsxv_edge_certificate.cert", "not_after"),
+					resource.TestCheckResourceAttr(
+						"nsxv_edge_ca_certificate.ca", "subject_cn", "tf-acc-ca"),
+					resource.TestCheckResourceAttrSet(
+						"nsxv_edge_crl.crl", "next_update"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeCertificate_CSR(t *testing.T) {
+
+	_, client := newMockNsxClient(t)
+	certs := testAccGenerateCertificates(t, 12*time.Hour)
+
+	raw := map[string]interface{}{
+		"edge_id": mockEdgeId,
+		"csr": []interface{}{
+			map[string]interface{}{
+				"common_name":  "tf-acc-edge",
+				"organization": "tf-acc",
+			},
+		},
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceNsxEdgeCertificate().Schema, raw)
+	if err := resourceNsxEdgeCertificateCreate(d, client); err != nil {
+		t.Fatalf("CSR creation failed with error: %s", err)
+	}
+	if d.Get("csr_pem").(string) == "" || d.Get("certificate_id").(string) != "" {
+		t.Fatalf("Unexpected state after CSR creation: %#v", d.State())
+	}
+
+	// Import of the signed certificate.
+	csrId := d.Id()
+	raw["signed_certificate"] = certs.certPEM
+	d = schema.TestResourceDataRaw(t, resourceNsxEdgeCertificate().Schema, raw)
+	d.SetId(csrId)
+	if err := resourceNsxEdgeCertificateUpdate(d, client); err != nil {
+		t.Fatalf("Signed certificate import failed with error: %s", err)
+	}
+	if d.Id() != csrId || d.Get("subject_cn").(string) != "tf-acc-edge" {
+		t.Fatalf("Unexpected state after signed certificate import: %#v", d.State())
+	}
+
+	if err := resourceNsxEdgeCertificateDelete(d, client); err != nil {
+		t.Fatalf("Certificate deletion failed with error: %s", err)
+	}
+}
+
+func TestAccNsxEdgeCertificate_Expired(t *testing.T) {
+
+	_, client := newMockNsxClient(t)
+	certs := testAccGenerateCertificates(t, -time.Hour)
+
+	d := schema.TestResourceDataRaw(t, resourceNsxEdgeCACertificate().Schema,
+		map[string]interface{}{
+			"edge_id":     mockEdgeId,
+			"certificate": certs.certPEM,
+		})
+	if err := resourceNsxEdgeCACertificateCreate(d, client); err != nil {
+		t.Fatalf("Certificate import failed with error: %s", err)
+	}
+	if d.Id() == "" || !d.Get("expired").(bool) {
+		t.Fatalf("Expired certificate not kept in state as expired: %#v", d.State())
+	}
+}
+
+func TestAccNsxEdgeCertificate_ExpiredReplaced(t *testing.T) {
+
+	var m *mockNsxManager
+	certs := testAccGenerateCertificates(t, 12*time.Hour)
+
+	config := fmt.Sprintf(testAccCheckEdgeCertificateConf,
+		edgeId, certs.certPEM, certs.keyPEM,
+		edgeId, certs.caPEM,
+		edgeId, certs.crlPEM)
+
+	resourceNames := []string{
+		"nsxv_edge_certificate.cert", "nsxv_edge_ca_certificate.ca", "nsxv_edge_crl.crl",
+	}
+	expiredIds := make(map[string]string)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			if !isTestAccMock() {
+				t.Skip("Expiring the certificates requires the mock NSX Manager")
+			}
+			m = testAccMockNsxManager(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeCertificateDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: func(s *terraform.State) error {
+					for _, name := range resourceNames {
+						rs := s.RootModule().Resources[name]
+						if rs.Primary.Attributes["expired"] != "false" {
+							return fmt.Errorf("%s is expired", name)
+						}
+						expiredIds[name] = rs.Primary.ID
+					}
+					return nil
+				},
+			},
+			resource.TestStep{
+				PreConfig: func() {
+					m.Lock()
+					defer m.Unlock()
+
+					past := time.Now().Add(-time.Hour).UnixNano() / int64(time.Millisecond)
+					for _, cert := range m.certificates {
+						cert.X509Certificate.NotAfter = past
+					}
+					for _, crl := range m.crls {
+						crl.X509Crl.NextUpdate = past
+					}
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			resource.TestStep{
+				Config: config,
+				Check: func(s *terraform.State) error {
+					for _, name := range resourceNames {
+						rs := s.RootModule().Resources[name]
+						if rs.Primary.ID == expiredIds[name] {
+							return fmt.Errorf("expired %s %s not replaced", name, rs.Primary.ID)
+						}
+						if rs.Primary.Attributes["expired"] != "false" {
+							return fmt.Errorf("%s is expired", name)
+						}
+					}
+
+					m.Lock()
+					defer m.Unlock()
+					if len(m.certificates) != 2 || len(m.crls) != 1 {
+						return fmt.Errorf("expired objects left on the edge: %d certificates, %d CRLs",
+							len(m.certificates), len(m.crls))
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func testAccCheckEdgeCertificateDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		var getUri string
+		switch rs.Type {
+		case "nsxv_edge_certificate":
+			getUri = fmt.Sprintf(TrustStoreCertificateUriFormat, client.MgrConfig.Uri,
+				rs.Primary.Attributes["certificate_id"])
+		case "nsxv_edge_ca_certificate":
+			getUri = fmt.Sprintf(TrustStoreCertificateUriFormat, client.MgrConfig.Uri,
+				rs.Primary.ID)
+		case "nsxv_edge_crl":
+			getUri = fmt.Sprintf(TrustStoreCrlUriFormat, client.MgrConfig.Uri, rs.Primary.ID)
+		default:
+			continue
+		}
+
+		if err := nsxGet(client, getUri, nil); !isNotFoundError(err) {
+			return fmt.Errorf("%s %s still exists", rs.Type, rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+func testAccPreCheckEdgeCertificate(t *testing.T) {
+
+	testAccPreCheck(t)
+
+	if edgeId == "" {
+		t.Fatal("NSX_EDGE_ID must be set for acceptance tests")
+	}
+}
@@ -0,0 +1,142 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+type x509Crl struct {
+	IssuerCn   string `xml:"issuerCn"`
+	NextUpdate int64  `xml:"nextUpdate"` // milliseconds since the epoch
+}
+
+type trustCrl struct {
+	XMLName     xml.Name `xml:"crl"`
+	ObjectId    string   `xml:"objectId"`
+	PemEncoding string   `xml:"pemEncoding"`
+	X509Crl     x509Crl  `xml:"x509Crl"`
+}
+
+func resourceNsxEdgeCrl() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceNsxEdgeCrlCreate,
+		Read:   resourceNsxEdgeCrlRead,
+		Delete: resourceNsxEdgeCrlDelete,
+
+		CustomizeDiff: resourceNsxEdgeCrlCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"edge_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"crl": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validatePemCrl,
+			},
+			"issuer_cn": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"next_update": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"expired": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceNsxEdgeCrlCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+	edgeId := d.Get("edge_id").(string)
+
+	postUri := fmt.Sprintf(TrustStoreCrlUriFormat, client.MgrConfig.Uri, edgeId)
+
+	_, body, err := nsxPost(client, postUri, &trustObject{
+		PemEncoding: d.Get("crl").(string),
+	})
+	if err != nil {
+		log.Printf("[ERROR] CRL import into Edge '%s' failed. %v", edgeId, err)
+		return err
+	}
+
+	crl := &trustCrl{}
+	if err := xml.Unmarshal(body, crl); err != nil {
+		return err
+	}
+
+	d.SetId(crl.ObjectId)
+	log.Printf("[INFO] CRL %s imported into Edge '%s'", crl.ObjectId, edgeId)
+
+	return resourceNsxEdgeCrlRead(d, meta)
+}
+
+func resourceNsxEdgeCrlRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	getUri := fmt.Sprintf(TrustStoreCrlUriFormat, client.MgrConfig.Uri, d.Id())
+
+	crl := &trustCrl{}
+	if err := nsxGet(client, getUri, crl); err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] CRL '%s' not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		log.Printf("[ERROR] Retriving CRL '%s' failed with error : '%v'", d.Id(), err)
+		return err
+	}
+
+	// A CRL past its next update is stale, a newer one has to be imported.
+	nextUpdate := crl.X509Crl.NextUpdate
+	expired := nextUpdate > 0 && time.Now().After(nsxTime(nextUpdate))
+	if expired {
+		log.Printf("[WARN] CRL '%s' expired on %s, it will be replaced",
+			d.Id(), formatNsxTime(nextUpdate))
+	}
+
+	d.Set("issuer_cn", crl.X509Crl.IssuerCn)
+	d.Set("next_update", formatNsxTime(nextUpdate))
+	d.Set("expired", expired)
+
+	return nil
+}
+
+func resourceNsxEdgeCrlCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	return forceNewWhenExpired(d)
+}
+
+func resourceNsxEdgeCrlDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	deleteUri := fmt.Sprintf(TrustStoreCrlUriFormat, client.MgrConfig.Uri, d.Id())
+	err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return nsxDelete(client, deleteUri)
+	})
+	if err != nil && !isNotFoundError(err) {
+		log.Printf("[ERROR] Deleting CRL '%s' failed with error : %v", d.Id(), err)
+		return err
+	}
+
+	log.Printf("[INFO] CRL deleted :%s", d.Id())
+	d.SetId("")
+	return nil
+}
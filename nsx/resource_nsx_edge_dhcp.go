@@ -1,11 +1,11 @@
 package nsx
 
 import (
-	"bytes"
 	"fmt"
 	"log"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/IBM-tfproviders/govnsx"
 	"github.com/IBM-tfproviders/govnsx/nsxresource"
@@ -23,12 +23,13 @@ type ipRange struct {
 }
 
 type subnet struct {
-	cidr        string
-	defaultGw   string
-	networkAddr string // network address eg: 10.10.10.0
-	netMask     string // netmask eg: 255.255.255.0
-	vnicAddr    string
-	ipRangeList []ipRange
+	cidr         string
+	defaultGw    string
+	networkAddr  string // network address eg: 10.10.10.0
+	netMask      string // netmask eg: 255.255.255.0
+	vnicAddr     string
+	secondaryIPs []string
+	ipRangeList  []ipRange
 }
 
 type pgCfg struct {
@@ -48,6 +49,23 @@ func resourceNsxEdgeDHCP() *schema.Resource {
 		Update: resourceNsxEdgeDHCPUpdate,
 		Delete: resourceNsxEdgeDHCPDelete,
 
+		CustomizeDiff: resourceNsxEdgeDHCPCustomizeDiff,
+
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceNsxEdgeDHCPResourceV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceNsxEdgeDHCPStateUpgradeV0,
+			},
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"edge_id": &schema.Schema{
 				Type:     schema.TypeString,
@@ -86,6 +104,103 @@ func resourceNsxEdgeDHCP() *schema.Resource {
 										Optional: true,
 										Elem:     &schema.Schema{Type: schema.TypeString},
 									},
+									"interface_ip": &schema.Schema{
+										Type:         schema.TypeString,
+										Optional:     true,
+										ValidateFunc: validateIP,
+									},
+									"secondary_ips": &schema.Schema{
+										Type:     schema.TypeList,
+										Optional: true,
+										Elem: &schema.Schema{
+											Type:         schema.TypeString,
+											ValidateFunc: validateIP,
+										},
+									},
+									"auto_allocate": &schema.Schema{
+										Type:     schema.TypeBool,
+										Optional: true,
+										Default:  false,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"allocated_address": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"logical_switch_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"cidr": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"default_gw": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"interface_ip": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"secondary_ips": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// resourceNsxEdgeDHCPResourceV0 is the schema before interface_ip and
+// auto_allocate, when the edge vNIC address was always taken from the IP pool.
+func resourceNsxEdgeDHCPResourceV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"edge_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"logical_switch": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				MaxItems: 10,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"subnet": &schema.Schema{
+							Type:     schema.TypeSet,
+							Required: true,
+							MinItems: 1,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"cidr": &schema.Schema{
+										Type:     schema.TypeString,
+										Required: true,
+									},
+									"default_gw": &schema.Schema{
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+									"ip_pool": &schema.Schema{
+										Type:     schema.TypeList,
+										Optional: true,
+										Elem:     &schema.Schema{Type: schema.TypeString},
+									},
 								},
 							},
 						},
@@ -96,6 +211,89 @@ func resourceNsxEdgeDHCP() *schema.Resource {
 	}
 }
 
+// resourceNsxEdgeDHCPStateUpgradeV0 sets auto_allocate on the subnets of
+// the state without an interface_ip, to keep allocating their edge vNIC
+// address from the IP pool.
+func resourceNsxEdgeDHCPStateUpgradeV0(rawState map[string]interface{},
+	meta interface{}) (map[string]interface{}, error) {
+
+	portgroups, _ := rawState["logical_switch"].([]interface{})
+	for _, pgRaw := range portgroups {
+		portgroup, ok := pgRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		subnets, _ := portgroup["subnet"].([]interface{})
+		for _, subnetRaw := range subnets {
+			subnetVal, ok := subnetRaw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			interfaceIP, _ := subnetVal["interface_ip"].(string)
+			autoAllocate, _ := subnetVal["auto_allocate"].(bool)
+			if interfaceIP == "" && !autoAllocate {
+				log.Printf("[INFO] Allocating the address of subnet %v from its IP pool",
+					subnetVal["cidr"])
+				subnetVal["auto_allocate"] = true
+			}
+		}
+	}
+
+	return rawState, nil
+}
+
+// resourceNsxEdgeDHCPCustomizeDiff validates the subnets at plan time, and
+// marks the allocated addresses as changing along with them.
+func resourceNsxEdgeDHCPCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+
+	keys := d.GetChangedKeysPrefix("logical_switch.")
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Every subnet added or changed has its cidr in the diff.
+	for _, k := range keys {
+		if !strings.HasSuffix(k, ".cidr") {
+			continue
+		}
+
+		subnetVal, ok := getDiffSubnet(d, strings.TrimSuffix(k, "cidr"))
+		if !ok {
+			continue
+		}
+		if _, err := parseSubnet(subnetVal); err != nil {
+			return fmt.Errorf("logical_switch: %s", err)
+		}
+	}
+
+	if d.Id() == "" {
+		return nil
+	}
+
+	return d.SetNewComputed("allocated_address")
+}
+
+// getDiffSubnet reads the subnet at the given diff address field by field,
+// reading the whole logical_switch set loses the ip_pool of new subnets.
+// It returns false for removed subnets and for subnets with values only
+// known at apply, those are validated then.
+func getDiffSubnet(d *schema.ResourceDiff, prefix string) (map[string]interface{}, bool) {
+
+	for _, k := range d.GetChangedKeysPrefix(prefix) {
+		if !d.NewValueKnown(k) {
+			return nil, false
+		}
+	}
+
+	subnetVal := make(map[string]interface{})
+	for _, field := range []string{"cidr", "default_gw", "ip_pool",
+		"interface_ip", "secondary_ips", "auto_allocate"} {
+		subnetVal[field] = d.Get(prefix + field)
+	}
+
+	return subnetVal, subnetVal["cidr"] != ""
+}
+
 func resourceNsxEdgeDHCPCreate(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[INFO] Creating NSX Edge DHCP")
@@ -111,36 +309,33 @@ func resourceNsxEdgeDHCPCreate(d *schema.ResourceData, meta interface{}) error {
 
 	client := meta.(*govnsx.Client)
 
-	// Get Edge details
-	edge := nsxresource.NewEdge(client)
-
-	var edgeCfg *nsxtypes.Edge
-	if edgeCfg, err = getEdge(edge, dhcp.edgeId); err != nil {
-		return err
-	}
+	edgeMutexKV.Lock(dhcp.edgeId)
+	defer edgeMutexKV.Unlock(dhcp.edgeId)
 
 	// Loop through all the vnics from 0-9 of edge config.
 	// If the portgroup matches, add new address group for the
 	// existing vnic. Else create a new vnic for the portgroup.
 	// If all the vnics are configured, return err
+	edgeCfg, err := modifyEdge(client, dhcp.edgeId, func(edgeCfg *edgeConfig) error {
 
-	for _, portgroup := range dhcp.portgroups {
+		for _, portgroup := range dhcp.portgroups {
 
-		if err := configureEdgeVnic(portgroup, edgeCfg); err != nil {
-			return err
+			if err := configureEdgeVnic(portgroup, edgeCfg); err != nil {
+				return err
+			}
 		}
-	}
 
-	// Deploy appliance to true
-	edgeCfg.Appliances.DeployAppliances = true
-
-	//update edge
-	if err = updateEdge(edge, edgeCfg); err != nil {
+		// Deploy appliance to true
+		edgeCfg.Appliances.DeployAppliances = true
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
-	// configure dhcp with the iprange and gw
-	ipPools := []nsxtypes.IPPool{}
+	// configure dhcp with the iprange and gw, keeping the pools already
+	// configured on the edge by other resources
+	ipPools := append([]nsxtypes.IPPool{}, edgeCfg.Features.Dhcp.IPPools...)
 	for _, portgroup := range dhcp.portgroups {
 
 		for _, subnetCfg := range portgroup.subnetList {
@@ -174,6 +369,12 @@ func resourceNsxEdgeDHCPCreate(d *schema.ResourceData, meta interface{}) error {
 	log.Printf("[INFO] Added DHCP configuration %#v to Edge '%s'",
 		dhcpConfigSpec, dhcp.edgeId)
 
+	// The appliance is deployed with the first vnic, wait for it to be up
+	if _, err := waitForEdgeReady(client, dhcp.edgeId, true,
+		d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
 	return resourceNsxEdgeDHCPRead(d, meta)
 }
 
@@ -198,9 +399,38 @@ func resourceNsxEdgeDHCPRead(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[INFO] The DHCP Configuration of Edge '%s': %v", edgeId, dhcpCfg)
 
+	dhcp, err := parseAndValidateResourceData(d)
+	if err != nil {
+		return fmt.Errorf("Unable to compute the addresses of Edge '%s' DHCP: %s", edgeId, err)
+	}
+
+	if err := d.Set("allocated_address", flattenAllocatedAddresses(dhcp)); err != nil {
+		return fmt.Errorf("Invalid allocated addresses to set: %s", err)
+	}
+
 	return nil
 }
 
+// flattenAllocatedAddresses exports the gateway and edge vNIC addresses of
+// every subnet, given or allocated from the IP pool.
+func flattenAllocatedAddresses(dhcp *dhcpCfg) []map[string]interface{} {
+
+	addresses := make([]map[string]interface{}, 0)
+	for _, portgroup := range dhcp.portgroups {
+		for _, subnetCfg := range portgroup.subnetList {
+			addresses = append(addresses, map[string]interface{}{
+				"logical_switch_id": portgroup.portgroupName,
+				"cidr":              subnetCfg.cidr,
+				"default_gw":        subnetCfg.defaultGw,
+				"interface_ip":      subnetCfg.vnicAddr,
+				"secondary_ips":     subnetCfg.secondaryIPs,
+			})
+		}
+	}
+
+	return addresses
+}
+
 func resourceNsxEdgeDHCPUpdate(d *schema.ResourceData, meta interface{}) error {
 
 	log.Printf("[INFO] Updating NSX Edge DHCP")
@@ -209,12 +439,20 @@ func resourceNsxEdgeDHCPUpdate(d *schema.ResourceData, meta interface{}) error {
 
 	client := meta.(*govnsx.Client)
 
-	// Get Edge details.
-	edge := nsxresource.NewEdge(client)
+	// Parsed before the change sets below are trimmed, which trims the
+	// subnets read from the resource data too.
+	dhcp, err := parseAndValidateResourceData(d)
+	if err != nil {
+		log.Printf("[ERROR] Configuration validation failed.")
+		return err
+	}
 
-	var edgeCfg *nsxtypes.Edge
-	var err error
-	if edgeCfg, err = getEdge(edge, edgeId); err != nil {
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
+
+	// Get Edge details, for the ids of its DHCP IP pools.
+	var edgeCfg *edgeConfig
+	if edgeCfg, err = getEdge(client, edgeId); err != nil {
 		return err
 	}
 
@@ -222,6 +460,10 @@ func resourceNsxEdgeDHCPUpdate(d *schema.ResourceData, meta interface{}) error {
 
 	if d.HasChange("logical_switch") {
 
+		// The IP pool calls change the edge version, the vNic changes are
+		// applied to the edge read again once they are done.
+		vnicChanges := []func(edgeCfg *edgeConfig) error{}
+
 		oldPg, newPg := d.GetChange("logical_switch")
 		oldPgSet := oldPg.(*schema.Set)
 		newPgSet := newPg.(*schema.Set)
@@ -278,38 +520,45 @@ func resourceNsxEdgeDHCPUpdate(d *schema.ResourceData, meta interface{}) error {
 								if addedPg["id"] == removedPg["id"] &&
 									addedSubnet["cidr"] == removedSubnet["cidr"] {
 
-									var addedGw, removedGw string
-									if v, ok := addedSubnet["default_gw"]; ok {
-										addedGw = v.(string)
+									parseRemovedSubnet, err := parseSubnet(removedSubnet)
+									if err != nil {
+										return err
 									}
-									if v, ok := removedSubnet["default_gw"]; ok {
-										removedGw = v.(string)
-									}
-									var addedIPPoolRaw, removedIPPoolRaw interface{}
-									if v, ok := addedSubnet["ip_pool"]; ok {
-										addedIPPoolRaw = v
-									}
-									if v, ok := removedSubnet["ip_pool"]; ok {
-										removedIPPoolRaw = v
+									parseAddedSubnet, err := parseSubnet(addedSubnet)
+									if err != nil {
+										return err
 									}
 
-									// Modified gw
-									if (addedGw != removedGw) || (len(addedIPPoolRaw.([]interface{})) !=
-										len(removedIPPoolRaw.([]interface{}))) {
+									// Modified gw or ip pool
+									if parseAddedSubnet.defaultGw != parseRemovedSubnet.defaultGw ||
+										!equalIPRanges(parseAddedSubnet.ipRangeList,
+											parseRemovedSubnet.ipRangeList) {
 
 										// delete ip_pool and add new ip_pool
 										log.Printf("[DEBUG] Modified Gateway IP or the IP Pool of the Logical Switch '%s'", addedPg["id"])
 
-										parseRemovedSubnet, _ := parseSubnet(removedSubnet)
 										if err := deleteIPPool(parseRemovedSubnet, edgeDHCPIPPool, edgeCfg); err != nil {
 											return err
 										}
-										parseAddedSubnet, _ := parseSubnet(addedSubnet)
 										if err := addIPPool(parseAddedSubnet, edgeDHCPIPPool, edgeCfg); err != nil {
 											return err
 										}
 									}
 
+									// Modified vnic addresses
+									if parseAddedSubnet.vnicAddr != parseRemovedSubnet.vnicAddr ||
+										strings.Join(parseAddedSubnet.secondaryIPs, ",") !=
+											strings.Join(parseRemovedSubnet.secondaryIPs, ",") {
+
+										log.Printf("[DEBUG] Modified vNic addresses of the Logical Switch '%s'", addedPg["id"])
+
+										portgroupName := addedPg["id"].(string)
+										vnicChanges = append(vnicChanges, func(edgeCfg *edgeConfig) error {
+											updateEdgeVnicAddressGroup(portgroupName, parseAddedSubnet, edgeCfg)
+											return nil
+										})
+									}
+
 									// Only ip Pool Changes and the same has been taken care above.
 									// Hence, remove the addedSubnet and removedSubnet from the set
 									addedSubnetSet.Remove(addedSubnet)
@@ -329,62 +578,85 @@ func resourceNsxEdgeDHCPUpdate(d *schema.ResourceData, meta interface{}) error {
 			}
 		}
 
-		updateEdgeNeeded := false
 		log.Printf("[DEBUG] Added Logical Switches after update: %#v\n", addedPgs)
 		log.Printf("[DEBUG] Removed Logical Switches after update: %#v\n", removedPgs)
 
 		if len(removedPgs.List()) > 0 {
 
-			updateEdgeNeeded = true
 			removedPortgroups, err := parsePortgroups(removedPgs)
 			if err != nil {
 				log.Printf("[ERROR] Removed logical switches Configuration validation failed.")
 				return err
 			}
 
-			// delete vnic, delete ip pool
-			if err := reconfigureEdgeVnicAndIPPool(removedPgs, removedPortgroups, edgeDHCPIPPool, edgeCfg); err != nil {
-				return err
+			// delete ip pool, then vnic
+			for _, portgroup := range removedPortgroups {
+				if err := deleteIPPools(portgroup, edgeDHCPIPPool, edgeCfg); err != nil {
+					return err
+				}
 			}
+			vnicChanges = append(vnicChanges, func(edgeCfg *edgeConfig) error {
+				for _, portgroup := range removedPortgroups {
+					reconfigureEdgeVnic(portgroup, edgeCfg)
+				}
+				return nil
+			})
 		}
 
+		var addedPortgroups []pgCfg
 		if len(addedPgs.List()) > 0 {
 
-			updateEdgeNeeded = true
-			addedPortgroups, err := parsePortgroups(addedPgs)
-
+			addedPortgroups, err = parsePortgroups(addedPgs)
 			if err != nil {
 				log.Printf("[ERROR] Added logical switches Configuration validation failed.")
 				return err
 			}
 
-			// add vnic, add ip pool
-			if err := configureEdgeVnicAndIPPool(addedPgs, addedPortgroups, edgeDHCPIPPool, edgeCfg); err != nil {
-				return err
-			}
+			// add vnic, then ip pool below
+			vnicChanges = append(vnicChanges, func(edgeCfg *edgeConfig) error {
+				for _, portgroup := range addedPortgroups {
+					if err := configureEdgeVnic(portgroup, edgeCfg); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
 		}
 
-		if updateEdgeNeeded == true {
-
-			// get DHCP configs and set it to Features
-			edgeDHCP := nsxresource.NewEdgeDHCP(client)
-			edgeDHCPConfig, err := edgeDHCP.Get(edgeId)
+		if len(vnicChanges) > 0 {
 
+			//update edge
+			edgeCfg, err = modifyEdge(client, edgeId, func(edgeCfg *edgeConfig) error {
+				for _, change := range vnicChanges {
+					if err := change(edgeCfg); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
 			if err != nil {
-				log.Printf("[ERROR] Retriving Edge DHCP configuration '%s' failed with error : '%v'", edgeId, err)
 				return err
 			}
 
-			log.Printf("[DEBUG] Edge '%s'  : '%s'", edgeCfg, edgeId)
+			for _, portgroup := range addedPortgroups {
+				for _, subnet := range portgroup.subnetList {
+					if err := addIPPool(subnet, edgeDHCPIPPool, edgeCfg); err != nil {
+						return err
+					}
+				}
+			}
 
-			edgeCfg.Features.Dhcp = *edgeDHCPConfig
-			//update edge
-			if err = updateEdge(edge, edgeCfg); err != nil {
+			if _, err := waitForEdgeReady(client, edgeId, edgeCfg.Appliances.DeployAppliances,
+				d.Timeout(schema.TimeoutUpdate)); err != nil {
 				return err
 			}
 		}
 	}
 
+	if err := d.Set("allocated_address", flattenAllocatedAddresses(dhcp)); err != nil {
+		return fmt.Errorf("Invalid allocated addresses to set: %s", err)
+	}
+
 	return nil
 }
 
@@ -396,43 +668,41 @@ func resourceNsxEdgeDHCPDelete(d *schema.ResourceData, meta interface{}) error {
 
 	edgeId := d.Get("edge_id").(string)
 
-	//remove the vnic details of edge as well
-	edge := nsxresource.NewEdge(client)
-	var err error
-	var edgeCfg *nsxtypes.Edge
-	if edgeCfg, err = getEdge(edge, edgeId); err != nil {
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
+
+	// Get Edge details, for the ids of its DHCP IP pools.
+	edgeCfg, err := getEdge(client, edgeId)
+	if err != nil {
 		return err
 	}
 
-	dhcp, _ := parseAndValidateResourceData(d)
+	dhcp, err := parseAndValidateResourceData(d)
+	if err != nil {
+		return err
+	}
 
 	edgeDHCPIPPool := nsxresource.NewEdgeDHCPIPPool(client)
 
 	for _, portgroup := range dhcp.portgroups {
-
-		reconfigureEdgeVnic(portgroup, edgeCfg)
-
-		for _, subnet := range portgroup.subnetList {
-
-			if err := deleteIPPool(subnet, edgeDHCPIPPool, edgeCfg); err != nil {
-				return err
-			}
+		if err := deleteIPPools(portgroup, edgeDHCPIPPool, edgeCfg); err != nil {
+			return err
 		}
 	}
 
-	// get DHCP configs and set it to Features
-	edgeDHCP := nsxresource.NewEdgeDHCP(client)
-	edgeDHCPConfig, err := edgeDHCP.Get(edgeId)
-
+	//remove the vnic details of edge as well
+	edgeCfg, err = modifyEdge(client, edgeId, func(edgeCfg *edgeConfig) error {
+		for _, portgroup := range dhcp.portgroups {
+			reconfigureEdgeVnic(portgroup, edgeCfg)
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("[ERROR] Retriving Edge DHCP configuration '%s' failed with error : '%v'", edgeId, err)
 		return err
 	}
 
-	edgeCfg.Features.Dhcp = *edgeDHCPConfig
-
-	//update edge
-	if err = updateEdge(edge, edgeCfg); err != nil {
+	if _, err := waitForEdgeReady(client, edgeId, edgeCfg.Appliances.DeployAppliances,
+		d.Timeout(schema.TimeoutDelete)); err != nil {
 		return err
 	}
 
@@ -515,16 +785,23 @@ func parseSubnets(vL interface{}) ([]subnet, error) {
 func parseSubnet(subnetVal map[string]interface{}) (subnet, error) {
 
 	newSubnet := subnet{}
-	var err error
 
 	cidr := subnetVal["cidr"].(string)
 	newSubnet.cidr = cidr
 
-	_, ipNet, _ := net.ParseCIDR(cidr)
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return subnet{}, fmt.Errorf("CIDR '%s' is not valid.", cidr)
+	}
+
+	// The edge DHCP service only serves IPv4 subnets.
+	if !isIPv4(ipNet.IP) {
+		return subnet{}, fmt.Errorf("CIDR '%s' is not an IPv4 subnet, "+
+			"DHCP is only supported for IPv4.", cidr)
+	}
 
 	newSubnet.networkAddr = ipNet.IP.String()
-	newSubnet.netMask = fmt.Sprint(
-		ipNet.Mask[0], ".", ipNet.Mask[1], ".", ipNet.Mask[2], ".", ipNet.Mask[3])
+	newSubnet.netMask = net.IP(ipNet.Mask).String()
 
 	gwPresent := false
 	var defaultGw net.IP
@@ -544,6 +821,61 @@ func parseSubnet(subnetVal map[string]interface{}) (subnet, error) {
 		}
 	}
 
+	autoAllocate := false
+	if v, ok := subnetVal["auto_allocate"]; ok {
+		autoAllocate = v.(bool)
+	}
+
+	// The addresses of the edge vNIC, they can't be leased.
+	vnicIPs := []net.IP{}
+
+	var interfaceIP net.IP
+	if v, ok := subnetVal["interface_ip"]; ok && v != "" {
+
+		if autoAllocate {
+			return subnet{}, fmt.Errorf("Subnet '%s' sets both interface_ip and "+
+				"auto_allocate, only one of them is allowed.", cidr)
+		}
+
+		interfaceIP = net.ParseIP(v.(string))
+		if interfaceIP == nil || !ipNet.Contains(interfaceIP) {
+			return subnet{}, fmt.Errorf("Interface IP '%s' does not belong to CIDR %s.",
+				v, cidr)
+		}
+		vnicIPs = append(vnicIPs, interfaceIP)
+
+	} else if !autoAllocate {
+		return subnet{}, fmt.Errorf("Subnet '%s' needs an interface_ip for the edge "+
+			"vNic, or auto_allocate to take it from the IP pool.", cidr)
+	}
+
+	if raw, ok := subnetVal["secondary_ips"]; ok {
+		for _, v := range raw.([]interface{}) {
+
+			secondaryIP := net.ParseIP(v.(string))
+			if secondaryIP == nil || !ipNet.Contains(secondaryIP) {
+				return subnet{}, fmt.Errorf("Secondary IP '%s' does not belong to CIDR %s.",
+					v, cidr)
+			}
+			for _, vnicIP := range vnicIPs {
+				if vnicIP.Equal(secondaryIP) {
+					return subnet{}, fmt.Errorf("Secondary IP '%s' is already an "+
+						"address of the vNic in subnet %s.", v, cidr)
+				}
+			}
+
+			vnicIPs = append(vnicIPs, secondaryIP)
+			newSubnet.secondaryIPs = append(newSubnet.secondaryIPs, secondaryIP.String())
+		}
+	}
+
+	for _, vnicIP := range vnicIPs {
+		if gwPresent && vnicIP.Equal(defaultGw) {
+			return subnet{}, fmt.Errorf("Default Gateway '%s' is an address of the "+
+				"vNic in subnet %s.", defaultGw, cidr)
+		}
+	}
+
 	if raw, ok := subnetVal["ip_pool"]; ok && len(raw.([]interface{})) > 0 {
 
 		ipRangeCfgs := []ipRange{}
@@ -573,12 +905,19 @@ func parseSubnet(subnetVal map[string]interface{}) (subnet, error) {
 
 			if gwPresent {
 				// check default gw is not part of ip range
-				if bytes.Compare(defaultGw, start) >= 0 && bytes.Compare(defaultGw, end) <= 0 {
+				if compareIP(defaultGw, start) >= 0 && compareIP(defaultGw, end) <= 0 {
 					return newSubnet, fmt.Errorf("Default Gateway '%s' is part of IP Range %s.",
 						defaultGw, rangeValue)
 				}
 			}
 
+			for _, vnicIP := range vnicIPs {
+				if checkIPInRange(newIPRange, vnicIP) {
+					return newSubnet, fmt.Errorf("vNic address '%s' is part of IP Range %s.",
+						vnicIP, rangeValue)
+				}
+			}
+
 			ipRangeCfgs = append(ipRangeCfgs, newIPRange)
 		}
 
@@ -599,65 +938,68 @@ func parseSubnet(subnetVal map[string]interface{}) (subnet, error) {
 
 		newSubnet.ipRangeList = append(newSubnet.ipRangeList, rangeVal)
 
-		// remove the gateway address from the range
+		// remove the gateway and vnic addresses from the range
 		if gwPresent {
 			newSubnet.ipRangeList = removeGwAddrFromIPRange(rangeVal, defaultGw)
 		}
+		for _, vnicIP := range vnicIPs {
+			newSubnet.ipRangeList = removeAddrFromIPRanges(newSubnet.ipRangeList, vnicIP)
+		}
 	}
 
-	if !gwPresent {
+	tooSmall := fmt.Errorf("Subnet '%s' is too small, it needs addresses for the "+
+		"default gateway, the edge vNIC and at least one DHCP lease.", cidr)
 
-		// compute gw from ip range
-		newSubnet.defaultGw = newSubnet.ipRangeList[0].start.String()
+	if !autoAllocate {
 
-		// move start ip to 1 ahead and assign it to ipRange
-		newSubnet.ipRangeList[0].start = intToIP(ipToInt(newSubnet.ipRangeList[0].start) + 1)
-	}
+		newSubnet.vnicAddr = interfaceIP.String()
 
-	newSubnet.vnicAddr = newSubnet.ipRangeList[0].start.String()
-	// move start ip to 1 ahead and assign it to ipRange
-	newSubnet.ipRangeList[0].start = intToIP(ipToInt(newSubnet.ipRangeList[0].start) + 1)
+		// the edge vnic is the gateway of the DHCP clients by default
+		if !gwPresent {
+			newSubnet.defaultGw = newSubnet.vnicAddr
+		}
 
-	return newSubnet, nil
-}
+		if len(newSubnet.ipRangeList) == 0 {
+			return newSubnet, tooSmall
+		}
+		return newSubnet, nil
+	}
 
-func getEdge(edge *nsxresource.Edge, edgeId string) (*nsxtypes.Edge, error) {
+	if !gwPresent {
 
-	// Get Edge details
-	edgeCfg, err := edge.Get(edgeId)
+		// compute gw from ip range
+		gw, ipRangeList, err := takeFirstIP(newSubnet.ipRangeList)
+		if err != nil {
+			return newSubnet, tooSmall
+		}
+		newSubnet.defaultGw = gw.String()
+		newSubnet.ipRangeList = ipRangeList
+	}
 
-	if err != nil {
-		log.Printf("[ERROR] Retriving Edge '%s' failed with error : '%v'", edgeId, err)
-		return nil, err
+	vnicAddr, ipRangeList, err := takeFirstIP(newSubnet.ipRangeList)
+	if err != nil || len(ipRangeList) == 0 {
+		return newSubnet, tooSmall
 	}
+	newSubnet.vnicAddr = vnicAddr.String()
+	newSubnet.ipRangeList = ipRangeList
 
-	log.Printf("[DEBUG] Edge details of '%s': '%v'", edgeId, edgeCfg)
-	return edgeCfg, nil
+	return newSubnet, nil
 }
 
-func updateEdge(edge *nsxresource.Edge, edgeCfg *nsxtypes.Edge) error {
+func equalIPRanges(a, b []ipRange) bool {
 
-	edgeUpdateSpec := &nsxtypes.EdgeInstallSpec{
-		Tenant:     edgeCfg.Tenant,
-		Appliances: edgeCfg.Appliances,
-		Vnics:      edgeCfg.Vnics,
-		Features:   edgeCfg.Features,
+	if len(a) != len(b) {
+		return false
 	}
-
-	err := edge.Put(edgeUpdateSpec, edgeCfg.Id)
-
-	if err != nil {
-		log.Printf("[ERROR] Updating Edge '%s' for DHCP configuration failed with error : '%v'",
-			edgeCfg.Id, err)
-		return err
+	for i := range a {
+		if getIPRangeString(a[i]) != getIPRangeString(b[i]) {
+			return false
+		}
 	}
-
-	log.Printf("[INFO] Updated Edge '%s' for DHCP configuration", edgeCfg.Id)
-
-	return nil
+	return true
 }
 
-func configureEdgeVnic(portgroup pgCfg, edgeCfg *nsxtypes.Edge) error {
+func configureEdgeVnic(portgroup pgCfg, edgeCfg *edgeConfig) error {
 
 	pgFound := false
 	pgConfigDone := false
@@ -680,9 +1022,7 @@ func configureEdgeVnic(portgroup pgCfg, edgeCfg *nsxtypes.Edge) error {
 				}
 
 				if !addrGroupFound {
-					addrGroup := nsxtypes.AddressGroup{}
-					addrGroup.PrimaryAddress = subnetCfg.vnicAddr
-					addrGroup.SubnetMask = subnetCfg.netMask
+					addrGroup := newSubnetAddressGroup(subnetCfg)
 
 					edgeCfg.Vnics[i].AddressGroups = append(
 						edgeCfg.Vnics[i].AddressGroups, addrGroup)
@@ -706,9 +1046,7 @@ func configureEdgeVnic(portgroup pgCfg, edgeCfg *nsxtypes.Edge) error {
 
 				for _, subnetCfg := range portgroup.subnetList {
 
-					addrGroup := nsxtypes.AddressGroup{}
-					addrGroup.PrimaryAddress = subnetCfg.vnicAddr
-					addrGroup.SubnetMask = subnetCfg.netMask
+					addrGroup := newSubnetAddressGroup(subnetCfg)
 
 					edgeCfg.Vnics[i].AddressGroups = append(
 						edgeCfg.Vnics[i].AddressGroups, addrGroup)
@@ -727,7 +1065,42 @@ func configureEdgeVnic(portgroup pgCfg, edgeCfg *nsxtypes.Edge) error {
 	return nil
 }
 
-func reconfigureEdgeVnic(portgroup pgCfg, edgeCfg *nsxtypes.Edge) {
+func newSubnetAddressGroup(subnetCfg subnet) addressGroup {
+
+	return addressGroup{
+		PrimaryAddress:     subnetCfg.vnicAddr,
+		SubnetMask:         subnetCfg.netMask,
+		SecondaryAddresses: subnetCfg.secondaryIPs,
+	}
+}
+
+// updateEdgeVnicAddressGroup replaces the address group of the subnet on the
+// vnic of the portgroup.
+func updateEdgeVnicAddressGroup(portgroupName string, subnetCfg subnet, edgeCfg *edgeConfig) {
+
+	for i, vnic := range edgeCfg.Vnics {
+
+		if vnic.PortgroupId != portgroupName {
+			continue
+		}
+
+		for j, addrGroupCfg := range vnic.AddressGroups {
+
+			if isIPInCIDR(subnetCfg.cidr, addrGroupCfg.PrimaryAddress) {
+
+				edgeCfg.Vnics[i].AddressGroups[j] = newSubnetAddressGroup(subnetCfg)
+				log.Printf("[DEBUG] Updated the Address Group '%#v' of the Vnic '%d' of the Edge %s.",
+					edgeCfg.Vnics[i].AddressGroups[j], i, edgeCfg.Id)
+				return
+			}
+		}
+	}
+
+	log.Printf("[INFO] No Address Group of subnet '%s' found on the logical switch '%s' of the Edge '%s'",
+		subnetCfg.cidr, portgroupName, edgeCfg.Id)
+}
+
+func reconfigureEdgeVnic(portgroup pgCfg, edgeCfg *edgeConfig) {
 
 	pgFound := false
 
@@ -757,7 +1130,7 @@ func reconfigureEdgeVnic(portgroup pgCfg, edgeCfg *nsxtypes.Edge) {
 
 				edgeCfg.Vnics[i].PortgroupId = ""
 				edgeCfg.Vnics[i].IsConnected = false
-				edgeCfg.Vnics[i].AddressGroups = []nsxtypes.AddressGroup{}
+				edgeCfg.Vnics[i].AddressGroups = []addressGroup{}
 			}
 			break
 		}
@@ -781,66 +1154,23 @@ func reconfigureEdgeVnic(portgroup pgCfg, edgeCfg *nsxtypes.Edge) {
 
 	// Not found any configured Vnic,
 	if !pgFound {
-		log.Printf("[INFO] No vNic is configured for the logical switch '%s' to remove from the Edge '%s'", edgeCfg.Id)
-	}
-}
-
-func configureEdgeVnicAndIPPool(addedPgs *schema.Set, portgroups []pgCfg, edgeDHCPIPPool *nsxresource.EdgeDHCPIPPool,
-	edgeCfg *nsxtypes.Edge) error {
-
-	for _, addedPgRaw := range addedPgs.List() {
-		addedPg, _ := addedPgRaw.(map[string]interface{})
-
-		for _, portgroup := range portgroups {
-
-			if portgroup.portgroupName == addedPg["id"].(string) {
-
-				if err := configureEdgeVnic(portgroup, edgeCfg); err != nil {
-					return err
-				}
-
-				// add ip_pool
-				for _, subnet := range portgroup.subnetList {
-
-					if err := addIPPool(subnet, edgeDHCPIPPool, edgeCfg); err != nil {
-						return err
-					}
-				}
-				break
-			}
-		}
+		log.Printf("[INFO] No vNic is configured for the logical switch '%s' to remove from the Edge '%s'", portgroup.portgroupName, edgeCfg.Id)
 	}
-	return nil
 }
 
-func reconfigureEdgeVnicAndIPPool(removedPgs *schema.Set, portgroups []pgCfg, edgeDHCPIPPool *nsxresource.EdgeDHCPIPPool,
-	edgeCfg *nsxtypes.Edge) error {
-
-	for _, removedPgRaw := range removedPgs.List() {
-		removedPg, _ := removedPgRaw.(map[string]interface{})
-
-		for _, portgroup := range portgroups {
-
-			if portgroup.portgroupName == removedPg["id"].(string) {
-
-				reconfigureEdgeVnic(portgroup, edgeCfg)
+// deleteIPPools deletes the IP pools of all the subnets of a portgroup.
+func deleteIPPools(portgroup pgCfg, edgeDHCPIPPool *nsxresource.EdgeDHCPIPPool,
+	edgeCfg *edgeConfig) error {
 
-				// delete ip_pool
-
-				for _, subnet := range portgroup.subnetList {
-
-					if err := deleteIPPool(subnet, edgeDHCPIPPool, edgeCfg); err != nil {
-						return err
-					}
-				}
-				break
-			}
+	for _, subnet := range portgroup.subnetList {
+		if err := deleteIPPool(subnet, edgeDHCPIPPool, edgeCfg); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func addIPPool(subnet subnet, edgeDHCPIPPool *nsxresource.EdgeDHCPIPPool, edgeCfg *nsxtypes.Edge) error {
+func addIPPool(subnet subnet, edgeDHCPIPPool *nsxresource.EdgeDHCPIPPool, edgeCfg *edgeConfig) error {
 
 	for _, ipRangeVal := range subnet.ipRangeList {
 
@@ -865,7 +1195,7 @@ func addIPPool(subnet subnet, edgeDHCPIPPool *nsxresource.EdgeDHCPIPPool, edgeCf
 	return nil
 }
 
-func deleteIPPool(subnet subnet, edgeDHCPIPPool *nsxresource.EdgeDHCPIPPool, edgeCfg *nsxtypes.Edge) error {
+func deleteIPPool(subnet subnet, edgeDHCPIPPool *nsxresource.EdgeDHCPIPPool, edgeCfg *edgeConfig) error {
 
 	for _, value := range subnet.ipRangeList {
 
@@ -5,19 +5,21 @@ import (
 	"github.com/IBM-tfproviders/govnsx"
 	"github.com/IBM-tfproviders/govnsx/nsxresource"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 	"log"
 	"net"
 	"os"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	//"github.com/IBM-tfproviders/govnsx/nsxtypes"
 )
 
 var (
-	lsId   = os.Getenv("NSX_LOGICAL_SWITCH_ID")
-	edgeId = os.Getenv("NSX_EDGE_ID")
+	lsId   = testAccEnvOrMock("NSX_LOGICAL_SWITCH_ID", mockLogicalSwitchId)
+	edgeId = testAccEnvOrMock("NSX_EDGE_ID", mockEdgeId)
 )
 
 const (
@@ -34,10 +36,11 @@ resource "nsxv_edge_dhcp" "%s" {
     edge_id = "%s"
 
     logical_switch {
-        id = "%s",
+        id = "%s"
 
         subnet {
             cidr = "%s"
+            auto_allocate = true
         }
     }
 }
@@ -53,9 +56,67 @@ resource "nsxv_edge_dhcp" "%s" {
             cidr = "%s"
             default_gw = "%s"
             ip_pool = ["%s"]
+            auto_allocate = true
+        }
+    }
+}
+`
+
+	testAccCheckEdgeDhcpConf_interfaceIP = `
+resource "nsxv_edge_dhcp" "%s" {
+    edge_id = "%s"
+
+    logical_switch {
+        id = "%s"
+
+        subnet {
+            cidr = "%s"
+            interface_ip = "%s"
+            secondary_ips = ["%s"]
+            ip_pool = ["%s"]
+        }
+    }
+}
+`
+
+	testAccCheckEdgeDhcpConf_newSwitch = `
+resource "nsxv_logical_switch" "%s" {
+    name = "tf-acc-dhcp-ls"
+    scope_id = "%s"
+    tenant_id = "tf-acc"
+}
+
+resource "nsxv_edge_dhcp" "%s" {
+    edge_id = "%s"
+
+    logical_switch {
+        id = "${nsxv_logical_switch.%s.id}"
+
+        subnet {
+            cidr = "%s"
+            %s
+        }
+    }
+}
+`
+
+	testAccCheckEdgeDhcpConf_allocatedRef = `
+resource "nsxv_edge_dhcp" "%s" {
+    edge_id = "%s"
+
+    logical_switch {
+        id = "%s"
+
+        subnet {
+            cidr = "%s"
+            interface_ip = "%s"
         }
     }
 }
+
+resource "nsxv_mac_set" "%s" {
+    name = "tf-acc-${nsxv_edge_dhcp.%s.allocated_address.0.interface_ip}"
+}
 `
 
 	testAccCheckEdgeDhcpConf_2subnets = `
@@ -69,12 +130,14 @@ resource "nsxv_edge_dhcp" "%s" {
             cidr = "%s"
             default_gw = "%s"
             ip_pool = ["%s"]
+            auto_allocate = true
         }
 
         subnet {
             cidr = "%s"
             default_gw = "%s"
             ip_pool = ["%s"]
+            auto_allocate = true
         }
     }
 }
@@ -150,30 +213,99 @@ func TestAccNsxEdgeDHCP_ValidateAndSortIPRange(t *testing.T) {
 	ipRange2 := ipRange{net.ParseIP("1.2.3.50"), net.ParseIP("1.2.3.70")}
 	ipRange3 := ipRange{net.ParseIP("1.2.3.80"), net.ParseIP("1.2.3.100")}
 	ipRange4 := ipRange{net.ParseIP("1.2.3.30"), net.ParseIP("1.2.3.60")}
+	ipRange5 := ipRange{net.ParseIP("1.2.3.10"), net.ParseIP("1.2.3.20")}
+	ipRange6 := ipRange{net.ParseIP("1.2.3.41"), net.ParseIP("1.2.3.41")}
+	ipRange7 := ipRange{net.ParseIP("1.2.3.40"), net.ParseIP("1.2.3.45")}
+	ipRange8 := ipRange{net.ParseIP("1.2.3.99"), net.ParseIP("1.2.3.90")}
 	testData := []ipRangeSortData{
 		{[]ipRange{ipRange1, ipRange2, ipRange3}, []ipRange{ipRange1, ipRange2, ipRange3}, ""},
 		{[]ipRange{ipRange2, ipRange1, ipRange3}, []ipRange{ipRange1, ipRange2, ipRange3}, ""},
 		{[]ipRange{ipRange3, ipRange2, ipRange1}, []ipRange{ipRange1, ipRange2, ipRange3}, ""},
-		{[]ipRange{ipRange1, ipRange4}, []ipRange{}, "Overlapping IP Ranges"},
-		{[]ipRange{ipRange1, ipRange4, ipRange3}, []ipRange{}, "Overlapping IP Ranges"},
+		{[]ipRange{ipRange3, ipRange1, ipRange2}, []ipRange{ipRange1, ipRange2, ipRange3}, ""},
+		{[]ipRange{ipRange2, ipRange3, ipRange1}, []ipRange{ipRange1, ipRange2, ipRange3}, ""},
+		{[]ipRange{ipRange2, ipRange6, ipRange1}, []ipRange{ipRange1, ipRange6, ipRange2}, ""},
+		{[]ipRange{}, []ipRange{}, ""},
+		{[]ipRange{ipRange1, ipRange4}, nil, "Overlapping IP Ranges"},
+		{[]ipRange{ipRange1, ipRange4, ipRange3}, nil, "Overlapping IP Ranges"},
+		{[]ipRange{ipRange1, ipRange5}, nil, "Overlapping IP Ranges"},
+		{[]ipRange{ipRange5, ipRange1}, nil, "Overlapping IP Ranges"},
+		{[]ipRange{ipRange3, ipRange5, ipRange1}, nil, "Overlapping IP Ranges"},
+		{[]ipRange{ipRange7, ipRange1}, nil, "Overlapping IP Ranges"},
+		{[]ipRange{ipRange8}, nil, "is not valid"},
 	}
 
 	log.Printf("Sorting IP Ranges")
 	for _, data := range testData {
 
-		_, err := validateAndSortIPRange(data.v)
+		retVal, err := validateAndSortIPRange(data.v)
 
 		if data.expected == "" && err != nil {
 			t.Fatalf("ValidationFailed: attribute value '%v' is not VALID.", data.v)
-		} else if err != nil {
-			ok := strings.Contains(err.Error(), data.expected)
-			if !ok {
-				t.Fatalf("ValidationFailed: Expected ERROR '%v' is not found.", data.expected)
+		} else if data.expected != "" {
+			if err == nil || !strings.Contains(err.Error(), data.expected) {
+				t.Fatalf("ValidationFailed: Expected ERROR '%v' is not found, got '%v'.",
+					data.expected, err)
+			}
+			continue
+		}
+
+		if len(retVal) != len(data.expectedRetVal) {
+			t.Fatalf("Sorting %v returned %v", data.v, retVal)
+		}
+		for i := range retVal {
+			if getIPRangeString(retVal[i]) != getIPRangeString(data.expectedRetVal[i]) {
+				t.Fatalf("Sorting %v returned %v, expected %v", data.v, retVal,
+					data.expectedRetVal)
 			}
 		}
 	}
 }
 
+func FuzzValidateAndSortIPRange(f *testing.F) {
+
+	f.Add([]byte{4, 40, 50, 70, 80, 100})
+	f.Add([]byte{10, 20, 4, 40})
+	f.Add([]byte{50, 70, 4, 40, 41, 41})
+
+	// Each pair of bytes is a range in 1.2.3.0/24.
+	f.Fuzz(func(t *testing.T, b []byte) {
+		ranges := []ipRange{}
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] > b[i+1] {
+				continue
+			}
+			ranges = append(ranges, ipRange{net.IPv4(1, 2, 3, b[i]), net.IPv4(1, 2, 3, b[i+1])})
+		}
+
+		overlapping := false
+		for i := range ranges {
+			for j := i + 1; j < len(ranges); j++ {
+				if compareIP(ranges[i].start, ranges[j].end) <= 0 &&
+					compareIP(ranges[j].start, ranges[i].end) <= 0 {
+					overlapping = true
+				}
+			}
+		}
+
+		sorted, err := validateAndSortIPRange(ranges)
+		if overlapping != (err != nil) {
+			t.Fatalf("ranges %v: overlapping %t, got error '%v'", ranges, overlapping, err)
+		}
+		if err != nil {
+			return
+		}
+
+		if len(sorted) != len(ranges) {
+			t.Fatalf("ranges %v: sorted to %v", ranges, sorted)
+		}
+		for i := 1; i < len(sorted); i++ {
+			if compareIP(sorted[i-1].end, sorted[i].start) >= 0 {
+				t.Fatalf("ranges %v: not sorted in %v", ranges, sorted)
+			}
+		}
+	})
+}
+
 type ipAndCidrData struct {
 	v1       string
 	v2       string
@@ -204,6 +336,70 @@ type subnetData struct {
 	expectedErr    string
 }
 
+func TestAccNsxEdgeDHCP_StateUpgradeV0(t *testing.T) {
+
+	rawState := map[string]interface{}{
+		"id":      mockEdgeId,
+		"edge_id": mockEdgeId,
+		"logical_switch": []interface{}{
+			map[string]interface{}{
+				"id": mockLogicalSwitchId,
+				"subnet": []interface{}{
+					map[string]interface{}{
+						"cidr":       cidr1,
+						"default_gw": defaultGw1,
+						"ip_pool":    []interface{}{ipRange1},
+					},
+				},
+			},
+		},
+	}
+
+	upgraded, err := resourceNsxEdgeDHCPStateUpgradeV0(rawState, nil)
+	if err != nil {
+		t.Fatalf("State upgrade failed with error: %s", err)
+	}
+
+	portgroup := upgraded["logical_switch"].([]interface{})[0].(map[string]interface{})
+	subnetVal := portgroup["subnet"].([]interface{})[0].(map[string]interface{})
+	if subnetVal["auto_allocate"] != true {
+		t.Fatalf("auto_allocate not set by the state upgrade: %#v", subnetVal)
+	}
+
+	delete(upgraded, "id")
+	d := schema.TestResourceDataRaw(t, resourceNsxEdgeDHCP().Schema, upgraded)
+	if _, err := parseAndValidateResourceData(d); err != nil {
+		t.Fatalf("Upgraded state does not parse: %s", err)
+	}
+}
+
+func TestAccNsxEdgeDHCP_DeleteInvalidState(t *testing.T) {
+
+	_, client := newMockNsxClient(t)
+
+	d := schema.TestResourceDataRaw(t, resourceNsxEdgeDHCP().Schema,
+		map[string]interface{}{
+			"edge_id": mockEdgeId,
+			"logical_switch": []interface{}{
+				map[string]interface{}{
+					"id": mockLogicalSwitchId,
+					"subnet": []interface{}{
+						map[string]interface{}{
+							"cidr":       cidr1,
+							"default_gw": defaultGw1,
+							"ip_pool":    []interface{}{ipRange1},
+						},
+					},
+				},
+			},
+		})
+	d.SetId(mockEdgeId)
+
+	if err := resourceNsxEdgeDHCPDelete(d, client); err == nil {
+		t.Fatalf("Delete of a subnet without interface_ip or auto_allocate succeeded")
+	}
+}
+
 func TestAccNsxEdgeDHCP_ParseSubnet(t *testing.T) {
 
 	subnet1 := createSubnetTestData("1.2.3.0/24", "1.2.3.1", []string{"1.2.3.5-1.2.3.50"})
@@ -215,6 +411,18 @@ func TestAccNsxEdgeDHCP_ParseSubnet(t *testing.T) {
 	subnet7 := createSubnetTestData("1.2.3.0/24", "1.2.3.1", []string{"11.22.33.5-11.22.33.50"})
 	subnet8 := createSubnetTestData("1.2.3.0/24", "1.2.3.1", []string{"1.2.3.5-1.2.3.50",
 		"1.2.3.25-1.2.3.35"})
+	subnet9 := createSubnetTestData("1.2.3.0/29", "", []string{})
+	subnet10 := createSubnetTestData("1.2.3.0/30", "", []string{})
+	subnet11 := createSubnetTestData("1.2.3.0/30", "1.2.3.1", []string{})
+	subnet12 := createSubnetTestData("1.2.3.0/31", "", []string{})
+	subnet13 := createSubnetTestData("1.2.3.4/32", "", []string{})
+	subnet14 := createSubnetTestData("1.2.3.0/24", "", []string{"1.2.3.5-1.2.3.6"})
+	subnet15 := createSubnetTestData("1.2.3.0/24", "", []string{"1.2.3.8-1.2.3.9",
+		"1.2.3.5-1.2.3.6"})
+	subnet16 := createSubnetTestData("1.2.3.0/24", "1.2.3.1", []string{"1.2.3.5-1.2.3.6"})
+	subnet17 := createSubnetTestData("1.2.3.0/24", "", []string{"1.2.3.5-1.2.3.50",
+		"1.2.3.10-1.2.3.20"})
+	subnet18 := createSubnetTestData("1.2.3.0/29", "1.2.3.4", []string{})
 
 	expectedSubnet1 := subnet{defaultGw: "1.2.3.1", vnicAddr: "1.2.3.5", netMask: "255.255.255.0",
 		ipRangeList: []ipRange{ipRange{net.ParseIP("1.2.3.6"), net.ParseIP("1.2.3.50")}}}
@@ -225,6 +433,19 @@ func TestAccNsxEdgeDHCP_ParseSubnet(t *testing.T) {
 	expectedSubnet3 := subnet{defaultGw: "1.2.3.1", vnicAddr: "1.2.3.2", netMask: "255.255.255.0",
 		ipRangeList: []ipRange{ipRange{net.ParseIP("1.2.3.3"), net.ParseIP("1.2.3.254")}}}
 
+	expectedSubnet9 := subnet{defaultGw: "1.2.3.1", vnicAddr: "1.2.3.2", netMask: "255.255.255.248",
+		ipRangeList: []ipRange{ipRange{net.ParseIP("1.2.3.3"), net.ParseIP("1.2.3.6")}}}
+
+	expectedSubnet15 := subnet{defaultGw: "1.2.3.5", vnicAddr: "1.2.3.6", netMask: "255.255.255.0",
+		ipRangeList: []ipRange{ipRange{net.ParseIP("1.2.3.8"), net.ParseIP("1.2.3.9")}}}
+
+	expectedSubnet16 := subnet{defaultGw: "1.2.3.1", vnicAddr: "1.2.3.5", netMask: "255.255.255.0",
+		ipRangeList: []ipRange{ipRange{net.ParseIP("1.2.3.6"), net.ParseIP("1.2.3.6")}}}
+
+	expectedSubnet18 := subnet{defaultGw: "1.2.3.4", vnicAddr: "1.2.3.1", netMask: "255.255.255.248",
+		ipRangeList: []ipRange{ipRange{net.ParseIP("1.2.3.2"), net.ParseIP("1.2.3.3")},
+			ipRange{net.ParseIP("1.2.3.5"), net.ParseIP("1.2.3.6")}}}
+
 	testData := []subnetData{
 		{subnet1, expectedSubnet1, ""},
 		{subnet2, expectedSubnet2, ""},
@@ -234,6 +455,16 @@ func TestAccNsxEdgeDHCP_ParseSubnet(t *testing.T) {
 		{subnet6, subnet{}, "does not belong to CIDR"},
 		{subnet7, subnet{}, "does not belong to CIDR"},
 		{subnet8, subnet{}, "Overlapping IP Ranges"},
+		{subnet9, expectedSubnet9, ""},
+		{subnet10, subnet{}, "is too small"},
+		{subnet11, subnet{}, "is too small"},
+		{subnet12, subnet{}, "is too small"},
+		{subnet13, subnet{}, "is too small"},
+		{subnet14, subnet{}, "is too small"},
+		{subnet15, expectedSubnet15, ""},
+		{subnet16, expectedSubnet16, ""},
+		{subnet17, subnet{}, "Overlapping IP Ranges"},
+		{subnet18, expectedSubnet18, ""},
 	}
 
 	for _, data := range testData {
@@ -244,6 +475,8 @@ func TestAccNsxEdgeDHCP_ParseSubnet(t *testing.T) {
 
 		if data.expectedErr == "" && err != nil {
 			t.Fatalf("Parsing subnet failed with error %s:", err)
+		} else if data.expectedErr != "" && err == nil {
+			t.Fatalf("Parsing subnet %v did not fail with '%v'.", data.v, data.expectedErr)
 		} else if err != nil {
 			ok := strings.Contains(err.Error(), data.expectedErr)
 			if !ok {
@@ -257,6 +490,119 @@ func TestAccNsxEdgeDHCP_ParseSubnet(t *testing.T) {
 	}
 }
 
+func TestAccNsxEdgeDHCP_ParseSubnetInterfaceIP(t *testing.T) {
+
+	subnet1 := createInterfaceSubnetTestData("1.2.3.0/24", "", []string{}, "1.2.3.1", []string{})
+	subnet2 := createInterfaceSubnetTestData("1.2.3.0/24", "1.2.3.1", []string{"1.2.3.5-1.2.3.50"},
+		"1.2.3.2", []string{"1.2.3.3"})
+	subnet3 := createInterfaceSubnetTestData("1.2.3.0/24", "1.2.3.1", []string{},
+		"1.2.3.100", []string{"1.2.3.101"})
+	subnet4 := createInterfaceSubnetTestData("1.2.3.0/24", "", []string{"1.2.3.5-1.2.3.50"},
+		"1.2.3.10", []string{})
+	subnet5 := createInterfaceSubnetTestData("1.2.3.0/24", "", []string{"1.2.3.5-1.2.3.50"},
+		"1.2.3.2", []string{"1.2.3.20"})
+	subnet6 := createInterfaceSubnetTestData("1.2.3.0/24", "", []string{}, "1.2.3.1", []string{})
+	subnet6["auto_allocate"] = true
+	subnet7 := createInterfaceSubnetTestData("1.2.3.0/24", "", []string{}, "", []string{})
+	subnet8 := createInterfaceSubnetTestData("1.2.3.0/24", "", []string{}, "1.2.4.1", []string{})
+	subnet9 := createInterfaceSubnetTestData("1.2.3.0/24", "", []string{}, "1.2.3.1",
+		[]string{"1.2.4.2"})
+	subnet10 := createInterfaceSubnetTestData("1.2.3.0/24", "", []string{}, "1.2.3.1",
+		[]string{"1.2.3.1"})
+	subnet11 := createInterfaceSubnetTestData("1.2.3.0/24", "1.2.3.1", []string{}, "1.2.3.1",
+		[]string{})
+	subnet12 := createInterfaceSubnetTestData("1.2.3.0/30", "", []string{}, "1.2.3.1",
+		[]string{"1.2.3.2"})
+
+	expectedSubnet1 := subnet{defaultGw: "1.2.3.1", vnicAddr: "1.2.3.1", netMask: "255.255.255.0",
+		ipRangeList: []ipRange{ipRange{net.ParseIP("1.2.3.2"), net.ParseIP("1.2.3.254")}}}
+
+	expectedSubnet2 := subnet{defaultGw: "1.2.3.1", vnicAddr: "1.2.3.2", netMask: "255.255.255.0",
+		secondaryIPs: []string{"1.2.3.3"},
+		ipRangeList:  []ipRange{ipRange{net.ParseIP("1.2.3.5"), net.ParseIP("1.2.3.50")}}}
+
+	expectedSubnet3 := subnet{defaultGw: "1.2.3.1", vnicAddr: "1.2.3.100", netMask: "255.255.255.0",
+		secondaryIPs: []string{"1.2.3.101"},
+		ipRangeList: []ipRange{ipRange{net.ParseIP("1.2.3.2"), net.ParseIP("1.2.3.99")},
+			ipRange{net.ParseIP("1.2.3.102"), net.ParseIP("1.2.3.254")}}}
+
+	testData := []subnetData{
+		{subnet1, expectedSubnet1, ""},
+		{subnet2, expectedSubnet2, ""},
+		{subnet3, expectedSubnet3, ""},
+		{subnet4, subnet{}, "is part of IP Range"},
+		{subnet5, subnet{}, "is part of IP Range"},
+		{subnet6, subnet{}, "only one of them is allowed"},
+		{subnet7, subnet{}, "needs an interface_ip"},
+		{subnet8, subnet{}, "does not belong to CIDR"},
+		{subnet9, subnet{}, "does not belong to CIDR"},
+		{subnet10, subnet{}, "is already an address of the vNic"},
+		{subnet11, subnet{}, "is an address of the vNic"},
+		{subnet12, subnet{}, "is too small"},
+	}
+
+	for _, data := range testData {
+
+		retSubnet, err := parseSubnet(data.v)
+
+		if data.expectedErr == "" && err != nil {
+			t.Fatalf("Parsing subnet failed with error %s:", err)
+		} else if data.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), data.expectedErr) {
+				t.Fatalf("Parsing subnet %v: Expected ERROR '%v' is not found, got '%v'.",
+					data.v, data.expectedErr, err)
+			}
+			continue
+		}
+
+		if !validateRetSubnet(retSubnet, data.expectedSubnet) {
+			t.Fatalf("Parsing subnet failed : Expected value '%v' is not found in '%v'.",
+				data.expectedSubnet, retSubnet)
+		}
+	}
+}
+
+func FuzzParseSubnet(f *testing.F) {
+
+	f.Add("1.2.3.0/24", "", "")
+	f.Add("1.2.3.0/30", "1.2.3.1", "")
+	f.Add("1.2.3.0/24", "", "1.2.3.5-1.2.3.6")
+	f.Add("1.2.3.0/24", "1.2.3.7", "1.2.3.5-1.2.3.9")
+
+	f.Fuzz(func(t *testing.T, cidr string, gw string, pool string) {
+		subnetVal := createSubnetTestData(cidr, gw, []string{})
+		if pool != "" {
+			subnetVal["ip_pool"] = []interface{}{pool}
+		}
+
+		s, err := parseSubnet(subnetVal)
+		if err != nil {
+			return
+		}
+
+		if len(s.ipRangeList) == 0 {
+			t.Fatalf("%v: no DHCP lease left", subnetVal)
+		}
+		gwIP := net.ParseIP(s.defaultGw)
+		vnicIP := net.ParseIP(s.vnicAddr)
+		if gwIP.Equal(vnicIP) {
+			t.Fatalf("%v: gateway and vNIC share %s", subnetVal, s.vnicAddr)
+		}
+		for _, r := range s.ipRangeList {
+			if compareIP(r.start, r.end) > 0 {
+				t.Fatalf("%v: inverted range %s", subnetVal, getIPRangeString(r))
+			}
+			if !isIPInCIDR(cidr, r.start.String()) || !isIPInCIDR(cidr, r.end.String()) {
+				t.Fatalf("%v: range %s out of the CIDR", subnetVal, getIPRangeString(r))
+			}
+			if checkIPInRange(r, gwIP) || checkIPInRange(r, vnicIP) {
+				t.Fatalf("%v: range %s holds the gateway or the vNIC address",
+					subnetVal, getIPRangeString(r))
+			}
+		}
+	})
+}
+
 func TestAccNsxEdgeDHCP_Create(t *testing.T) {
 
 	dhcpName := "TFT_DEFAULT"
@@ -317,6 +663,175 @@ func TestAccNsxEdgeDHCP_UpdateAddSubnet(t *testing.T) {
 	})
 }
 
+func TestAccNsxEdgeDHCP_InterfaceIP(t *testing.T) {
+
+	dhcpName := "TFT_DEFAULT"
+	resourceName := "nsxv_edge_dhcp." + dhcpName
+
+	config := fmt.Sprintf(testAccCheckEdgeDhcpConf_interfaceIP, dhcpName, edgeId, lsId, cidr1,
+		"1.2.3.60", "1.2.3.61", ipRange1)
+	configUpdate := fmt.Sprintf(testAccCheckEdgeDhcpConf_interfaceIP, dhcpName, edgeId, lsId, cidr1,
+		"1.2.3.70", "1.2.3.71", ipRange1)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdgeDHCP(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDHCPDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						resourceName, "allocated_address.0.interface_ip", "1.2.3.60"),
+					resource.TestCheckResourceAttr(
+						resourceName, "allocated_address.0.default_gw", "1.2.3.60"),
+					resource.TestCheckResourceAttr(
+						resourceName, "allocated_address.0.secondary_ips.0", "1.2.3.61"),
+					testAccCheckEdgeVnicAddress(edgeId, "1.2.3.60", "1.2.3.61"),
+				),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						resourceName, "allocated_address.0.interface_ip", "1.2.3.70"),
+					testAccCheckEdgeVnicAddress(edgeId, "1.2.3.70", "1.2.3.71"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeDHCP_RequestXML(t *testing.T) {
+
+	if !isTestAccMock() {
+		t.Skip("Checking the requests requires the mock NSX Manager")
+	}
+	m := testAccMockNsxManager(t)
+
+	dhcpName := "TFT_DEFAULT"
+	config := fmt.Sprintf(testAccCheckEdgeDhcpConf_interfaceIP, dhcpName, edgeId, lsId, cidr1,
+		"1.2.3.60", "1.2.3.61", ipRange1)
+
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDHCPDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckMockRequestXML(m, "PUT", "/api/4.0/edges/"+edgeId,
+						map[string][]string{
+							"edge/vnics/vnic/portgroupId": {lsId},
+							"edge/vnics/vnic/addressGroups/addressGroup/primaryAddress": {
+								"1.2.3.60"},
+							"edge/vnics/vnic/addressGroups/addressGroup/subnetMask": {
+								"255.255.255.0"},
+							"edge/vnics/vnic/addressGroups/addressGroup/secondaryAddresses/ipAddress": {
+								"1.2.3.61"},
+						}),
+					testAccCheckMockRequestXML(m, "PUT", "/api/4.0/edges/"+edgeId+"/dhcp/config",
+						map[string][]string{
+							"dhcp/ipPools/ipPool/ipRange":        {ipRange1},
+							"dhcp/ipPools/ipPool/defaultGateway": {"1.2.3.60"},
+							"dhcp/ipPools/ipPool/subnetMask":     {"255.255.255.0"},
+						}),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeDHCP_PlanValidation(t *testing.T) {
+
+	dhcpName := "TFT_DEFAULT"
+	lsName := "TFT_LS"
+
+	newSwitchConfig := func(subnetArgs string) string {
+		return fmt.Sprintf(testAccCheckEdgeDhcpConf_newSwitch, lsName, vdnScopeId,
+			dhcpName, edgeId, lsName, cidr1, subnetArgs)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheckEdgeDHCP(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      newSwitchConfig(`default_gw = "1.2.3.1"`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("needs an interface_ip for the edge vNic"),
+			},
+			resource.TestStep{
+				Config:      newSwitchConfig(`interface_ip = "4.3.2.1"`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("does not belong to CIDR"),
+			},
+			// Values only known at apply are checked then.
+			resource.TestStep{
+				Config: newSwitchConfig(
+					`interface_ip = "${replace(nsxv_logical_switch.` + lsName + `.id, "/.*/", "1.2.3.60")}"`),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeDHCP_AllocatedAddressReference(t *testing.T) {
+
+	dhcpName := "TFT_DEFAULT"
+	macSetName := "TFT_MACSET"
+
+	config := fmt.Sprintf(testAccCheckEdgeDhcpConf_allocatedRef, dhcpName, edgeId, lsId,
+		cidr1, "1.2.3.60", macSetName, dhcpName)
+	configUpdate := fmt.Sprintf(testAccCheckEdgeDhcpConf_allocatedRef, dhcpName, edgeId, lsId,
+		cidr1, "1.2.3.70", macSetName, dhcpName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdgeDHCP(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDHCPDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.TestCheckResourceAttr(
+					"nsxv_mac_set."+macSetName, "name", "tf-acc-1.2.3.60"),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check: resource.TestCheckResourceAttr(
+					"nsxv_mac_set."+macSetName, "name", "tf-acc-1.2.3.70"),
+			},
+		},
+	})
+}
+
+func testAccCheckEdgeVnicAddress(edgeId string, primaryIP string, secondaryIP string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		client := testAccProvider.Meta().(*govnsx.Client)
+
+		edgeCfg, err := getEdge(client, edgeId)
+		if err != nil {
+			return err
+		}
+
+		for _, vnic := range edgeCfg.Vnics {
+			for _, addrGroup := range vnic.AddressGroups {
+				if addrGroup.PrimaryAddress == primaryIP {
+					if strings.Join(addrGroup.SecondaryAddresses, ",") != secondaryIP {
+						return fmt.Errorf("vNic address '%s' has secondary addresses %v",
+							primaryIP, addrGroup.SecondaryAddresses)
+					}
+					return nil
+				}
+			}
+		}
+
+		return fmt.Errorf("No vNic of Edge '%s' has the address '%s'", edgeId, primaryIP)
+	}
+}
+
 func testAccCheckEdgeDHCPDestroy(s *terraform.State) error {
 
 	client := testAccProvider.Meta().(*govnsx.Client)
@@ -361,6 +876,7 @@ func createSubnetTestData(cidr string, gwIP string, ipPool []string) map[string]
 	subnetItemsMap := make(map[string]interface{})
 	subnetItemsMap["cidr"] = cidr
 	subnetItemsMap["default_gw"] = gwIP
+	subnetItemsMap["auto_allocate"] = true
 
 	if len(ipPool) > 0 {
 		ipPoolInterface := make([]interface{}, len(ipPool))
@@ -376,6 +892,22 @@ func createSubnetTestData(cidr string, gwIP string, ipPool []string) map[string]
 	return subnetMap
 }
 
+func createInterfaceSubnetTestData(cidr string, gwIP string, ipPool []string,
+	interfaceIP string, secondaryIPs []string) map[string]interface{} {
+
+	subnetMap := createSubnetTestData(cidr, gwIP, ipPool)
+	subnetMap["auto_allocate"] = false
+	subnetMap["interface_ip"] = interfaceIP
+
+	secondaryIPsInterface := make([]interface{}, len(secondaryIPs))
+	for i, value := range secondaryIPs {
+		secondaryIPsInterface[i] = value
+	}
+	subnetMap["secondary_ips"] = secondaryIPsInterface
+
+	return subnetMap
+}
+
 func validateRetSubnet(retSubnet, expectedSubnet subnet) bool {
 
 	emptySubnet := subnet{}
@@ -389,20 +921,22 @@ func validateRetSubnet(retSubnet, expectedSubnet subnet) bool {
 
 	if retSubnet.defaultGw != expectedSubnet.defaultGw ||
 		retSubnet.vnicAddr != expectedSubnet.vnicAddr ||
-		retSubnet.netMask != expectedSubnet.netMask {
+		retSubnet.netMask != expectedSubnet.netMask ||
+		strings.Join(retSubnet.secondaryIPs, ",") != strings.Join(expectedSubnet.secondaryIPs, ",") {
 
 		return false
 	}
 
-	for _, retIPRange := range retSubnet.ipRangeList {
+	if len(retSubnet.ipRangeList) != len(expectedSubnet.ipRangeList) {
+		return false
+	}
 
-		for _, expectedIPRange := range expectedSubnet.ipRangeList {
+	for i, retIPRange := range retSubnet.ipRangeList {
 
-			if retIPRange.start.String() != expectedIPRange.start.String() &&
-				retIPRange.end.String() != expectedIPRange.end.String() {
-				log.Printf("in ip range")
-				return false
-			}
+		if getIPRangeString(retIPRange) != getIPRangeString(expectedSubnet.ipRangeList[i]) {
+			log.Printf("IP range %s is not the expected %s", getIPRangeString(retIPRange),
+				getIPRangeString(expectedSubnet.ipRangeList[i]))
+			return false
 		}
 	}
 
@@ -415,6 +949,10 @@ func testAccPreCheckEdgeDHCP(t *testing.T) {
 
 	testAccPreCheck(t)
 
+	if isTestAccMock() {
+		return
+	}
+
 	for _, env := range envList {
 		if v := os.Getenv(env); v == "" {
 			t.Fatal(env + " must be set for acceptance tests")
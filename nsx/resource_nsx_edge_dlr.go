@@ -3,11 +3,11 @@ package nsx
 import (
 	"fmt"
 	"github.com/IBM-tfproviders/govnsx"
-	"github.com/IBM-tfproviders/govnsx/nsxresource"
-	"github.com/IBM-tfproviders/govnsx/nsxtypes"
 	"github.com/hashicorp/terraform/helper/schema"
 	"log"
+	"net"
 	"strings"
+	"time"
 )
 
 const (
@@ -25,6 +25,8 @@ type ifCfg struct {
 	name              string
 	ip                string
 	mask              string
+	prefixLength      int
+	secondaryIPs      []string
 	logical_switch_id string
 }
 
@@ -40,6 +42,12 @@ func resourceNsxEdgeDLR() *schema.Resource {
 		Update: resourceNsxEdgeDLRInterfaceUpdate,
 		Delete: resourceNsxEdgeDLRInterfaceDelete,
 
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"edge_id": &schema.Schema{
 				Type:     schema.TypeString,
@@ -73,9 +81,20 @@ func resourceNsxEdgeDLR() *schema.Resource {
 						},
 						"mask": &schema.Schema{
 							Type:         schema.TypeString,
-							Required:     true,
-							ForceNew:     false,
-							ValidateFunc: validateIP,
+							Optional:     true,
+							ValidateFunc: validateNetmask,
+						},
+						"prefix_length": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+						"secondary_ips": &schema.Schema{
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type:         schema.TypeString,
+								ValidateFunc: validateIP,
+							},
 						},
 						"logical_switch_id": &schema.Schema{
 							Type:     schema.TypeString,
@@ -91,29 +110,10 @@ func resourceNsxEdgeDLR() *schema.Resource {
 
 func resourceNsxEdgeDLRInterfaceCreate(d *schema.ResourceData, meta interface{}) error {
 
-	var edgeType string
-	var err error
-	
-	if v, ok := d.GetOk("type"); ok {
-
-		edgeType = v.(string)
-	
-	} else {
-		edgeType, err = getEdgeType(d.Get("edge_id").(string), meta)
-
-		if err != nil {
-			log.Printf("[ERROR] Unable to read Edge type %s", err)
-			return err
-		}
+	if err := checkDLREdgeType(d, meta); err != nil {
+		return err
 	}
 
-	if edgeType != EdgeTypeDistributedRouter {
-		log.Printf("[ERROR] Edge type is not ", EdgeTypeDistributedRouter)
-		err := fmt.Errorf("[ERROR] Only Edge type %s is supported for this operation",
-			EdgeTypeDistributedRouter)
-			return err
-	}
-	
 	dlr, err := parseAndValidateDLRResourceData(d, meta)
 	if err != nil {
 		log.Printf("[ERROR] Configuration validation failed.")
@@ -123,93 +123,62 @@ func resourceNsxEdgeDLRInterfaceCreate(d *schema.ResourceData, meta interface{})
 	log.Printf("[INFO] Adding DLR Interface '%#v' to Edge '%s'", dlr, dlr.edgeId)
 
 	client := meta.(*govnsx.Client)
-	dlrInterfaces := nsxresource.NewEdgeDLRInterfaces(client)
 
 	edgeId := dlr.edgeId
-	ifaces := []nsxtypes.EdgeDLRInterface{}
+	ifaces := []dlrInterface{}
 
-	for _, ifcfg := range dlr.ifCfgList {
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
 
-		addrGroups := []nsxtypes.AddressGroup{nsxtypes.AddressGroup{
-			PrimaryAddress: ifcfg.ip,
-			SubnetMask:     ifcfg.mask}}
+	for _, ifcfg := range dlr.ifCfgList {
 
-		iface := nsxtypes.EdgeDLRInterface{
-			AddressGroups: addrGroups,
-			Name:          ifcfg.name,
-			ConnectedToId: ifcfg.logical_switch_id,
-			Type:          "internal",
-			IsConnected:   true}
+		iface, err := createDLRInterfaceSpec(ifcfg)
+		if err != nil {
+			return err
+		}
 
 		ifaces = append(ifaces, iface)
 	}
 
-	addInterfacesSpec := &nsxtypes.EdgeDLRAddInterfacesSpec{
-		EdgeDLRInterfaceList: ifaces,
-	}
-
-	_, err = dlrInterfaces.Post(addInterfacesSpec, edgeId)
-	if err != nil {
-		log.Printf("[ERROR] dlrInterfaces.Post () returned error : %v", err)
+	if err = addDLRInterfaces(client, edgeId, ifaces); err != nil {
 		return err
 	}
 
 	d.SetId(fmt.Sprintf(DLRResourceIdPrefix + dlr.edgeId))
 
+	if _, err := waitForEdgeReady(client, edgeId, false,
+		d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
 	return resourceNsxEdgeDLRInterfaceRead(d, meta)
 }
 
 func resourceNsxEdgeDLRInterfaceRead(d *schema.ResourceData, meta interface{}) error {
 
 	client := meta.(*govnsx.Client)
-	dlrInterfaces := nsxresource.NewEdgeDLRInterfaces(client)
 
 	edgeId := d.Get("edge_id").(string)
 
-	if v, ok := d.GetOk("type"); ok {
-
-		edgeType := v.(string)
-
-		if edgeType != EdgeTypeDistributedRouter {
-			log.Printf("[ERROR] Edge type is not ", EdgeTypeDistributedRouter)
-			err := fmt.Errorf(
-				"[ERROR] Only Edge type %s is supported for this operation",
-				EdgeTypeDistributedRouter)
-			return err
-		}
-	} else {
-
-		edgeType, err := getEdgeType(d.Get("edge_id").(string), meta)
-        
-		if err != nil {
-			log.Printf("[ERROR] Unable to read Edge type %s", err)
-			return err
-		}
-        
-		if edgeType != EdgeTypeDistributedRouter {
-			log.Printf("[ERROR] Edge type is not ", EdgeTypeDistributedRouter) 
-			err := fmt.Errorf("[ERROR] Only Edge type %s is supported for this operation",
-			EdgeTypeDistributedRouter)
-			return err
-		}
-
-		d.Set("type", EdgeTypeDistributedRouter)
+	if err := checkDLREdgeType(d, meta); err != nil {
+		return err
 	}
 
-	log.Printf("[INFO] Read NSX Edge Router Interface: ", edgeId)
-	resp, err := dlrInterfaces.Get(edgeId)
+	log.Printf("[INFO] Read NSX Edge Router Interface: %s", edgeId)
+	resp, err := getDLRInterfaces(client, edgeId)
 
 	if err != nil {
-		log.Printf("[ERROR] Retriving Edge Interfaces %s failed with error : '%v'", edgeId, err)
 		d.SetId("")
 		return err
 	}
 
-	log.Printf("[DEBUG] Retrieved Edge Interfaces %v", resp) 
-
 	ifaces := make([]map[string]interface{}, 0)
 
-	for _, curIface := range resp.EdgeDLRInterfaceList {
+	for _, curIface := range resp.Interfaces {
+
+		if len(curIface.AddressGroups) == 0 {
+			continue
+		}
 
 		if prvIfaces, ok := d.Get("interface").(*schema.Set); ok {
 
@@ -226,7 +195,8 @@ func resourceNsxEdgeDLRInterfaceRead(d *schema.ResourceData, meta interface{}) e
 
 				if curIface.Name == prvIface["name"] &&
 					curIface.ConnectedToId == prvIface["logical_switch_id"].(string) &&
-					curIface.AddressGroups[0].PrimaryAddress == prvIface["ip"].(string) {
+					net.ParseIP(curIface.AddressGroups[0].PrimaryAddress).Equal(
+						net.ParseIP(prvIface["ip"].(string))) {
 
 						prvIface["index"] = curIface.Index
 						ifaces = append(ifaces, prvIface)
@@ -247,10 +217,12 @@ func resourceNsxEdgeDLRInterfaceRead(d *schema.ResourceData, meta interface{}) e
 
 func resourceNsxEdgeDLRInterfaceUpdate(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*govnsx.Client)
-        edlrIface := nsxresource.NewEdgeDLRInterfaces(client)
 
         edgeId := d.Get("edge_id").(string)
 
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
+
 	if d.HasChange("interface") {
 
 		oldIface, newIface := d.GetChange("interface")
@@ -267,44 +239,33 @@ func resourceNsxEdgeDLRInterfaceUpdate(d *schema.ResourceData, meta interface{})
 		for _, removedIfaceRaw := range removedIfaceSet.List() {
 			removedIface  := removedIfaceRaw.(map[string]interface{})	
 			removedIfaceIndex := removedIface["index"].(string)
-			err := edlrIface.Delete(edgeId, removedIfaceIndex)
+			err := deleteDLRInterface(client, edgeId, removedIfaceIndex)
 			if err != nil {
-				log.Printf("[Error]  NSX Edge Interface Delete returned error : %v", err)
 				return err
 			}
 		}
 	
-		ifaces := []nsxtypes.EdgeDLRInterface{}
+		ifaces := []dlrInterface{}
 		for _, addedIfaceRaw := range addedIfaceSet.List() {
 			addedIface := addedIfaceRaw.(map[string]interface{})
 
-			addrGroups := []nsxtypes.AddressGroup{nsxtypes.AddressGroup{
-                        	PrimaryAddress: addedIface["ip"].(string),
-                        	SubnetMask:     addedIface["mask"].(string),
-				},
-			}	
-			
-			iface := nsxtypes.EdgeDLRInterface{
-				AddressGroups: addrGroups,
-				Name:          addedIface["name"].(string),
-				ConnectedToId: addedIface["logical_switch_id"].(string),
-				Type:          "internal",
-				IsConnected:   true,
+			iface, err := createDLRInterfaceSpec(parseDLRInterface(addedIface))
+			if err != nil {
+				return err
 			}
 
-			ifaces = append(ifaces, iface)	
+			ifaces = append(ifaces, iface)
 		}
-			
+
 		if len(ifaces) > 0 {
-			addInterfacesSpec := &nsxtypes.EdgeDLRAddInterfacesSpec{
-				EdgeDLRInterfaceList: ifaces,
+			if err := addDLRInterfaces(client, edgeId, ifaces); err != nil {
+				return err
 			}
+		}
 
-			_, err := edlrIface.Post(addInterfacesSpec, edgeId)
-			if err != nil {
-				log.Printf("[ERROR] dlrInterfaces.Post () returned error : %v", err)
+		if _, err := waitForEdgeReady(client, edgeId, false,
+			d.Timeout(schema.TimeoutUpdate)); err != nil {
 			return err
-			}
 		}
         }
 	return resourceNsxEdgeDLRInterfaceRead(d, meta)
@@ -312,19 +273,49 @@ func resourceNsxEdgeDLRInterfaceUpdate(d *schema.ResourceData, meta interface{})
 
 func resourceNsxEdgeDLRInterfaceDelete(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*govnsx.Client)
-	iface := nsxresource.NewEdgeDLRInterfaces(client)
 
 	edgeId := d.Get("edge_id").(string)
-	log.Printf("[INFO] Deleting NSX EdgeInterface:%s %s\n", edgeId)
+	log.Printf("[INFO] Deleting NSX EdgeInterface: %s\n", edgeId)
+
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
 
-	err := iface.Delete(edgeId)
+	err := deleteDLRInterface(client, edgeId, "")
 	if err != nil {
-		log.Printf("[Error] NSX Edge Interface Delete returned error : %v", err)
+		return err
+	}
+
+	if _, err := waitForEdgeReady(client, edgeId, false,
+		d.Timeout(schema.TimeoutDelete)); err != nil {
 		return err
 	}
 	return nil
 }
 
+// checkDLREdgeType checks that the edge of the resource is a distributed
+// router. The edge type is looked up once and kept in the type attribute.
+func checkDLREdgeType(d *schema.ResourceData, meta interface{}) error {
+
+	edgeType := d.Get("type").(string)
+	if edgeType == "" {
+		var err error
+		edgeType, err = getEdgeType(d.Get("edge_id").(string), meta)
+		if err != nil {
+			log.Printf("[ERROR] Unable to read Edge type %s", err)
+			return err
+		}
+	}
+
+	if edgeType != EdgeTypeDistributedRouter {
+		log.Printf("[ERROR] Edge type is not %s", EdgeTypeDistributedRouter)
+		return fmt.Errorf("[ERROR] Only Edge type %s is supported for this operation",
+			EdgeTypeDistributedRouter)
+	}
+
+	d.Set("type", edgeType)
+	return nil
+}
+
 func parseAndValidateDLRResourceData(d *schema.ResourceData, meta interface{}) (*dlrCfg, error) {
 
 	dlr := &dlrCfg{
@@ -337,13 +328,11 @@ func parseAndValidateDLRResourceData(d *schema.ResourceData, meta interface{}) (
 	if ifSet, ok := vL.(*schema.Set); ok {
 		for _, value := range ifSet.List() {
 
-			newInterface := ifCfg{}
-			iface := value.(map[string]interface{})
+			newInterface := parseDLRInterface(value.(map[string]interface{}))
 
-			newInterface.name = iface["name"].(string)
-			newInterface.ip = iface["ip"].(string)
-			newInterface.mask = iface["mask"].(string)
-			newInterface.logical_switch_id = iface["logical_switch_id"].(string)
+			if _, err := createDLRInterfaceSpec(newInterface); err != nil {
+				return nil, fmt.Errorf("interface '%s': %s", newInterface.name, err)
+			}
 			ifCfgs = append(ifCfgs, newInterface)
 		}
 	}
@@ -351,6 +340,39 @@ func parseAndValidateDLRResourceData(d *schema.ResourceData, meta interface{}) (
 	return dlr, nil
 }
 
+func parseDLRInterface(iface map[string]interface{}) ifCfg {
+
+	newInterface := ifCfg{
+		name:              iface["name"].(string),
+		ip:                iface["ip"].(string),
+		mask:              iface["mask"].(string),
+		prefixLength:      iface["prefix_length"].(int),
+		logical_switch_id: iface["logical_switch_id"].(string),
+	}
+	for _, ip := range iface["secondary_ips"].([]interface{}) {
+		newInterface.secondaryIPs = append(newInterface.secondaryIPs, ip.(string))
+	}
+
+	return newInterface
+}
+
+func createDLRInterfaceSpec(ifcfg ifCfg) (dlrInterface, error) {
+
+	addrGroup, err := newAddressGroup(ifcfg.ip, ifcfg.mask, ifcfg.prefixLength,
+		ifcfg.secondaryIPs)
+	if err != nil {
+		return dlrInterface{}, err
+	}
+
+	return dlrInterface{
+		AddressGroups: []addressGroup{addrGroup},
+		Name:          ifcfg.name,
+		ConnectedToId: ifcfg.logical_switch_id,
+		Type:          InterfaceTypeInternal,
+		IsConnected:   true,
+	}, nil
+}
+
 func validateInterfaceType(v interface{}, k string) (ws []string, errors []error) {
 	value := v.(string)
         found := false
@@ -0,0 +1,265 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	DLRBridgeResourceIdPrefix = "bridge-"
+
+	EdgeBridgingUriFormat = "%s/api/4.0/edges/%s/bridging/config"
+)
+
+// dlrBridge bridges a logical switch to a VLAN backed portgroup.
+type dlrBridge struct {
+	Name        string `xml:"name"`
+	VirtualWire string `xml:"virtualWire"`
+	DvportGroup string `xml:"dvportGroup"`
+}
+
+type dlrBridges struct {
+	XMLName xml.Name    `xml:"bridges"`
+	Version string      `xml:"version,omitempty"`
+	Enabled bool        `xml:"enabled"`
+	Bridges []dlrBridge `xml:"bridge"`
+}
+
+func resourceNsxEdgeDLRBridge() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceNsxEdgeDLRBridgeCreate,
+		Read:   resourceNsxEdgeDLRBridgeRead,
+		Update: resourceNsxEdgeDLRBridgeUpdate,
+		Delete: resourceNsxEdgeDLRBridgeDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"edge_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"type": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"bridge": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"virtual_wire_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						// A VLAN backed portgroup of the distributed
+						// switch of the logical switch.
+						"dvportgroup_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceNsxEdgeDLRBridgeCreate(d *schema.ResourceData, meta interface{}) error {
+
+	if err := checkDLREdgeType(d, meta); err != nil {
+		return err
+	}
+
+	edgeId := d.Get("edge_id").(string)
+
+	log.Printf("[INFO] Configuring bridges of Edge '%s'", edgeId)
+
+	if err := setDLRBridges(d, meta.(*govnsx.Client), d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
+	d.SetId(DLRBridgeResourceIdPrefix + edgeId)
+
+	return resourceNsxEdgeDLRBridgeRead(d, meta)
+}
+
+func resourceNsxEdgeDLRBridgeRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+	edgeId := d.Get("edge_id").(string)
+
+	if err := checkDLREdgeType(d, meta); err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Edge '%s' not found, removing bridges from state", edgeId)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	bridges, err := getDLRBridges(client, edgeId)
+	if err != nil {
+		return err
+	}
+
+	if !bridges.Enabled || len(bridges.Bridges) == 0 {
+		log.Printf("[WARN] Bridging of Edge '%s' is disabled, removing from state", edgeId)
+		d.SetId("")
+		return nil
+	}
+
+	bridgeList := []map[string]interface{}{}
+	for _, bridge := range bridges.Bridges {
+		bridgeList = append(bridgeList, map[string]interface{}{
+			"name":            bridge.Name,
+			"virtual_wire_id": bridge.VirtualWire,
+			"dvportgroup_id":  bridge.DvportGroup,
+		})
+	}
+
+	if err := d.Set("bridge", bridgeList); err != nil {
+		return fmt.Errorf("Invalid bridges to set: %#v", bridgeList)
+	}
+
+	return nil
+}
+
+func resourceNsxEdgeDLRBridgeUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	log.Printf("[INFO] Updating bridges of Edge '%s'", d.Get("edge_id").(string))
+
+	if err := setDLRBridges(d, meta.(*govnsx.Client), d.Timeout(schema.TimeoutUpdate)); err != nil {
+		return err
+	}
+
+	return resourceNsxEdgeDLRBridgeRead(d, meta)
+}
+
+func resourceNsxEdgeDLRBridgeDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+	edgeId := d.Get("edge_id").(string)
+
+	log.Printf("[INFO] Deleting bridges of Edge '%s'", edgeId)
+
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
+
+	deleteUri := fmt.Sprintf(EdgeBridgingUriFormat, client.MgrConfig.Uri, edgeId)
+	if err := nsxDelete(client, deleteUri); err != nil {
+		if isNotFoundError(err) {
+			return nil
+		}
+		log.Printf("[ERROR] Deleting bridges of Edge '%s' failed with error : %v", edgeId, err)
+		return err
+	}
+
+	if _, err := waitForEdgeReady(client, edgeId, false,
+		d.Timeout(schema.TimeoutDelete)); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// setDLRBridges replaces the bridges of the edge by the configured ones.
+func setDLRBridges(d *schema.ResourceData, client *govnsx.Client, timeout time.Duration) error {
+
+	edgeId := d.Get("edge_id").(string)
+
+	bridges, err := expandDLRBridges(d.Get("bridge").(*schema.Set).List())
+	if err != nil {
+		log.Printf("[ERROR] Configuration validation failed.")
+		return err
+	}
+
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
+
+	curBridges, err := getDLRBridges(client, edgeId)
+	if err != nil {
+		return err
+	}
+	bridges.Version = curBridges.Version
+
+	putUri := fmt.Sprintf(EdgeBridgingUriFormat, client.MgrConfig.Uri, edgeId)
+	if err := nsxPut(client, putUri, bridges); err != nil {
+		log.Printf("[ERROR] Configuring bridges of Edge '%s' failed with error : '%v'", edgeId, err)
+		return err
+	}
+
+	_, err = waitForEdgeReady(client, edgeId, false, timeout)
+	return err
+}
+
+func getDLRBridges(client *govnsx.Client, edgeId string) (*dlrBridges, error) {
+
+	getUri := fmt.Sprintf(EdgeBridgingUriFormat, client.MgrConfig.Uri, edgeId)
+
+	bridges := &dlrBridges{}
+	if err := nsxGet(client, getUri, bridges); err != nil {
+		log.Printf("[ERROR] Retriving bridges of Edge '%s' failed with error : '%v'", edgeId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] Retrieved bridges of Edge '%s': %v", edgeId, bridges)
+	return bridges, nil
+}
+
+// expandDLRBridges builds the bridges of the set. A logical switch or a
+// portgroup can only be part of one bridge, and the bridge names must be
+// unique.
+func expandDLRBridges(bridgeList []interface{}) (*dlrBridges, error) {
+
+	bridges := &dlrBridges{Enabled: true}
+
+	names := map[string]bool{}
+	virtualWires := map[string]string{}
+	portgroups := map[string]string{}
+
+	for _, v := range bridgeList {
+		bridgeMap := v.(map[string]interface{})
+		bridge := dlrBridge{
+			Name:        bridgeMap["name"].(string),
+			VirtualWire: bridgeMap["virtual_wire_id"].(string),
+			DvportGroup: bridgeMap["dvportgroup_id"].(string),
+		}
+
+		if names[bridge.Name] {
+			return nil, fmt.Errorf("Bridge name '%s' is used more than once.", bridge.Name)
+		}
+		if name, ok := virtualWires[bridge.VirtualWire]; ok {
+			return nil, fmt.Errorf("Logical switch '%s' is part of bridges '%s' and '%s'.",
+				bridge.VirtualWire, name, bridge.Name)
+		}
+		if name, ok := portgroups[bridge.DvportGroup]; ok {
+			return nil, fmt.Errorf("Portgroup '%s' is part of bridges '%s' and '%s'.",
+				bridge.DvportGroup, name, bridge.Name)
+		}
+
+		names[bridge.Name] = true
+		virtualWires[bridge.VirtualWire] = bridge.Name
+		portgroups[bridge.DvportGroup] = bridge.Name
+
+		bridges.Bridges = append(bridges.Bridges, bridge)
+	}
+
+	return bridges, nil
+}
@@ -0,0 +1,166 @@
+package nsx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+var vlanPortgroupId = testAccEnvOrMock("NSX_VLAN_PORTGROUP_ID", "dvportgroup-2")
+
+const testAccCheckEdgeDLRBridgeConf = `
+resource "nsxv_edge_dlr_bridge" "bridge" {
+    edge_id = "%s"
+    bridge {
+        name = "%s"
+        virtual_wire_id = "%s"
+        dvportgroup_id = "%s"
+    }
+}
+`
+
+func TestAccNsxEdgeDLRBridge_Expand(t *testing.T) {
+
+	bridge := func(name, vwire, portgroup string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":            name,
+			"virtual_wire_id": vwire,
+			"dvportgroup_id":  portgroup,
+		}
+	}
+
+	cases := []struct {
+		bridges []interface{}
+		expErr  string
+	}{
+		{bridges: []interface{}{bridge("br-1", "virtualwire-1", "dvportgroup-1"),
+			bridge("br-2", "virtualwire-2", "dvportgroup-2")}},
+		{bridges: []interface{}{bridge("br-1", "virtualwire-1", "dvportgroup-1"),
+			bridge("br-1", "virtualwire-2", "dvportgroup-2")},
+			expErr: "Bridge name 'br-1' is used more than once"},
+		{bridges: []interface{}{bridge("br-1", "virtualwire-1", "dvportgroup-1"),
+			bridge("br-2", "virtualwire-1", "dvportgroup-2")},
+			expErr: "Logical switch 'virtualwire-1' is part of bridges"},
+		{bridges: []interface{}{bridge("br-1", "virtualwire-1", "dvportgroup-1"),
+			bridge("br-2", "virtualwire-2", "dvportgroup-1")},
+			expErr: "Portgroup 'dvportgroup-1' is part of bridges"},
+	}
+
+	for i, c := range cases {
+		bridges, err := expandDLRBridges(c.bridges)
+		if c.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expErr) {
+				t.Fatalf("case %d: expected error '%s', got '%v'", i, c.expErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d: unexpected error %v", i, err)
+		}
+		if !bridges.Enabled || len(bridges.Bridges) != len(c.bridges) {
+			t.Fatalf("case %d: unexpected bridges %#v", i, bridges)
+		}
+	}
+}
+
+func TestAccNsxEdgeDLRBridge_Basic(t *testing.T) {
+
+	resourceName := "nsxv_edge_dlr_bridge.bridge"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdgeDLRBridge(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDLRBridgeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckEdgeDLRBridgeConf, dlrEdgeId,
+					"tf-acc-bridge", lsId, vlanPortgroupId),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id",
+						DLRBridgeResourceIdPrefix+dlrEdgeId),
+					resource.TestCheckResourceAttr(resourceName, "type",
+						EdgeTypeDistributedRouter),
+					resource.TestCheckResourceAttr(resourceName, "bridge.#", "1"),
+					testAccCheckEdgeDLRBridgeName(dlrEdgeId, "tf-acc-bridge"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckEdgeDLRBridgeConf, dlrEdgeId,
+					"tf-acc-bridge-2", lsId, vlanPortgroupId),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "bridge.#", "1"),
+					testAccCheckEdgeDLRBridgeName(dlrEdgeId, "tf-acc-bridge-2"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeDLRBridge_NotDistributedRouter(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdgeDLRBridge(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDLRBridgeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckEdgeDLRBridgeConf, edgeId,
+					"tf-acc-bridge", lsId, vlanPortgroupId),
+				ExpectError: regexp.MustCompile("Only Edge type distributedRouter is supported"),
+			},
+		},
+	})
+}
+
+func testAccCheckEdgeDLRBridgeName(edgeId string, name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		client := testAccProvider.Meta().(*govnsx.Client)
+
+		bridges, err := getDLRBridges(client, edgeId)
+		if err != nil {
+			return err
+		}
+
+		if len(bridges.Bridges) != 1 || bridges.Bridges[0].Name != name {
+			return fmt.Errorf("Expected bridge %s on Edge %s, got %#v", name, edgeId, bridges)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckEdgeDLRBridgeDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_edge_dlr_bridge" {
+			continue
+		}
+
+		bridges, err := getDLRBridges(client, rs.Primary.Attributes["edge_id"])
+		if err != nil {
+			return err
+		}
+		if len(bridges.Bridges) != 0 {
+			return fmt.Errorf("Bridges of Edge %s still exist", rs.Primary.Attributes["edge_id"])
+		}
+	}
+
+	return nil
+}
+
+func testAccPreCheckEdgeDLRBridge(t *testing.T) {
+
+	testAccPreCheckEdgeDLR(t)
+
+	if edgeId == "" || vlanPortgroupId == "" {
+		t.Fatal("NSX_EDGE_ID and NSX_VLAN_PORTGROUP_ID must be set for acceptance tests")
+	}
+}
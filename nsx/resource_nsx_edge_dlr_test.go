@@ -0,0 +1,186 @@
+package nsx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+var dlrEdgeId = testAccEnvOrMock("NSX_DLR_EDGE_ID", mockDLREdgeId)
+
+const testAccCheckEdgeDLRConf = `
+resource "nsxv_edge_dlr" "dlr" {
+    edge_id = "%s"
+    interface {
+        name = "tf-acc-ipv4"
+        ip = "10.30.0.1"
+        mask = "255.255.255.0"
+        secondary_ips = ["10.30.0.2"]
+        logical_switch_id = "%s"
+    }
+    interface {
+        name = "tf-acc-ipv6"
+        ip = "2001:db8:30::1"
+        prefix_length = 64
+        logical_switch_id = "%s"
+    }
+}
+`
+
+func TestAccNsxEdgeDLR_NewAddressGroup(t *testing.T) {
+
+	cases := []struct {
+		ip           string
+		mask         string
+		prefixLength int
+		secondaryIPs []string
+		expected     addressGroup
+		expErr       string
+	}{
+		{ip: "10.0.0.1", mask: "255.255.255.0",
+			expected: addressGroup{PrimaryAddress: "10.0.0.1", SubnetMask: "255.255.255.0"}},
+		{ip: "10.0.0.1", prefixLength: 24,
+			expected: addressGroup{PrimaryAddress: "10.0.0.1", SubnetPrefixLength: 24}},
+		{ip: "2001:db8::1", prefixLength: 64, secondaryIPs: []string{"2001:db8::2"},
+			expected: addressGroup{PrimaryAddress: "2001:db8::1", SubnetPrefixLength: 64,
+				SecondaryAddresses: []string{"2001:db8::2"}}},
+		{ip: "2001:db8::1", mask: "255.255.255.0", expErr: "need a prefix_length"},
+		{ip: "2001:db8::1", expErr: "between 1 and 128 must be set"},
+		{ip: "10.0.0.1", prefixLength: 33, expErr: "between 1 and 32 must be set"},
+		{ip: "10.0.0.1", mask: "255.255.255.0", prefixLength: 24,
+			expErr: "only one of mask or prefix_length"},
+		{ip: "10.0.0.1", mask: "255.255.255.0", secondaryIPs: []string{"2001:db8::2"},
+			expErr: "is not an address of the same IP version"},
+	}
+
+	for _, c := range cases {
+		addrGroup, err := newAddressGroup(c.ip, c.mask, c.prefixLength, c.secondaryIPs)
+		if c.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expErr) {
+				t.Fatalf("%s: expected error '%s', got '%v'", c.ip, c.expErr, err)
+			}
+			continue
+		}
+		if err != nil || fmt.Sprint(addrGroup) != fmt.Sprint(c.expected) {
+			t.Fatalf("%s: expected %#v, got %#v, '%v'", c.ip, c.expected, addrGroup, err)
+		}
+	}
+}
+
+func TestAccNsxEdgeDLR_IPv6(t *testing.T) {
+
+	resourceName := "nsxv_edge_dlr.dlr"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdgeDLR(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDLRDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckEdgeDLRConf, dlrEdgeId, lsId, lsId),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "interface.#", "2"),
+					testAccCheckEdgeDLRAddressGroups(dlrEdgeId),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdgeDLR_RequestXML(t *testing.T) {
+
+	if !isTestAccMock() {
+		t.Skip("Checking the requests requires the mock NSX Manager")
+	}
+	m := testAccMockNsxManager(t)
+
+	iface := "interfaces/interface/"
+	addrGroup := iface + "addressGroups/addressGroup/"
+
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDLRDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckEdgeDLRConf, dlrEdgeId, lsId, lsId),
+				Check: testAccCheckMockRequestXML(m, "POST",
+					"/api/4.0/edges/"+dlrEdgeId+"/interfaces/", map[string][]string{
+						iface + "name":                             {"tf-acc-ipv4", "tf-acc-ipv6"},
+						iface + "type":                             {"internal", "internal"},
+						iface + "isConnected":                      {"true", "true"},
+						iface + "connectedToId":                    {lsId, lsId},
+						addrGroup + "primaryAddress":               {"10.30.0.1", "2001:db8:30::1"},
+						addrGroup + "subnetMask":                   {"255.255.255.0"},
+						addrGroup + "subnetPrefixLength":           {"64"},
+						addrGroup + "secondaryAddresses/ipAddress": {"10.30.0.2"},
+					}),
+			},
+		},
+	})
+}
+
+func testAccCheckEdgeDLRAddressGroups(edgeId string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		client := testAccProvider.Meta().(*govnsx.Client)
+
+		ifaces, err := getDLRInterfaces(client, edgeId)
+		if err != nil {
+			return err
+		}
+
+		found := map[string]addressGroup{}
+		for _, iface := range ifaces.Interfaces {
+			if len(iface.AddressGroups) > 0 {
+				found[iface.Name] = iface.AddressGroups[0]
+			}
+		}
+
+		if ag := found["tf-acc-ipv4"]; ag.SubnetMask != "255.255.255.0" ||
+			len(ag.SecondaryAddresses) != 1 {
+			return fmt.Errorf("Unexpected IPv4 address group %#v", ag)
+		}
+		if ag := found["tf-acc-ipv6"]; ag.PrimaryAddress != "2001:db8:30::1" ||
+			ag.SubnetPrefixLength != 64 {
+			return fmt.Errorf("Unexpected IPv6 address group %#v", ag)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckEdgeDLRDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_edge_dlr" {
+			continue
+		}
+
+		ifaces, err := getDLRInterfaces(client, rs.Primary.Attributes["edge_id"])
+		if err != nil {
+			return err
+		}
+		for _, iface := range ifaces.Interfaces {
+			if strings.HasPrefix(iface.Name, "tf-acc-") {
+				return fmt.Errorf("DLR interface %s still exists", iface.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func testAccPreCheckEdgeDLR(t *testing.T) {
+
+	testAccPreCheck(t)
+
+	if dlrEdgeId == "" || lsId == "" {
+		t.Fatal("NSX_DLR_EDGE_ID and NSX_LOGICAL_SWITCH_ID must be set for acceptance tests")
+	}
+}
@@ -0,0 +1,225 @@
+package nsx
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	DNSForwarderResourceIdPrefix = "dns-"
+	DefaultDnsCacheSize          = 16 // MB
+)
+
+func resourceNsxEdgeDnsForwarder() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceNsxEdgeDnsForwarderCreate,
+		Read:   resourceNsxEdgeDnsForwarderRead,
+		Update: resourceNsxEdgeDnsForwarderUpdate,
+		Delete: resourceNsxEdgeDnsForwarderDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"edge_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"cache_size": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      DefaultDnsCacheSize,
+				ValidateFunc: validateDnsCacheSize,
+			},
+			"listeners": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateIP,
+				},
+			},
+			"upstream_servers": &schema.Schema{
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				MaxItems: 3,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateIP,
+				},
+			},
+		},
+	}
+}
+
+func resourceNsxEdgeDnsForwarderCreate(d *schema.ResourceData, meta interface{}) error {
+
+	edgeId := d.Get("edge_id").(string)
+
+	log.Printf("[INFO] Configuring DNS forwarder of Edge '%s'", edgeId)
+
+	if err := setEdgeDnsForwarder(d, meta.(*govnsx.Client), d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
+	d.SetId(DNSForwarderResourceIdPrefix + edgeId)
+
+	return resourceNsxEdgeDnsForwarderRead(d, meta)
+}
+
+func resourceNsxEdgeDnsForwarderRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+	edgeId := d.Get("edge_id").(string)
+
+	edgeCfg, err := getEdge(client, edgeId)
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Edge '%s' not found, removing DNS forwarder from state", edgeId)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	dns := edgeCfg.Features.Dns
+	if dns == nil || !dns.Enabled {
+		log.Printf("[WARN] DNS forwarder of Edge '%s' is disabled, removing from state", edgeId)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("cache_size", dns.CacheSize)
+
+	listeners := []string{}
+	for _, listener := range dns.Listeners {
+		if listener != DnsListenerAny {
+			listeners = append(listeners, listener)
+		}
+	}
+	d.Set("listeners", listeners)
+
+	upstreamServers := []string{}
+	for _, view := range dns.DnsViews {
+		if view.Name == DnsDefaultView {
+			upstreamServers = view.Forwarders
+			break
+		}
+	}
+	d.Set("upstream_servers", upstreamServers)
+
+	return nil
+}
+
+func resourceNsxEdgeDnsForwarderUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	log.Printf("[INFO] Updating DNS forwarder of Edge '%s'", d.Get("edge_id").(string))
+
+	if err := setEdgeDnsForwarder(d, meta.(*govnsx.Client), d.Timeout(schema.TimeoutUpdate)); err != nil {
+		return err
+	}
+
+	return resourceNsxEdgeDnsForwarderRead(d, meta)
+}
+
+func resourceNsxEdgeDnsForwarderDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+	edgeId := d.Get("edge_id").(string)
+
+	log.Printf("[INFO] Disabling DNS forwarder of Edge '%s'", edgeId)
+
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
+
+	edgeCfg, err := getEdge(client, edgeId)
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil
+		}
+		return err
+	}
+
+	if err := updateEdgeDns(client, edgeId, &edgeDns{Enabled: false}); err != nil {
+		return err
+	}
+
+	if _, err := waitForEdgeReady(client, edgeId, edgeCfg.Appliances.DeployAppliances,
+		d.Timeout(schema.TimeoutDelete)); err != nil {
+		return err
+	}
+
+	d.SetId("")
+	return nil
+}
+
+// setEdgeDnsForwarder pushes the DNS forwarder configuration through the
+// DNS API of the edge.
+func setEdgeDnsForwarder(d *schema.ResourceData, client *govnsx.Client, timeout time.Duration) error {
+
+	edgeId := d.Get("edge_id").(string)
+
+	edgeMutexKV.Lock(edgeId)
+	defer edgeMutexKV.Unlock(edgeId)
+
+	edgeCfg, err := getEdge(client, edgeId)
+	if err != nil {
+		return err
+	}
+
+	if err := updateEdgeDns(client, edgeId, expandEdgeDns(d)); err != nil {
+		return err
+	}
+
+	_, err = waitForEdgeReady(client, edgeId, edgeCfg.Appliances.DeployAppliances, timeout)
+	return err
+}
+
+func expandEdgeDns(d *schema.ResourceData) *edgeDns {
+
+	dns := &edgeDns{
+		Enabled:   true,
+		CacheSize: d.Get("cache_size").(int),
+	}
+
+	for _, listener := range d.Get("listeners").([]interface{}) {
+		dns.Listeners = append(dns.Listeners, listener.(string))
+	}
+	if len(dns.Listeners) == 0 {
+		dns.Listeners = []string{DnsListenerAny}
+	}
+
+	view := edgeDnsView{
+		Name:    DnsDefaultView,
+		Enabled: true,
+		ViewMatch: edgeDnsViewMatch{
+			IpAddress: []string{DnsListenerAny},
+			Vnic:      []string{DnsListenerAny},
+		},
+	}
+	for _, server := range d.Get("upstream_servers").([]interface{}) {
+		view.Forwarders = append(view.Forwarders, server.(string))
+	}
+	dns.DnsViews = []edgeDnsView{view}
+
+	return dns
+}
+
+func validateDnsCacheSize(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(int)
+
+	if value < 1 {
+		errors = append(errors, fmt.Errorf(
+			"%s: cache size must be a positive number of MB, got %d", k, value))
+	}
+	return
+}
@@ -0,0 +1,80 @@
+package nsx
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const testAccCheckEdgeDnsForwarderConf = `
+resource "nsxv_edge_dns_forwarder" "dns" {
+    edge_id = "%s"
+    cache_size = %d
+    upstream_servers = [%s]
+}
+`
+
+func TestAccNsxEdgeDnsForwarder_Basic(t *testing.T) {
+
+	resourceName := "nsxv_edge_dns_forwarder.dns"
+
+	config := fmt.Sprintf(testAccCheckEdgeDnsForwarderConf, edgeId, 16, `"10.10.1.53"`)
+	configUpdate := fmt.Sprintf(testAccCheckEdgeDnsForwarderConf, edgeId, 32,
+		`"10.10.1.53", "10.10.2.53"`)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdgeDnsForwarder(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDnsForwarderDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "cache_size", "16"),
+					resource.TestCheckResourceAttr(resourceName, "upstream_servers.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "listeners.#", "0"),
+				),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "cache_size", "32"),
+					resource.TestCheckResourceAttr(resourceName, "upstream_servers.1", "10.10.2.53"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckEdgeDnsForwarderDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_edge_dns_forwarder" {
+			continue
+		}
+
+		edgeCfg, err := getEdge(client, rs.Primary.Attributes["edge_id"])
+		if err != nil {
+			return err
+		}
+		if edgeCfg.Features.Dns != nil && edgeCfg.Features.Dns.Enabled {
+			return fmt.Errorf("DNS forwarder of Edge %s still enabled", edgeCfg.Id)
+		}
+	}
+
+	return nil
+}
+
+func testAccPreCheckEdgeDnsForwarder(t *testing.T) {
+
+	testAccPreCheck(t)
+
+	if edgeId == "" {
+		t.Fatal("NSX_EDGE_ID must be set for acceptance tests")
+	}
+}
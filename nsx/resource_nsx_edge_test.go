@@ -0,0 +1,843 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/IBM-tfproviders/govnsx/nsxresource"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+var (
+	resourcePoolId   = testAccEnvOrMock("NSX_RESOURCE_POOL_ID", "resgroup-1")
+	datastoreId      = testAccEnvOrMock("NSX_DATASTORE_ID", "datastore-1")
+	resourcePoolName = testAccEnvOrMock("NSX_RESOURCE_POOL_NAME", "mock-rp")
+	datastoreName    = testAccEnvOrMock("NSX_DATASTORE_NAME", "mock-ds")
+)
+
+const testAccCheckEdgeConf = `
+resource "nsxv_edge" "%s" {
+    name = "%s"
+    type = "gatewayServices"
+    appliances {
+        size = "compact"
+        appliance {
+            resource_pool_id = "%s"
+            datastore_id = "%s"
+        }
+    }
+%s
+}
+`
+
+const testAccCheckEdgeConf_placementByName = `
+resource "nsxv_edge" "%s" {
+    name = "%s"
+    type = "gatewayServices"
+    appliances {
+        appliance {
+            resource_pool_name = "%s"
+            datastore_name = "%s"
+        }
+    }
+}
+`
+
+const testAccCheckEdgeConf_ha = `
+resource "nsxv_edge" "%s" {
+    name = "%s"
+    type = "gatewayServices"
+    appliances {
+        size = "%s"
+        appliance {
+            resource_pool_id = "%s"
+            datastore_id = "%s"
+            mgmt_interface {
+                portgroup = "dvportgroup-1"
+                ip = "%s"
+                mask = "%s"
+            }
+        }
+        appliance {
+            resource_pool_id = "%s"
+            datastore_id = "%s"
+            mgmt_interface {
+                portgroup = "dvportgroup-1"
+                ip = "%s"
+                mask = "%s"
+            }
+        }
+    }
+}
+`
+
+const testAccCheckEdgeConf_dlr = `
+resource "nsxv_edge" "%s" {
+    name = "%s"
+    type = "%s"
+    appliances {
+        appliance {
+            resource_pool_id = "%s"
+            datastore_id = "%s"
+%s
+        }
+%s
+    }
+%s
+}
+`
+
+const testAccCheckEdgeConf_dlrAppliance = `
+        appliance {
+            resource_pool_id = "%s"
+            datastore_id = "%s"
+        }
+`
+
+const testAccCheckEdgeConf_haInterface = `
+    ha_interface {
+        portgroup = "%s"
+        ip = "%s"
+        mask = "255.255.255.0"
+    }
+`
+
+const testAccCheckEdgeConf_routing = `
+    routing_global_config {
+        router_id = "%s"
+        ecmp = %t
+        log_enabled = %t
+        log_level = "%s"
+    }
+`
+
+// testEdgeRoutingXML is the routing of a distributed router with static
+// routes, OSPF and IP prefixes, none of them managed by the edge resource.
+const testEdgeRoutingXML = `<routing>
+    <version>3</version>
+    <enabled>true</enabled>
+    <routingGlobalConfig>
+        <routerId>10.40.0.10</routerId>
+        <ecmp>false</ecmp>
+        <ipPrefixes><ipPrefix><name>tf-acc-prefix</name><ipAddress>10.50.0.0/24</ipAddress></ipPrefix></ipPrefixes>
+    </routingGlobalConfig>
+    <staticRouting><staticRoutes><route><network>10.60.0.0/24</network><nextHop>10.40.0.1</nextHop></route></staticRoutes></staticRouting>
+    <ospf><enabled>true</enabled></ospf>
+</routing>`
+
+const testAccCheckEdgeConf_features = `
+    syslog {
+        server_addresses = ["10.10.1.10", "10.10.1.11"]
+        protocol = "tcp"
+    }
+    dns_client {
+        primary_dns = "10.10.1.53"
+        domain_name = "example.com"
+    }
+`
+
+const testAccCheckEdgeConf_featuresUpdate = `
+    syslog {
+        server_addresses = ["10.10.2.10"]
+    }
+`
+
+const testAccCheckEdgeConf_cliSettings = `
+    fips_enabled = true
+    cli_settings {
+        password = "Tf-Acc-Passw0rd"
+        remote_access = %t
+        banner = "%s"
+    }
+`
+
+func TestAccNsxEdge_ValidatorFunc(t *testing.T) {
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "type", validatorFn: validateEdgeType,
+			values: []attributeProperty{
+				{value: EdgeTypeGatewayServices, successCase: true},
+				{value: EdgeTypeDistributedRouter, successCase: true},
+				{value: "router", expErr: "Supported values are"},
+			},
+		},
+		{name: "size", validatorFn: validateEdgeApplianceSize,
+			values: []attributeProperty{
+				{value: EdgeApplianceSizeCompact, successCase: true},
+				{value: "huge", expErr: "Supported values are"},
+			},
+		},
+		{name: "protocol", validatorFn: validateSyslogProtocol,
+			values: []attributeProperty{
+				{value: SyslogProtocolUDP, successCase: true},
+				{value: SyslogProtocolTCP, successCase: true},
+				{value: "tls", expErr: "Supported values are"},
+			},
+		},
+		{name: "mask", validatorFn: validateNetmask,
+			values: []attributeProperty{
+				{value: "255.255.255.0", successCase: true},
+				{value: "255.255.255.254", successCase: true},
+				{value: "255.0.255.0", expErr: "is not contiguous"},
+				{value: "0.0.0.0", expErr: "is not contiguous"},
+				{value: "10.0.0", expErr: "is not a valid IPv4 netmask"},
+			},
+		},
+		{name: "password", validatorFn: validateCliPassword,
+			values: []attributeProperty{
+				{value: "Tf-Acc-Passw0rd", successCase: true},
+				{value: "Sh0rt-Pass", expErr: "must be at least 12 characters"},
+				{value: "tf-acc-passw0rd", expErr: "must be at least 12 characters"},
+				{value: "TfAccPassw0rd1", expErr: "must be at least 12 characters"},
+			},
+		},
+		{name: "log_level", validatorFn: validateRoutingLogLevel,
+			values: []attributeProperty{
+				{value: "info", successCase: true},
+				{value: "debug", successCase: true},
+				{value: "verbose", expErr: "Supported values are"},
+			},
+		},
+		{name: "router_id", validatorFn: validateRouterId,
+			values: []attributeProperty{
+				{value: "10.0.0.1", successCase: true},
+				{value: "2001:db8::1", expErr: "is not a valid IPv4 address"},
+				{value: "router-1", expErr: "is not a valid IPv4 address"},
+			},
+		},
+		{name: "password_expiry", validatorFn: validatePasswordExpiry,
+			values: []attributeProperty{
+				{value: 90, successCase: true},
+				{value: 0, expErr: "must be between 1 and 99999 days"},
+				{value: 100000, expErr: "must be between 1 and 99999 days"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+func TestAccNsxEdge_Basic(t *testing.T) {
+
+	edgeName := "TFT_EDGE"
+	resourceName := "nsxv_edge." + edgeName
+
+	config := fmt.Sprintf(testAccCheckEdgeConf, edgeName, edgeName,
+		resourcePoolId, datastoreId, testAccCheckEdgeConf_features)
+	configUpdate := fmt.Sprintf(testAccCheckEdgeConf, edgeName, edgeName,
+		resourcePoolId, datastoreId, testAccCheckEdgeConf_featuresUpdate)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdge(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "edge_id"),
+					resource.TestCheckResourceAttr(resourceName, "syslog.0.protocol", "tcp"),
+					resource.TestCheckResourceAttr(resourceName, "syslog.0.server_addresses.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "dns_client.0.primary_dns", "10.10.1.53"),
+					resource.TestCheckResourceAttr(resourceName, "dns_client.0.domain_name", "example.com"),
+				),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "syslog.0.protocol", "udp"),
+					resource.TestCheckResourceAttr(resourceName, "syslog.0.server_addresses.0", "10.10.2.10"),
+					resource.TestCheckResourceAttr(resourceName, "dns_client.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdge_PlacementByName(t *testing.T) {
+
+	edgeName := "TFT_EDGE_PLACEMENT"
+	resourceName := "nsxv_edge." + edgeName
+
+	config := fmt.Sprintf(testAccCheckEdgeConf_placementByName, edgeName, edgeName,
+		resourcePoolName, datastoreName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdgePlacementByName(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName,
+						"appliances.0.appliance.0.resource_pool_id"),
+					resource.TestCheckResourceAttrSet(resourceName,
+						"appliances.0.appliance.0.datastore_id"),
+					resource.TestCheckResourceAttr(resourceName,
+						"appliances.0.appliance.0.datastore_name", datastoreName),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdge_ValidateHostAddress(t *testing.T) {
+
+	cases := []struct {
+		ip     string
+		mask   string
+		subnet string
+		expErr string
+	}{
+		{ip: "10.0.0.1", mask: "255.255.255.0", subnet: "10.0.0.0/24"},
+		{ip: "10.0.0.254", mask: "255.255.255.0", subnet: "10.0.0.0/24"},
+		{ip: "10.0.0.0", mask: "255.255.255.31", expErr: "is not contiguous"},
+		{ip: "10.0.0.0", mask: "255.255.255.0", expErr: "is the network address"},
+		{ip: "10.0.0.255", mask: "255.255.255.0", expErr: "is the broadcast address"},
+		{ip: "10.0.0.4", mask: "255.255.255.254", subnet: "10.0.0.4/31"},
+		{ip: "10.0.0.5", mask: "255.255.255.255", subnet: "10.0.0.5/32"},
+		{ip: "10.0.0", mask: "255.255.255.0", expErr: "is not a valid IPv4 address"},
+	}
+
+	for _, c := range cases {
+		subnet, err := validateHostAddress(c.ip, c.mask)
+		if c.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expErr) {
+				t.Fatalf("%s/%s: expected error '%s', got '%v'", c.ip, c.mask, c.expErr, err)
+			}
+			continue
+		}
+		if err != nil || subnet.String() != c.subnet {
+			t.Fatalf("%s/%s: expected subnet '%s', got '%v', '%v'",
+				c.ip, c.mask, c.subnet, subnet, err)
+		}
+	}
+}
+
+func TestAccNsxEdge_PlanValidation(t *testing.T) {
+
+	edgeName := "TFT_EDGE_HA"
+
+	config := func(size, ip1, mask1, ip2, mask2 string) string {
+		return fmt.Sprintf(testAccCheckEdgeConf_ha, edgeName, edgeName, size,
+			resourcePoolId, datastoreId, ip1, mask1,
+			resourcePoolId, datastoreId, ip2, mask2)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheckEdge(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config(EdgeApplianceSizeCompact,
+					"10.0.0.1", "255.255.255.0", "10.0.0.2", "255.255.255.0"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("size 'compact' does not support HA"),
+			},
+			resource.TestStep{
+				Config: config(EdgeApplianceSizeLarge,
+					"10.0.0.1", "255.255.255.0", "10.0.0.255", "255.255.255.0"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("is the broadcast address"),
+			},
+			resource.TestStep{
+				Config: config(EdgeApplianceSizeLarge,
+					"10.0.0.1", "255.255.255.0", "10.0.0.1", "255.255.255.0"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("IP '10.0.0.1' is not unique"),
+			},
+			resource.TestStep{
+				Config: config(EdgeApplianceSizeLarge,
+					"10.0.0.1", "255.255.255.0", "10.0.1.2", "255.255.255.0"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("differs from the HA peer subnet"),
+			},
+			resource.TestStep{
+				Config: config(EdgeApplianceSizeLarge,
+					"10.0.0.1", "255.0.255.0", "10.0.0.2", "255.255.255.0"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("is not contiguous"),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdge_DistributedRouter(t *testing.T) {
+
+	edgeName := "TFT_EDGE_DLR"
+	resourceName := "nsxv_edge." + edgeName
+
+	config := func(haIP, routing string) string {
+		return fmt.Sprintf(testAccCheckEdgeConf_dlr, edgeName, edgeName,
+			EdgeTypeDistributedRouter, resourcePoolId, datastoreId, "",
+			fmt.Sprintf(testAccCheckEdgeConf_dlrAppliance, resourcePoolId, datastoreId),
+			fmt.Sprintf(testAccCheckEdgeConf_haInterface, portgroupId, haIP)+routing)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdgeDistributedRouter(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config("10.40.0.10", fmt.Sprintf(testAccCheckEdgeConf_routing,
+					"10.40.0.10", false, false, "info")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "appliances.0.appliance.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "ha_interface.0.portgroup", portgroupId),
+					resource.TestCheckResourceAttr(resourceName, "ha_interface.0.ip", "10.40.0.10"),
+					resource.TestCheckResourceAttr(resourceName, "routing_global_config.0.router_id", "10.40.0.10"),
+					resource.TestCheckResourceAttr(resourceName, "routing_global_config.0.ecmp", "false"),
+					testAccCheckEdgeHaInterface(resourceName, "10.40.0.10"),
+				),
+			},
+			resource.TestStep{
+				Config: config("10.40.0.11", fmt.Sprintf(testAccCheckEdgeConf_routing,
+					"10.40.0.11", true, true, "debug")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "ha_interface.0.ip", "10.40.0.11"),
+					resource.TestCheckResourceAttr(resourceName, "routing_global_config.0.router_id", "10.40.0.11"),
+					resource.TestCheckResourceAttr(resourceName, "routing_global_config.0.ecmp", "true"),
+					resource.TestCheckResourceAttr(resourceName, "routing_global_config.0.log_enabled", "true"),
+					resource.TestCheckResourceAttr(resourceName, "routing_global_config.0.log_level", "debug"),
+					testAccCheckEdgeHaInterface(resourceName, "10.40.0.11"),
+				),
+			},
+			resource.TestStep{
+				Config: config("10.40.0.11", ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "routing_global_config.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdge_TypePlanValidation(t *testing.T) {
+
+	edgeName := "TFT_EDGE_TYPE"
+
+	config := func(edgeType, mgmt, peer, settings string) string {
+		return fmt.Sprintf(testAccCheckEdgeConf_dlr, edgeName, edgeName, edgeType,
+			resourcePoolId, datastoreId, mgmt, peer, settings)
+	}
+	mgmt := `
+            mgmt_interface {
+                portgroup = "dvportgroup-1"
+                ip = "10.0.0.1"
+                mask = "255.255.255.0"
+            }
+`
+	peer := fmt.Sprintf(testAccCheckEdgeConf_dlrAppliance, resourcePoolId, datastoreId)
+	haInterface := func(ip string) string {
+		return fmt.Sprintf(testAccCheckEdgeConf_haInterface, "dvportgroup-1", ip)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheckEdge(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      config(EdgeTypeGatewayServices, "", "", haInterface("10.0.0.1")),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("ha_interface: only supported on distributedRouter edges"),
+			},
+			resource.TestStep{
+				Config: config(EdgeTypeGatewayServices, "", "",
+					fmt.Sprintf(testAccCheckEdgeConf_routing, "10.0.0.1", false, false, "info")),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("routing_global_config: only supported on distributedRouter edges"),
+			},
+			resource.TestStep{
+				Config:      config(EdgeTypeDistributedRouter, mgmt, "", haInterface("10.0.0.1")),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("mgmt_interface is not supported on distributedRouter edges"),
+			},
+			resource.TestStep{
+				Config:      config(EdgeTypeDistributedRouter, "", peer, ""),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("control VMs needs an ha_interface"),
+			},
+			resource.TestStep{
+				Config:      config(EdgeTypeDistributedRouter, "", peer, haInterface("10.0.0.0")),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("ha_interface: .* is the network address"),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdge_FindInventoryObject(t *testing.T) {
+
+	objects := []inventoryObject{
+		{ObjectId: "domain-c7", ObjectTypeName: InventoryTypeCluster, Name: "cluster"},
+		{ObjectId: "resgroup-1", ObjectTypeName: InventoryTypeResourcePool, Name: "rp"},
+		{ObjectId: "datastore-1", ObjectTypeName: InventoryTypeDatastore, Name: "rp"},
+		{ObjectId: "datastore-2", ObjectTypeName: InventoryTypeDatastore, Name: "shared"},
+		{ObjectId: "datastore-3", ObjectTypeName: InventoryTypeDatastore, Name: "shared"},
+	}
+	resourcePoolTypes := []string{InventoryTypeResourcePool, InventoryTypeCluster}
+
+	cases := []struct {
+		types  []string
+		name   string
+		id     string
+		expErr string
+	}{
+		{types: resourcePoolTypes, name: "rp", id: "resgroup-1"},
+		{types: resourcePoolTypes, name: "cluster", id: "domain-c7"},
+		{types: []string{InventoryTypeDatastore}, name: "rp", id: "datastore-1"},
+		{types: []string{InventoryTypeDatastore}, name: "shared",
+			expErr: "is ambiguous, it matches datastore-2, datastore-3"},
+		{types: []string{InventoryTypeHost}, name: "rp",
+			expErr: "No HostSystem named 'rp' found"},
+	}
+
+	for _, c := range cases {
+		id, err := findInventoryObject(objects, c.types, c.name)
+		if c.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expErr) {
+				t.Fatalf("Lookup of %v '%s': expected error '%s', got '%v'",
+					c.types, c.name, c.expErr, err)
+			}
+			continue
+		}
+		if err != nil || id != c.id {
+			t.Fatalf("Lookup of %v '%s': expected '%s', got '%s', '%v'",
+				c.types, c.name, c.id, id, err)
+		}
+	}
+}
+
+func TestAccNsxEdge_CliSettings(t *testing.T) {
+
+	edgeName := "TFT_EDGE_CLI"
+	resourceName := "nsxv_edge." + edgeName
+
+	config := fmt.Sprintf(testAccCheckEdgeConf, edgeName, edgeName,
+		resourcePoolId, datastoreId,
+		fmt.Sprintf(testAccCheckEdgeConf_cliSettings, false, "Authorized access only"))
+	configUpdate := fmt.Sprintf(testAccCheckEdgeConf, edgeName, edgeName,
+		resourcePoolId, datastoreId,
+		fmt.Sprintf(testAccCheckEdgeConf_cliSettings, true, "Audited access only"))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckEdge(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEdgeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "fips_enabled", "true"),
+					resource.TestCheckResourceAttr(resourceName, "cli_settings.0.username", "admin"),
+					resource.TestCheckResourceAttr(resourceName, "cli_settings.0.remote_access", "false"),
+					resource.TestCheckResourceAttr(resourceName, "cli_settings.0.password_expiry", "99999"),
+					resource.TestCheckResourceAttr(resourceName, "cli_settings.0.banner", "Authorized access only"),
+				),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "cli_settings.0.remote_access", "true"),
+					resource.TestCheckResourceAttr(resourceName, "cli_settings.0.banner", "Audited access only"),
+					resource.TestCheckResourceAttr(resourceName, "cli_settings.0.password", "Tf-Acc-Passw0rd"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxEdge_Lifecycle(t *testing.T) {
+
+	m, client := testAccWaitClient(t)
+	m.StatusPolls = 1
+	m.edgeVersions[mockEdgeId] = "6.2.4"
+
+	d := schema.TestResourceDataRaw(t, resourceNsxEdge().Schema,
+		map[string]interface{}{
+			"type":                       EdgeTypeGatewayServices,
+			"name":                       "mock-esg",
+			"upgrade_to_manager_version": true,
+			"redeploy_trigger":           "1",
+			"appliances": []interface{}{
+				map[string]interface{}{
+					"size": EdgeApplianceSizeCompact,
+					"appliance": []interface{}{
+						map[string]interface{}{
+							"resource_pool_id": "resgroup-1",
+							"datastore_id":     "datastore-1",
+						},
+					},
+				},
+			},
+		})
+	d.Set("edge_id", mockEdgeId)
+
+	// An outdated edge is reported as not upgraded.
+	if err := resourceNsxEdgeRead(d, client); err != nil {
+		t.Fatalf("Edge read failed with error: %s", err)
+	}
+	if d.Get("upgrade_to_manager_version").(bool) ||
+		d.Get("appliance_version").(string) != "6.2.4" {
+		t.Fatalf("Outdated edge not detected: %#v", d.State())
+	}
+
+	d.Set("upgrade_to_manager_version", true)
+	if err := resourceNsxEdgeUpdate(d, client); err != nil {
+		t.Fatalf("Edge update failed with error: %s", err)
+	}
+	if v := d.Get("appliance_version").(string); v != mockManagerVersion {
+		t.Fatalf("Edge not upgraded, at version %s", v)
+	}
+	if !d.Get("upgrade_to_manager_version").(bool) {
+		t.Fatalf("Upgraded edge reported as outdated")
+	}
+	if m.redeploys[mockEdgeId] != 1 {
+		t.Fatalf("Expected 1 redeploy of the edge, got %d", m.redeploys[mockEdgeId])
+	}
+}
+
+// The DHCP resource updates the whole edge, the syslog and DNS client
+// settings of nsxv_edge must survive it.
+func TestAccNsxEdge_FeaturesKeptByDHCP(t *testing.T) {
+
+	m, client := newMockNsxClient(t)
+	m.edges[mockEdgeId].DnsClient = &edgeDnsClient{PrimaryDns: "10.10.1.53"}
+	m.edges[mockEdgeId].Features.Syslog = &edgeSyslog{Enabled: true,
+		Protocol: SyslogProtocolUDP, ServerAddresses: []string{"10.10.1.10"}}
+
+	d := schema.TestResourceDataRaw(t, resourceNsxEdgeDHCP().Schema,
+		map[string]interface{}{
+			"edge_id": mockEdgeId,
+			"logical_switch": []interface{}{
+				map[string]interface{}{
+					"id": mockLogicalSwitchId,
+					"subnet": []interface{}{
+						map[string]interface{}{
+							"cidr":          "10.10.10.0/24",
+							"auto_allocate": true,
+						},
+					},
+				},
+			},
+		})
+	if err := resourceNsxEdgeDHCPCreate(d, client); err != nil {
+		t.Fatalf("DHCP creation failed with error: %s", err)
+	}
+
+	edgeCfg, err := getEdge(client, mockEdgeId)
+	if err != nil {
+		t.Fatalf("Edge Get failed with error: %s", err)
+	}
+	if edgeCfg.DnsClient == nil || edgeCfg.DnsClient.PrimaryDns != "10.10.1.53" {
+		t.Fatalf("DNS client settings lost: %#v", edgeCfg.DnsClient)
+	}
+	if edgeCfg.Features.Syslog == nil || !edgeCfg.Features.Syslog.Enabled {
+		t.Fatalf("Syslog settings lost: %#v", edgeCfg.Features.Syslog)
+	}
+	if len(edgeCfg.Features.Dhcp.IPPools) != 1 {
+		t.Fatalf("DHCP IP pool not configured: %#v", edgeCfg.Features.Dhcp)
+	}
+}
+
+func TestAccNsxEdge_RoutingKept(t *testing.T) {
+
+	m, client := newMockNsxClient(t)
+	routing := &edgeRouting{}
+	if err := xml.Unmarshal([]byte(testEdgeRoutingXML), routing); err != nil {
+		t.Fatalf("Unable to parse routing: %s", err)
+	}
+	m.edges[mockDLREdgeId].Features.Routing = routing
+
+	checkRouting := func(step string, enabled bool, routerId string) {
+		raw := &rawXML{}
+		uri := fmt.Sprintf(EdgeRoutingUriFormat, client.MgrConfig.Uri, mockDLREdgeId)
+		if err := nsxGet(client, uri, raw); err != nil {
+			t.Fatalf("%s: routing Get failed with error: %s", step, err)
+		}
+		for _, s := range []string{
+			fmt.Sprintf("<enabled>%t</enabled>", enabled),
+			"<routerId>" + routerId + "</routerId>",
+			"<ipAddress>10.50.0.0/24</ipAddress>",
+			"<network>10.60.0.0/24</network>",
+			"<ospf><enabled>true</enabled></ospf>",
+		} {
+			if !strings.Contains(raw.InnerXML, s) {
+				t.Fatalf("%s: %s missing from routing %s", step, s, raw.InnerXML)
+			}
+		}
+	}
+
+	// The edge is sent back by the other resources, e.g. the DLR interfaces.
+	edgeCfg, err := getEdge(client, mockDLREdgeId)
+	if err != nil {
+		t.Fatalf("Edge Get failed with error: %s", err)
+	}
+	if err := updateEdge(client, edgeCfg); err != nil {
+		t.Fatalf("Edge Update failed with error: %s", err)
+	}
+	checkRouting("edge update", true, "10.40.0.10")
+
+	globalConfig := []interface{}{map[string]interface{}{
+		"router_id":   "10.40.0.11",
+		"ecmp":        true,
+		"log_enabled": false,
+		"log_level":   RoutingLogLevelDefault,
+	}}
+	if err := setEdgeRouting(client, mockDLREdgeId, expandEdgeRouting(globalConfig)); err != nil {
+		t.Fatalf("Routing update failed with error: %s", err)
+	}
+	checkRouting("global config update", true, "10.40.0.11")
+
+	if err := setEdgeRouting(client, mockDLREdgeId, nil); err != nil {
+		t.Fatalf("Routing disable failed with error: %s", err)
+	}
+	checkRouting("routing disabled", false, "10.40.0.11")
+
+	if err := setEdgeRouting(client, mockDLREdgeId, expandEdgeRouting(globalConfig)); err != nil {
+		t.Fatalf("Routing enable failed with error: %s", err)
+	}
+	checkRouting("routing enabled", true, "10.40.0.11")
+}
+
+func TestAccNsxEdge_ModifyEdgeVersionConflict(t *testing.T) {
+
+	m, client := newMockNsxClient(t)
+	syslog := &edgeSyslog{Enabled: true, Protocol: SyslogProtocolUDP,
+		ServerAddresses: []string{"10.10.1.10"}}
+
+	// An edge read before a change made through a feature API is stale.
+	staleCfg, err := getEdge(client, mockEdgeId)
+	if err != nil {
+		t.Fatalf("Edge Get failed with error: %s", err)
+	}
+	if err := updateEdgeSyslog(client, mockEdgeId, syslog); err != nil {
+		t.Fatalf("Syslog update failed with error: %s", err)
+	}
+	if err := updateEdge(client, staleCfg); !isVersionConflictError(err) {
+		t.Fatalf("Stale edge update returned %v, expected a version conflict", err)
+	}
+
+	calls := 0
+	edgeCfg, err := modifyEdge(client, mockEdgeId, func(edgeCfg *edgeConfig) error {
+		calls++
+		if calls == 1 {
+			if err := updateEdgeSyslog(client, mockEdgeId, syslog); err != nil {
+				t.Fatalf("Syslog update failed with error: %s", err)
+			}
+		}
+		edgeCfg.Description = "tf-acc-modified"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Edge modification failed with error: %s", err)
+	}
+	if calls != 2 {
+		t.Fatalf("Expected the edge to be changed twice, got %d", calls)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	edge := m.edges[mockEdgeId]
+	if edge.Description != "tf-acc-modified" || edge.Features.Syslog == nil ||
+		!edge.Features.Syslog.Enabled || edge.Version == edgeCfg.Version {
+		t.Fatalf("Unexpected edge: description '%s', syslog %#v, version %s",
+			edge.Description, edge.Features.Syslog, edge.Version)
+	}
+}
+
+func TestAccNsxEdge_ModifyEdgeAttempts(t *testing.T) {
+
+	_, client := newMockNsxClient(t)
+
+	calls := 0
+	_, err := modifyEdge(client, mockEdgeId, func(edgeCfg *edgeConfig) error {
+		calls++
+		edgeCfg.Version = "0"
+		return nil
+	})
+	if !isVersionConflictError(err) {
+		t.Fatalf("Edge modification returned %v, expected a version conflict", err)
+	}
+	if calls != EdgeUpdateAttempts {
+		t.Fatalf("Expected %d attempts, got %d", EdgeUpdateAttempts, calls)
+	}
+}
+
+func testAccCheckEdgeHaInterface(resourceName string, ip string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		client := testAccProvider.Meta().(*govnsx.Client)
+
+		edgeCfg, err := getEdge(client, rs.Primary.Attributes["edge_id"])
+		if err != nil {
+			return err
+		}
+
+		haInterface := edgeCfg.MgmtInterface
+		if haInterface == nil || haInterface.ConnectedToId != portgroupId ||
+			len(haInterface.AddressGroups) != 1 ||
+			haInterface.AddressGroups[0].PrimaryAddress != ip {
+			return fmt.Errorf("Unexpected HA interface %#v", haInterface)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckEdgeDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+	edge := nsxresource.NewEdge(client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_edge" {
+			continue
+		}
+
+		if _, err := edge.Get(rs.Primary.Attributes["edge_id"]); err == nil {
+			return fmt.Errorf("Edge %s still exists", rs.Primary.Attributes["edge_id"])
+		}
+	}
+
+	return nil
+}
+
+func testAccPreCheckEdge(t *testing.T) {
+
+	testAccPreCheck(t)
+
+	if resourcePoolId == "" || datastoreId == "" {
+		t.Fatal("NSX_RESOURCE_POOL_ID and NSX_DATASTORE_ID must be set for acceptance tests")
+	}
+}
+
+func testAccPreCheckEdgePlacementByName(t *testing.T) {
+
+	testAccPreCheck(t)
+
+	if resourcePoolName == "" || datastoreName == "" {
+		t.Fatal("NSX_RESOURCE_POOL_NAME and NSX_DATASTORE_NAME must be set for acceptance tests")
+	}
+}
+
+func testAccPreCheckEdgeDistributedRouter(t *testing.T) {
+
+	testAccPreCheckEdge(t)
+
+	if portgroupId == "" {
+		t.Fatal("NSX_PORTGROUP_ID must be set for acceptance tests")
+	}
+}
@@ -0,0 +1,376 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	IPPoolScopeUriFormat     = "%s/api/2.0/services/ipam/pools/scope/%s"
+	IPPoolUriFormat          = "%s/api/2.0/services/ipam/pools/%s"
+	IPPoolAddressesUriFormat = "%s/api/2.0/services/ipam/pools/%s/ipaddresses"
+	IPPoolAddressUriFormat   = "%s/api/2.0/services/ipam/pools/%s/ipaddresses/%s"
+
+	IPPoolMaxDnsServers = 2
+)
+
+type ipamIPRange struct {
+	Id           string `xml:"id,omitempty"`
+	StartAddress string `xml:"startAddress"`
+	EndAddress   string `xml:"endAddress"`
+}
+
+// ipamAddressPool is an IP pool of NSX Manager, the addresses of the
+// controllers, VTEPs and edges can be allocated from.
+type ipamAddressPool struct {
+	XMLName           xml.Name      `xml:"ipamAddressPool"`
+	ObjectId          string        `xml:"objectId,omitempty"`
+	Revision          int           `xml:"revision,omitempty"`
+	Name              string        `xml:"name"`
+	PrefixLength      int           `xml:"prefixLength"`
+	Gateway           string        `xml:"gateway,omitempty"`
+	DnsSuffix         string        `xml:"dnsSuffix,omitempty"`
+	DnsServer1        string        `xml:"dnsServer1,omitempty"`
+	DnsServer2        string        `xml:"dnsServer2,omitempty"`
+	IPRanges          []ipamIPRange `xml:"ipRanges>ipRangeDto"`
+	TotalAddressCount int           `xml:"totalAddressCount,omitempty"`
+	UsedAddressCount  int           `xml:"usedAddressCount,omitempty"`
+}
+
+func resourceIPPool() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceIPPoolCreate,
+		Read:   resourceIPPoolRead,
+		Update: resourceIPPoolUpdate,
+		Delete: resourceIPPoolDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"scope_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  DefaultScopeId,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"prefix_length": &schema.Schema{
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validatePrefixLength,
+			},
+
+			"gateway": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateIP,
+			},
+
+			"dns_suffix": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"dns_servers": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: IPPoolMaxDnsServers,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateIP,
+				},
+			},
+
+			"ip_ranges": &schema.Schema{
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateIPRangeValue,
+				},
+			},
+
+			"total_address_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"used_address_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceIPPoolCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	poolSpec, err := expandIPPool(d)
+	if err != nil {
+		log.Printf("[ERROR] Configuration validation failed.")
+		return err
+	}
+
+	scopeId := d.Get("scope_id").(string)
+	postUri := fmt.Sprintf(IPPoolScopeUriFormat, client.MgrConfig.Uri, scopeId)
+
+	_, body, err := nsxPost(client, postUri, poolSpec)
+	if err != nil {
+		log.Printf("[ERROR] IP Pool creation failed. %v", err)
+		return err
+	}
+
+	d.SetId(strings.TrimSpace(string(body)))
+	log.Printf("[INFO] IP Pool %s created with id: %s", poolSpec.Name, d.Id())
+
+	return resourceIPPoolRead(d, meta)
+}
+
+func resourceIPPoolRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	pool, err := getIPPool(client, d.Id())
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] IP Pool '%s' not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", pool.Name)
+	d.Set("prefix_length", pool.PrefixLength)
+	d.Set("gateway", pool.Gateway)
+	d.Set("dns_suffix", pool.DnsSuffix)
+
+	dnsServers := []string{}
+	for _, server := range []string{pool.DnsServer1, pool.DnsServer2} {
+		if server != "" {
+			dnsServers = append(dnsServers, server)
+		}
+	}
+	d.Set("dns_servers", dnsServers)
+
+	d.Set("ip_ranges", orderIPPoolRanges(d.Get("ip_ranges").([]interface{}), pool.IPRanges))
+
+	d.Set("total_address_count", pool.TotalAddressCount)
+	d.Set("used_address_count", pool.UsedAddressCount)
+
+	return nil
+}
+
+func resourceIPPoolUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	poolSpec, err := expandIPPool(d)
+	if err != nil {
+		log.Printf("[ERROR] Configuration validation failed.")
+		return err
+	}
+
+	// The PUT needs the current revision of the pool, and the ids of the
+	// ranges which are kept.
+	pool, err := getIPPool(client, d.Id())
+	if err != nil {
+		return err
+	}
+
+	poolSpec.ObjectId = pool.ObjectId
+	poolSpec.Revision = pool.Revision
+	for i, r := range poolSpec.IPRanges {
+		for _, curRange := range pool.IPRanges {
+			if net.ParseIP(r.StartAddress).Equal(net.ParseIP(curRange.StartAddress)) &&
+				net.ParseIP(r.EndAddress).Equal(net.ParseIP(curRange.EndAddress)) {
+				poolSpec.IPRanges[i].Id = curRange.Id
+				break
+			}
+		}
+	}
+
+	log.Printf("[INFO] Updating IP Pool %s : %#v", d.Id(), poolSpec)
+
+	putUri := fmt.Sprintf(IPPoolUriFormat, client.MgrConfig.Uri, d.Id())
+	if err := nsxPut(client, putUri, poolSpec); err != nil {
+		log.Printf("[ERROR] Updating IP Pool '%s' failed with error : '%v'", d.Id(), err)
+		return err
+	}
+
+	return resourceIPPoolRead(d, meta)
+}
+
+func resourceIPPoolDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	deleteUri := fmt.Sprintf(IPPoolUriFormat, client.MgrConfig.Uri, d.Id())
+	err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return nsxDelete(client, deleteUri)
+	})
+	if err != nil && !isNotFoundError(err) {
+		log.Printf("[ERROR] Deleting IP Pool '%s' failed with error : %v", d.Id(), err)
+		return err
+	}
+
+	log.Printf("[INFO] IP Pool deleted :%s", d.Id())
+	d.SetId("")
+	return nil
+}
+
+func getIPPool(client *govnsx.Client, poolId string) (*ipamAddressPool, error) {
+
+	getUri := fmt.Sprintf(IPPoolUriFormat, client.MgrConfig.Uri, poolId)
+
+	pool := &ipamAddressPool{}
+	if err := nsxGet(client, getUri, pool); err != nil {
+		log.Printf("[ERROR] Retriving IP Pool '%s' failed with error : '%v'", poolId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] IP Pool details of '%s': '%v'", poolId, pool)
+	return pool, nil
+}
+
+// expandIPPool builds the pool from the resource data. The ranges, the
+// gateway and the DNS servers must be of a single IP version, and the
+// ranges must not overlap and be in the subnet of the gateway.
+func expandIPPool(d *schema.ResourceData) (*ipamAddressPool, error) {
+
+	pool := &ipamAddressPool{
+		Name:         d.Get("name").(string),
+		PrefixLength: d.Get("prefix_length").(int),
+		Gateway:      d.Get("gateway").(string),
+		DnsSuffix:    d.Get("dns_suffix").(string),
+	}
+
+	ranges := []ipRange{}
+	for _, v := range d.Get("ip_ranges").([]interface{}) {
+		r, err := parseIPRange(v.(string))
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+
+	// The ranges are sent in the configured order, they are only sorted
+	// to be validated.
+	if _, err := validateAndSortIPRange(ranges); err != nil {
+		return nil, err
+	}
+
+	ipv4 := isIPv4(ranges[0].start)
+	for _, r := range ranges {
+		if isIPv4(r.start) != ipv4 {
+			return nil, fmt.Errorf("IP ranges '%s' and '%s' are not of the same IP version.",
+				getIPRangeString(ranges[0]), getIPRangeString(r))
+		}
+		pool.IPRanges = append(pool.IPRanges, ipamIPRange{
+			StartAddress: r.start.String(),
+			EndAddress:   r.end.String(),
+		})
+	}
+
+	bits := 8 * net.IPv6len
+	if ipv4 {
+		bits = 8 * net.IPv4len
+	}
+	if pool.PrefixLength > bits {
+		return nil, fmt.Errorf("Prefix length %d is not valid for the IP ranges, "+
+			"it must be between 1 and %d.", pool.PrefixLength, bits)
+	}
+
+	if pool.Gateway != "" {
+		gateway := net.ParseIP(pool.Gateway)
+		if isIPv4(gateway) != ipv4 {
+			return nil, fmt.Errorf("Gateway '%s' is not of the IP version of the IP ranges.",
+				pool.Gateway)
+		}
+
+		subnet := &net.IPNet{IP: gateway, Mask: net.CIDRMask(pool.PrefixLength, bits)}
+		subnet.IP = subnet.IP.Mask(subnet.Mask)
+		for _, r := range ranges {
+			if !subnet.Contains(r.start) || !subnet.Contains(r.end) {
+				return nil, fmt.Errorf("IP range '%s' is not in the subnet %s of the gateway.",
+					getIPRangeString(r), subnet)
+			}
+			if checkIPInRange(r, gateway) {
+				return nil, fmt.Errorf("Gateway '%s' is part of IP range '%s'.",
+					pool.Gateway, getIPRangeString(r))
+			}
+		}
+	}
+
+	dnsServers := d.Get("dns_servers").([]interface{})
+	if len(dnsServers) > 0 {
+		pool.DnsServer1 = dnsServers[0].(string)
+	}
+	if len(dnsServers) > 1 {
+		pool.DnsServer2 = dnsServers[1].(string)
+	}
+
+	return pool, nil
+}
+
+// orderIPPoolRanges returns the ranges of the pool in the order of the
+// configured ones, NSX does not keep the order they were sent in. The ranges
+// which are not configured come last, in the order of NSX.
+func orderIPPoolRanges(configured []interface{}, ranges []ipamIPRange) []string {
+
+	ordered := []string{}
+	done := make([]bool, len(ranges))
+
+	for _, v := range configured {
+		cfgRange, err := parseIPRange(v.(string))
+		if err != nil {
+			continue
+		}
+		for i, r := range ranges {
+			if !done[i] && cfgRange.start.Equal(net.ParseIP(r.StartAddress)) &&
+				cfgRange.end.Equal(net.ParseIP(r.EndAddress)) {
+				ordered = append(ordered, r.StartAddress+"-"+r.EndAddress)
+				done[i] = true
+				break
+			}
+		}
+	}
+
+	for i, r := range ranges {
+		if !done[i] {
+			ordered = append(ordered, r.StartAddress+"-"+r.EndAddress)
+		}
+	}
+
+	return ordered
+}
+
+func validatePrefixLength(v interface{}, k string) (ws []string, errors []error) {
+
+	prefixLength := v.(int)
+	if prefixLength < 1 || prefixLength > 8*net.IPv6len {
+		errors = append(errors, fmt.Errorf(
+			"%s: Prefix length %d is not valid, it must be between 1 and %d.",
+			k, prefixLength, 8*net.IPv6len))
+	}
+	return
+}
@@ -0,0 +1,242 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	IPAllocationModeAllocate = "ALLOCATE"
+	IPAllocationModeReserve  = "RESERVE"
+)
+
+type ipAddressRequest struct {
+	XMLName        xml.Name `xml:"ipAddressRequest"`
+	AllocationMode string   `xml:"allocationMode"`
+	IpAddress      string   `xml:"ipAddress,omitempty"`
+}
+
+// allocatedIPAddress is an address allocated from an IP pool, with the
+// network settings of the pool.
+type allocatedIPAddress struct {
+	Id           int    `xml:"id,omitempty"`
+	IpAddress    string `xml:"ipAddress"`
+	Gateway      string `xml:"gateway,omitempty"`
+	PrefixLength int    `xml:"prefixLength,omitempty"`
+	DnsServer1   string `xml:"dnsServer1,omitempty"`
+	DnsServer2   string `xml:"dnsServer2,omitempty"`
+	DnsSuffix    string `xml:"dnsSuffix,omitempty"`
+	SubnetId     string `xml:"subnetId,omitempty"`
+}
+
+type allocatedIPAddressList struct {
+	XMLName   xml.Name             `xml:"allocatedIpAddresses"`
+	Addresses []allocatedIPAddress `xml:"allocatedIpAddress"`
+}
+
+func resourceIPPoolAllocation() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceIPPoolAllocationCreate,
+		Read:   resourceIPPoolAllocationRead,
+		Delete: resourceIPPoolAllocationDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			// The next free address of the pool is allocated when no
+			// address is given.
+			"ip_address": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIP,
+			},
+
+			"gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"prefix_length": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"dns_suffix": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"dns_servers": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceIPPoolAllocationCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	poolId := d.Get("pool_id").(string)
+
+	request := &ipAddressRequest{AllocationMode: IPAllocationModeAllocate}
+	if v, ok := d.GetOk("ip_address"); ok {
+		request.AllocationMode = IPAllocationModeReserve
+		request.IpAddress = v.(string)
+	}
+
+	postUri := fmt.Sprintf(IPPoolAddressesUriFormat, client.MgrConfig.Uri, poolId)
+
+	// A reserved address still being released by a former allocation is
+	// reported as in use.
+	var body []byte
+	err := retryWhileInUse(d.Timeout(schema.TimeoutCreate), func() error {
+		var err error
+		_, body, err = nsxPost(client, postUri, request)
+		return err
+	})
+	if err != nil {
+		log.Printf("[ERROR] Allocating an IP address from IP Pool '%s' failed. %v",
+			poolId, err)
+		return err
+	}
+
+	allocated := &allocatedIPAddress{}
+	if err := xml.Unmarshal(body, allocated); err != nil {
+		return fmt.Errorf("Unable to read the IP address allocated from IP Pool '%s': %s",
+			poolId, err)
+	}
+
+	d.SetId(ipPoolAllocationId(poolId, allocated.IpAddress))
+	log.Printf("[INFO] IP address %s allocated from IP Pool %s", allocated.IpAddress, poolId)
+
+	return resourceIPPoolAllocationRead(d, meta)
+}
+
+func resourceIPPoolAllocationRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	poolId, ipAddress, err := parseIPPoolAllocationId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	allocated, err := getIPPoolAllocation(client, poolId, ipAddress)
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] IP Pool '%s' not found, removing from state", poolId)
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	if allocated == nil {
+		log.Printf("[WARN] IP address '%s' is not allocated from IP Pool '%s', removing from state",
+			ipAddress, poolId)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("pool_id", poolId)
+	d.Set("ip_address", allocated.IpAddress)
+	d.Set("gateway", allocated.Gateway)
+	d.Set("prefix_length", allocated.PrefixLength)
+	d.Set("dns_suffix", allocated.DnsSuffix)
+
+	dnsServers := []string{}
+	for _, server := range []string{allocated.DnsServer1, allocated.DnsServer2} {
+		if server != "" {
+			dnsServers = append(dnsServers, server)
+		}
+	}
+	d.Set("dns_servers", dnsServers)
+
+	return nil
+}
+
+func resourceIPPoolAllocationDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	poolId, ipAddress, err := parseIPPoolAllocationId(d.Id())
+	if err != nil {
+		return err
+	}
+
+	deleteUri := fmt.Sprintf(IPPoolAddressUriFormat, client.MgrConfig.Uri, poolId, ipAddress)
+	err = retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return nsxDelete(client, deleteUri)
+	})
+	if err != nil && !isNotFoundError(err) {
+		log.Printf("[ERROR] Releasing IP address '%s' of IP Pool '%s' failed with error : %v",
+			ipAddress, poolId, err)
+		return err
+	}
+
+	log.Printf("[INFO] IP address %s released to IP Pool %s", ipAddress, poolId)
+	d.SetId("")
+	return nil
+}
+
+// getIPPoolAllocation returns the allocation of ipAddress from the pool, or
+// nil when the address is not allocated.
+func getIPPoolAllocation(client *govnsx.Client, poolId string,
+	ipAddress string) (*allocatedIPAddress, error) {
+
+	getUri := fmt.Sprintf(IPPoolAddressesUriFormat, client.MgrConfig.Uri, poolId)
+
+	list := &allocatedIPAddressList{}
+	if err := nsxGet(client, getUri, list); err != nil {
+		log.Printf("[ERROR] Retriving the IP addresses allocated from IP Pool '%s' failed with error : '%v'",
+			poolId, err)
+		return nil, err
+	}
+
+	for i, allocated := range list.Addresses {
+		if net.ParseIP(allocated.IpAddress).Equal(net.ParseIP(ipAddress)) {
+			return &list.Addresses[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// The id of an allocation is <pool id>/<ip address>.
+func ipPoolAllocationId(poolId string, ipAddress string) string {
+	return poolId + "/" + ipAddress
+}
+
+func parseIPPoolAllocationId(id string) (string, string, error) {
+
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || net.ParseIP(parts[1]) == nil {
+		return "", "", fmt.Errorf("IP Pool allocation id '%s' is not valid, "+
+			"it must be <pool id>/<ip address>.", id)
+	}
+
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,317 @@
+package nsx
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const (
+	testAccCheckIPPoolConf = `
+resource "nsxv_ip_pool" "%s" {
+    name = "%s"
+    prefix_length = 24
+    gateway = "10.20.30.1"
+    dns_suffix = "example.com"
+    dns_servers = [%s]
+    ip_ranges = [%s]
+}
+`
+
+	testAccCheckIPPoolAllocationConf = `
+resource "nsxv_ip_pool" "pool" {
+    name = "TFT_IPPOOL_ALLOC"
+    prefix_length = 24
+    gateway = "10.20.30.1"
+    dns_servers = ["10.20.0.53"]
+    ip_ranges = ["10.20.30.10-10.20.30.20"]
+}
+
+resource "nsxv_ip_pool_allocation" "next" {
+    pool_id = "${nsxv_ip_pool.pool.id}"
+}
+
+resource "nsxv_ip_pool_allocation" "fixed" {
+    pool_id = "${nsxv_ip_pool.pool.id}"
+    ip_address = "10.20.30.15"
+}
+`
+)
+
+func TestAccNsxIPPool_ValidatorFunc(t *testing.T) {
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "prefix_length", validatorFn: validatePrefixLength,
+			values: []attributeProperty{
+				{value: 24, successCase: true},
+				{value: 64, successCase: true},
+				{value: 0, expErr: "is not valid"},
+				{value: 129, expErr: "is not valid"},
+			},
+		},
+		{name: "ip_ranges", validatorFn: validateIPRangeValue,
+			values: []attributeProperty{
+				{value: "10.20.30.10-10.20.30.20", successCase: true},
+				{value: "2001:db8::10-2001:db8::20", successCase: true},
+				{value: "10.20.30.10", expErr: "is not valid"},
+				{value: "10.20.30.20-10.20.30.10", expErr: "needs to be smaller than"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+func TestAccNsxIPPool_Expand(t *testing.T) {
+
+	cases := []struct {
+		prefixLength int
+		gateway      string
+		ranges       []interface{}
+		expErr       string
+	}{
+		{24, "10.20.30.1", []interface{}{"10.20.30.50-10.20.30.60", "10.20.30.10-10.20.30.20"}, ""},
+		{24, "", []interface{}{"10.20.30.10-10.20.30.20"}, ""},
+		{64, "2001:db8::1", []interface{}{"2001:db8::10-2001:db8::20"}, ""},
+		{24, "10.20.30.1", []interface{}{"10.20.30.10-10.20.30.20", "10.20.30.12-10.20.30.14"},
+			"Overlapping IP Ranges"},
+		{24, "10.20.30.1", []interface{}{"10.20.30.10-10.20.31.20"}, "is not in the subnet"},
+		{24, "10.20.30.15", []interface{}{"10.20.30.10-10.20.30.20"}, "is part of IP range"},
+		{24, "2001:db8::1", []interface{}{"10.20.30.10-10.20.30.20"}, "is not of the IP version"},
+		{24, "", []interface{}{"10.20.30.10-10.20.30.20", "2001:db8::10-2001:db8::20"},
+			"are not of the same IP version"},
+		{64, "", []interface{}{"10.20.30.10-10.20.30.20"}, "must be between 1 and 32"},
+	}
+
+	for _, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceIPPool().Schema, map[string]interface{}{
+			"name":          "pool",
+			"prefix_length": c.prefixLength,
+			"gateway":       c.gateway,
+			"ip_ranges":     c.ranges,
+		})
+
+		pool, err := expandIPPool(d)
+		if c.expErr == "" {
+			if err != nil {
+				t.Fatalf("%v: unexpected error %s", c.ranges, err)
+			}
+			if len(pool.IPRanges) != len(c.ranges) {
+				t.Fatalf("%v: expected %d ranges, got %v", c.ranges, len(c.ranges), pool.IPRanges)
+			}
+			for i, r := range pool.IPRanges {
+				if r.StartAddress+"-"+r.EndAddress != c.ranges[i] {
+					t.Fatalf("%v: ranges not in the configured order: %v", c.ranges, pool.IPRanges)
+				}
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.expErr) {
+			t.Fatalf("%v: expected error '%s', got '%v'", c.ranges, c.expErr, err)
+		}
+	}
+}
+
+func TestAccNsxIPPool_Basic(t *testing.T) {
+
+	poolName := "TFT_IPPOOL"
+	resourceName := "nsxv_ip_pool." + poolName
+
+	config := fmt.Sprintf(testAccCheckIPPoolConf, poolName, poolName,
+		`"10.20.0.53"`, `"10.20.30.10-10.20.30.20"`)
+	log.Printf("[DEBUG] template config= %s", config)
+
+	configUpdate := fmt.Sprintf(testAccCheckIPPoolConf, poolName, poolName+"_UPD",
+		`"10.20.0.53", "10.20.0.54"`, `"10.20.30.10-10.20.30.20", "10.20.30.100-10.20.30.109"`)
+	log.Printf("[DEBUG] template configUpdate= %s", configUpdate)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckIPPoolDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", poolName),
+					resource.TestCheckResourceAttr(resourceName, "ip_ranges.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "total_address_count", "11"),
+				),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", poolName+"_UPD"),
+					resource.TestCheckResourceAttr(resourceName, "dns_servers.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "ip_ranges.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "total_address_count", "21"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"scope_id"},
+			},
+		},
+	})
+}
+
+// NSX returns the ranges sorted, the configured order must not show a diff.
+func TestAccNsxIPPool_RangeOrder(t *testing.T) {
+
+	poolName := "TFT_IPPOOL_ORDER"
+	resourceName := "nsxv_ip_pool." + poolName
+
+	config := fmt.Sprintf(testAccCheckIPPoolConf, poolName, poolName, `"10.20.0.53"`,
+		`"10.20.30.100-10.20.30.109", "10.20.30.10-10.20.30.20"`)
+	configUpdate := fmt.Sprintf(testAccCheckIPPoolConf, poolName, poolName, `"10.20.0.53"`,
+		`"10.20.30.100-10.20.30.109", "10.20.30.50-10.20.30.60", "10.20.30.10-10.20.30.20"`)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckIPPoolDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "ip_ranges.0",
+						"10.20.30.100-10.20.30.109"),
+					resource.TestCheckResourceAttr(resourceName, "ip_ranges.1",
+						"10.20.30.10-10.20.30.20"),
+				),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "ip_ranges.#", "3"),
+					resource.TestCheckResourceAttr(resourceName, "ip_ranges.1",
+						"10.20.30.50-10.20.30.60"),
+					resource.TestCheckResourceAttr(resourceName, "total_address_count", "32"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxIPPool_OrderRanges(t *testing.T) {
+
+	ranges := []ipamIPRange{
+		{StartAddress: "10.20.30.10", EndAddress: "10.20.30.20"},
+		{StartAddress: "10.20.30.50", EndAddress: "10.20.30.60"},
+		{StartAddress: "10.20.30.100", EndAddress: "10.20.30.109"},
+	}
+
+	cases := []struct {
+		configured []interface{}
+		expected   []string
+	}{
+		{nil, []string{"10.20.30.10-10.20.30.20", "10.20.30.50-10.20.30.60",
+			"10.20.30.100-10.20.30.109"}},
+		{[]interface{}{"10.20.30.100-10.20.30.109", "10.20.30.10-10.20.30.20"},
+			[]string{"10.20.30.100-10.20.30.109", "10.20.30.10-10.20.30.20",
+				"10.20.30.50-10.20.30.60"}},
+		{[]interface{}{"10.20.30.1-10.20.30.5", "10.20.30.50-10.20.30.60"},
+			[]string{"10.20.30.50-10.20.30.60", "10.20.30.10-10.20.30.20",
+				"10.20.30.100-10.20.30.109"}},
+	}
+
+	for _, c := range cases {
+		ordered := orderIPPoolRanges(c.configured, ranges)
+		if strings.Join(ordered, ",") != strings.Join(c.expected, ",") {
+			t.Fatalf("%v: expected %v, got %v", c.configured, c.expected, ordered)
+		}
+	}
+}
+
+func TestAccNsxIPPoolAllocation_Basic(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckIPPoolDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCheckIPPoolAllocationConf,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"nsxv_ip_pool_allocation.fixed", "ip_address", "10.20.30.15"),
+					resource.TestCheckResourceAttrSet(
+						"nsxv_ip_pool_allocation.next", "ip_address"),
+					resource.TestCheckResourceAttr(
+						"nsxv_ip_pool_allocation.next", "gateway", "10.20.30.1"),
+					resource.TestCheckResourceAttr(
+						"nsxv_ip_pool_allocation.next", "prefix_length", "24"),
+					resource.TestCheckResourceAttr(
+						"nsxv_ip_pool_allocation.next", "dns_servers.0", "10.20.0.53"),
+					resource.TestCheckResourceAttr(
+						"nsxv_ip_pool.pool", "used_address_count", "0"),
+				),
+			},
+			resource.TestStep{
+				Config: testAccCheckIPPoolAllocationConf,
+				Check: resource.TestCheckResourceAttr(
+					"nsxv_ip_pool.pool", "used_address_count", "2"),
+			},
+			resource.TestStep{
+				ResourceName:      "nsxv_ip_pool_allocation.fixed",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccNsxIPPoolAllocation_ParseId(t *testing.T) {
+
+	cases := []struct {
+		id, poolId, ip string
+	}{
+		{"ipaddresspool-1/10.20.30.15", "ipaddresspool-1", "10.20.30.15"},
+		{"ipaddresspool-1/2001:db8::15", "ipaddresspool-1", "2001:db8::15"},
+		{"ipaddresspool-1", "", ""},
+		{"/10.20.30.15", "", ""},
+		{"ipaddresspool-1/10.20.30", "", ""},
+	}
+
+	for _, c := range cases {
+		poolId, ip, err := parseIPPoolAllocationId(c.id)
+		if c.poolId == "" {
+			if err == nil {
+				t.Fatalf("IP Pool allocation id '%s' is VALID", c.id)
+			}
+			continue
+		}
+		if err != nil || poolId != c.poolId || ip != c.ip ||
+			ipPoolAllocationId(poolId, ip) != c.id {
+			t.Fatalf("IP Pool allocation id '%s': got '%s', '%s', '%v'", c.id, poolId, ip, err)
+		}
+	}
+}
+
+func testAccCheckIPPoolDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_ip_pool" {
+			continue
+		}
+
+		_, err := getIPPool(client, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("IP Pool %s still exists", rs.Primary.ID)
+		}
+		if !isNotFoundError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
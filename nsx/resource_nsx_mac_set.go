@@ -0,0 +1,222 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	DefaultScopeId = "globalroot-0"
+
+	MacSetScopeUriFormat = "%s/api/2.0/services/macset/%s"
+	MacSetUriLocFormat   = "%s/api/2.0/services/macset/%s"
+	MacSetDelUriFormat   = "%s/api/2.0/services/macset/%s?force=false"
+)
+
+type macSet struct {
+	XMLName     xml.Name `xml:"macset"`
+	ObjectId    string   `xml:"objectId,omitempty"`
+	Revision    int      `xml:"revision,omitempty"`
+	ScopeId     string   `xml:"scope>id,omitempty"`
+	Name        string   `xml:"name"`
+	Description string   `xml:"description,omitempty"`
+	Value       string   `xml:"value"`
+}
+
+func resourceMacSet() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceMacSetCreate,
+		Read:   resourceMacSetRead,
+		Update: resourceMacSetUpdate,
+		Delete: resourceMacSetDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		CustomizeDiff: checkUniversalChange,
+
+		Schema: map[string]*schema.Schema{
+			"scope_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  DefaultScopeId,
+				ForceNew: true,
+			},
+
+			// A universal MAC Set is created in the universal scope,
+			// scope_id is ignored.
+			"universal": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"mac_addresses": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateMacAddress,
+				},
+			},
+		},
+	}
+}
+
+func resourceMacSetCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := nsxClient(meta, d.Get("universal").(bool))
+
+	macSetSpec := &macSet{
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		Value:       strings.Join(getMacAddresses(d), ","),
+	}
+
+	scopeId := d.Get("scope_id").(string)
+	if d.Get("universal").(bool) {
+		scopeId = UniversalScopeId
+	}
+	postUri := fmt.Sprintf(MacSetScopeUriFormat, client.MgrConfig.Uri, scopeId)
+
+	_, body, err := nsxPost(client, postUri, macSetSpec)
+	if err != nil {
+		log.Printf("[ERROR] MAC Set creation failed. %v", err)
+		return err
+	}
+
+	d.SetId(strings.TrimSpace(string(body)))
+	log.Printf("[INFO] MAC Set %s created with id: %s", macSetSpec.Name, d.Id())
+
+	return resourceMacSetRead(d, meta)
+}
+
+func resourceMacSetRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := nsxClient(meta, d.Get("universal").(bool))
+
+	macSetCfg, err := getMacSet(client, d.Id())
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] MAC Set '%s' not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", macSetCfg.Name)
+	d.Set("description", macSetCfg.Description)
+	d.Set("universal", macSetCfg.ScopeId == UniversalScopeId)
+	if macSetCfg.ScopeId != "" && macSetCfg.ScopeId != UniversalScopeId {
+		d.Set("scope_id", macSetCfg.ScopeId)
+	}
+
+	macAddresses := []string{}
+	for _, mac := range strings.Split(macSetCfg.Value, ",") {
+		if mac = strings.TrimSpace(mac); mac != "" {
+			macAddresses = append(macAddresses, mac)
+		}
+	}
+	d.Set("mac_addresses", macAddresses)
+
+	return nil
+}
+
+func resourceMacSetUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	client := nsxClient(meta, d.Get("universal").(bool))
+
+	// The PUT needs the current revision of the object.
+	macSetCfg, err := getMacSet(client, d.Id())
+	if err != nil {
+		return err
+	}
+
+	if d.HasChange("name") {
+		_, v := d.GetChange("name")
+		macSetCfg.Name = v.(string)
+		log.Printf("[INFO] Updating MAC Set %s : name: %s", d.Id(), v)
+	}
+	if d.HasChange("description") {
+		_, v := d.GetChange("description")
+		macSetCfg.Description = v.(string)
+		log.Printf("[INFO] Updating MAC Set %s : description: %s", d.Id(), v)
+	}
+	if d.HasChange("mac_addresses") {
+		macSetCfg.Value = strings.Join(getMacAddresses(d), ",")
+		log.Printf("[INFO] Updating MAC Set %s : mac_addresses: %s", d.Id(),
+			macSetCfg.Value)
+	}
+
+	putUri := fmt.Sprintf(MacSetUriLocFormat, client.MgrConfig.Uri, d.Id())
+	if err := nsxPut(client, putUri, macSetCfg); err != nil {
+		log.Printf("[ERROR] Updating MAC Set '%s' failed with error : '%v'", d.Id(), err)
+		return err
+	}
+
+	return resourceMacSetRead(d, meta)
+}
+
+func resourceMacSetDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := nsxClient(meta, d.Get("universal").(bool))
+
+	deleteUri := fmt.Sprintf(MacSetDelUriFormat, client.MgrConfig.Uri, d.Id())
+	err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return nsxDelete(client, deleteUri)
+	})
+	if err != nil && !isNotFoundError(err) {
+		log.Printf("[ERROR] Deleting MAC Set '%s' failed with error : %v", d.Id(), err)
+		return err
+	}
+
+	log.Printf("[INFO] MAC Set deleted :%s", d.Id())
+	d.SetId("")
+	return nil
+}
+
+func getMacSet(client *govnsx.Client, macSetId string) (*macSet, error) {
+
+	getUri := fmt.Sprintf(MacSetUriLocFormat, client.MgrConfig.Uri, macSetId)
+
+	macSetCfg := &macSet{}
+	if err := nsxGet(client, getUri, macSetCfg); err != nil {
+		log.Printf("[ERROR] Retriving MAC Set '%s' failed with error : '%v'", macSetId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] MAC Set details of '%s': '%v'", macSetId, macSetCfg)
+	return macSetCfg, nil
+}
+
+func getMacAddresses(d *schema.ResourceData) []string {
+
+	macAddresses := []string{}
+	if v, ok := d.GetOk("mac_addresses"); ok {
+		for _, mac := range v.(*schema.Set).List() {
+			macAddresses = append(macAddresses, mac.(string))
+		}
+	}
+	return macAddresses
+}
@@ -0,0 +1,108 @@
+package nsx
+
+import (
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const (
+	macAddr1 = "00:50:56:aa:bb:01"
+	macAddr2 = "00:50:56:aa:bb:02"
+
+	testAccCheckMacSetConf = `
+resource "nsxv_mac_set" "%s" {
+    name = "%s"
+    description = "Created by Terraform acceptance test"
+    mac_addresses = [%s]
+}
+`
+)
+
+func TestAccNsxMacSet_ValidatorFunc(t *testing.T) {
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "mac_addresses", validatorFn: validateMacAddress,
+			values: []attributeProperty{
+				{value: "00:50:56:aa:bb:01", successCase: true},
+				{value: "00:50:56:AA:BB:01", successCase: true},
+				{value: "00-50-56-aa-bb-01", expErr: "is not valid"},
+				{value: "00:50:56:aa:bb", expErr: "is not valid"},
+				{value: "00:50:56:aa:bb:zz", expErr: "is not valid"},
+				{value: "02:00:5e:10:00:00:00:01", expErr: "is not valid"},
+				{value: "1.2.3.4", expErr: "is not valid"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+func TestAccNsxMacSet_Basic(t *testing.T) {
+
+	macSetName := "TFT_MACSET"
+	resourceName := "nsxv_mac_set." + macSetName
+
+	config := fmt.Sprintf(testAccCheckMacSetConf, macSetName, macSetName,
+		fmt.Sprintf("%q", macAddr1))
+	log.Printf("[DEBUG] template config= %s", config)
+
+	configUpdate := fmt.Sprintf(testAccCheckMacSetConf, macSetName, macSetName+"_UPD",
+		fmt.Sprintf("%q, %q", macAddr1, macAddr2))
+	log.Printf("[DEBUG] template configUpdate= %s", configUpdate)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckMacSetDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						resourceName, "name", macSetName),
+					resource.TestCheckResourceAttr(
+						resourceName, "mac_addresses.#", "1"),
+				),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						resourceName, "name", macSetName+"_UPD"),
+					resource.TestCheckResourceAttr(
+						resourceName, "mac_addresses.#", "2"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckMacSetDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_mac_set" {
+			continue
+		}
+
+		_, err := getMacSet(client, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("MAC Set %s still exists", rs.Primary.ID)
+		}
+		if !isNotFoundError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	MulticastRangesUriFormat = "%s/api/2.0/vdn/config/multicasts"
+	MulticastRangeUriFormat  = "%s/api/2.0/vdn/config/multicasts/%s"
+)
+
+// multicastRange is a range of multicast addresses, the logical switches
+// in hybrid or multicast control plane mode take their group from it.
+type multicastRange struct {
+	XMLName xml.Name `xml:"multicastRange"`
+	Id      string   `xml:"id,omitempty"`
+	Name    string   `xml:"name"`
+	Desc    string   `xml:"desc,omitempty"`
+	Begin   string   `xml:"begin"`
+	End     string   `xml:"end"`
+}
+
+type multicastRangeList struct {
+	XMLName xml.Name         `xml:"multicastRanges"`
+	Ranges  []multicastRange `xml:"multicastRange"`
+}
+
+func resourceMulticastRange() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceMulticastRangeCreate,
+		Read:   resourceMulticastRangeRead,
+		Update: resourceMulticastRangeUpdate,
+		Delete: resourceMulticastRangeDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"begin": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateMulticastAddress,
+			},
+
+			"end": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateMulticastAddress,
+			},
+		},
+	}
+}
+
+func resourceMulticastRangeCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	rangeSpec := &multicastRange{
+		Name:  d.Get("name").(string),
+		Desc:  d.Get("description").(string),
+		Begin: d.Get("begin").(string),
+		End:   d.Get("end").(string),
+	}
+
+	if err := validateMulticastRange(client, rangeSpec); err != nil {
+		log.Printf("[ERROR] Configuration validation failed.")
+		return err
+	}
+
+	postUri := fmt.Sprintf(MulticastRangesUriFormat, client.MgrConfig.Uri)
+
+	_, body, err := nsxPost(client, postUri, rangeSpec)
+	if err != nil {
+		log.Printf("[ERROR] Multicast Range creation failed. %v", err)
+		return err
+	}
+
+	d.SetId(strings.TrimSpace(string(body)))
+	log.Printf("[INFO] Multicast Range %s created with id: %s", rangeSpec.Name, d.Id())
+
+	return resourceMulticastRangeRead(d, meta)
+}
+
+func resourceMulticastRangeRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	mcastRange, err := getMulticastRange(client, d.Id())
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Multicast Range '%s' not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", mcastRange.Name)
+	d.Set("description", mcastRange.Desc)
+	d.Set("begin", mcastRange.Begin)
+	d.Set("end", mcastRange.End)
+
+	return nil
+}
+
+func resourceMulticastRangeUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	rangeSpec := &multicastRange{
+		Id:    d.Id(),
+		Name:  d.Get("name").(string),
+		Desc:  d.Get("description").(string),
+		Begin: d.Get("begin").(string),
+		End:   d.Get("end").(string),
+	}
+
+	if err := validateMulticastRange(client, rangeSpec); err != nil {
+		log.Printf("[ERROR] Configuration validation failed.")
+		return err
+	}
+
+	log.Printf("[INFO] Updating Multicast Range %s : %#v", d.Id(), rangeSpec)
+
+	putUri := fmt.Sprintf(MulticastRangeUriFormat, client.MgrConfig.Uri, d.Id())
+	if err := nsxPut(client, putUri, rangeSpec); err != nil {
+		log.Printf("[ERROR] Updating Multicast Range '%s' failed with error : '%v'", d.Id(), err)
+		return err
+	}
+
+	return resourceMulticastRangeRead(d, meta)
+}
+
+func resourceMulticastRangeDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	deleteUri := fmt.Sprintf(MulticastRangeUriFormat, client.MgrConfig.Uri, d.Id())
+	err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return nsxDelete(client, deleteUri)
+	})
+	if err != nil && !isNotFoundError(err) {
+		log.Printf("[ERROR] Deleting Multicast Range '%s' failed with error : %v", d.Id(), err)
+		return err
+	}
+
+	log.Printf("[INFO] Multicast Range deleted :%s", d.Id())
+	d.SetId("")
+	return nil
+}
+
+func getMulticastRange(client *govnsx.Client, rangeId string) (*multicastRange, error) {
+
+	getUri := fmt.Sprintf(MulticastRangeUriFormat, client.MgrConfig.Uri, rangeId)
+
+	mcastRange := &multicastRange{}
+	if err := nsxGet(client, getUri, mcastRange); err != nil {
+		log.Printf("[ERROR] Retriving Multicast Range '%s' failed with error : '%v'",
+			rangeId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] Multicast Range details of '%s': '%v'", rangeId, mcastRange)
+	return mcastRange, nil
+}
+
+// validateMulticastRange checks that the range is valid and does not
+// overlap the other multicast ranges of NSX Manager.
+func validateMulticastRange(client *govnsx.Client, mcastRange *multicastRange) error {
+
+	getUri := fmt.Sprintf(MulticastRangesUriFormat, client.MgrConfig.Uri)
+
+	list := &multicastRangeList{}
+	if err := nsxGet(client, getUri, list); err != nil {
+		log.Printf("[ERROR] Retriving Multicast Ranges failed with error : '%v'", err)
+		return err
+	}
+
+	ranges := []multicastRange{*mcastRange}
+	for _, r := range list.Ranges {
+		if r.Id != mcastRange.Id {
+			ranges = append(ranges, r)
+		}
+	}
+
+	return checkMulticastRangesOverlap(ranges)
+}
+
+func checkMulticastRangesOverlap(ranges []multicastRange) error {
+
+	ipRanges := []ipRange{}
+	for _, r := range ranges {
+		ipRanges = append(ipRanges, ipRange{net.ParseIP(r.Begin), net.ParseIP(r.End)})
+	}
+
+	if compareIP(ipRanges[0].start, ipRanges[0].end) > 0 {
+		return fmt.Errorf("Multicast Range begin '%s' needs to be smaller than its end '%s'.",
+			ranges[0].Begin, ranges[0].End)
+	}
+
+	if _, err := validateAndSortIPRange(ipRanges); err != nil {
+		return fmt.Errorf("Multicast Range '%s' overlaps another Multicast Range: %s",
+			ranges[0].Name, err)
+	}
+
+	return nil
+}
+
+func validateMulticastAddress(v interface{}, k string) (ws []string, errors []error) {
+
+	address := v.(string)
+
+	ip := net.ParseIP(address)
+	if ip == nil || !isIPv4(ip) || !ip.IsMulticast() {
+		errors = append(errors, fmt.Errorf(
+			"%s: '%s' is not valid, it must be an IPv4 multicast address.", k, address))
+	}
+	return
+}
@@ -0,0 +1,132 @@
+package nsx
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const (
+	testAccCheckMulticastRangeConf = `
+resource "nsxv_multicast_range" "%s" {
+    name = "%s"
+    begin = "%s"
+    end = "%s"
+}
+`
+)
+
+func TestAccNsxMulticastRange_ValidatorFunc(t *testing.T) {
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "begin", validatorFn: validateMulticastAddress,
+			values: []attributeProperty{
+				{value: "239.1.0.0", successCase: true},
+				{value: "224.0.1.1", successCase: true},
+				{value: "10.0.0.1", expErr: "is not valid"},
+				{value: "ff02::1", expErr: "is not valid"},
+				{value: "239.1.0", expErr: "is not valid"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+func TestAccNsxMulticastRange_Overlap(t *testing.T) {
+
+	cases := []struct {
+		ranges []multicastRange
+		expErr string
+	}{
+		{[]multicastRange{{Name: "a", Begin: "239.1.0.0", End: "239.1.0.255"},
+			{Name: "b", Begin: "239.2.0.0", End: "239.2.0.255"}}, ""},
+		{[]multicastRange{{Name: "a", Begin: "239.1.0.0", End: "239.1.0.0"}}, ""},
+		{[]multicastRange{{Name: "a", Begin: "239.1.0.255", End: "239.1.0.0"}},
+			"needs to be smaller than its end"},
+		{[]multicastRange{{Name: "a", Begin: "239.1.0.10", End: "239.1.0.20"},
+			{Name: "b", Begin: "239.1.0.0", End: "239.1.0.255"}},
+			"Multicast Range 'a' overlaps another Multicast Range"},
+	}
+
+	for _, c := range cases {
+		err := checkMulticastRangesOverlap(c.ranges)
+		if c.expErr == "" {
+			if err != nil {
+				t.Fatalf("%v: unexpected error %s", c.ranges, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.expErr) {
+			t.Fatalf("%v: expected error '%s', got '%v'", c.ranges, c.expErr, err)
+		}
+	}
+}
+
+func TestAccNsxMulticastRange_Basic(t *testing.T) {
+
+	rangeName := "TFT_MCAST_RANGE"
+	resourceName := "nsxv_multicast_range." + rangeName
+
+	config := fmt.Sprintf(testAccCheckMulticastRangeConf, rangeName, rangeName,
+		"239.1.0.0", "239.1.0.255")
+	log.Printf("[DEBUG] template config= %s", config)
+
+	configUpdate := fmt.Sprintf(testAccCheckMulticastRangeConf, rangeName, rangeName,
+		"239.1.0.0", "239.1.1.255")
+	log.Printf("[DEBUG] template configUpdate= %s", configUpdate)
+
+	configOverlap := configUpdate + fmt.Sprintf(testAccCheckMulticastRangeConf, "overlap",
+		"overlap", "239.1.1.0", "239.1.2.255")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckMulticastRangeDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check:  resource.TestCheckResourceAttr(resourceName, "end", "239.1.0.255"),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check:  resource.TestCheckResourceAttr(resourceName, "end", "239.1.1.255"),
+			},
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			resource.TestStep{
+				Config:      configOverlap,
+				ExpectError: regexp.MustCompile("overlaps another Multicast Range"),
+			},
+		},
+	})
+}
+
+func testAccCheckMulticastRangeDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_multicast_range" {
+			continue
+		}
+
+		_, err := getMulticastRange(client, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Multicast Range %s still exists", rs.Primary.ID)
+		}
+		if !isNotFoundError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
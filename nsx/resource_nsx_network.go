@@ -1,8 +1,11 @@
 package nsx
 
 import (
+	"encoding/xml"
 	"fmt"
 	"log"
+	"path"
+	"time"
 
 	"github.com/IBM-tfproviders/govnsx"
 	"github.com/IBM-tfproviders/govnsx/nsxresource"
@@ -10,12 +13,53 @@ import (
 	"github.com/hashicorp/terraform/helper/schema"
 )
 
+const (
+	NetworkFeaturesUriFormat     = "%s/api/2.0/xvs/networks/%s/features"
+	HwGatewayBindingsUriFormat   = "%s/api/2.0/vdn/virtualwires/%s/hardwaregateways"
+	HwGatewayBindingUriLocFormat = "%s/api/2.0/vdn/virtualwires/%s/hardwaregateways/%s"
+)
+
+type ipDiscoveryConfig struct {
+	Enabled      bool `xml:"enabled"`
+	ArpSnooping  bool `xml:"arpSnoopingEnabled"`
+	DhcpSnooping bool `xml:"dhcpSnoopingEnabled"`
+	VmTools      bool `xml:"vmToolsEnabled"`
+}
+
+type macLearningConfig struct {
+	Enabled bool `xml:"enabled"`
+}
+
+type networkFeatureConfig struct {
+	XMLName           xml.Name          `xml:"networkFeatureConfig"`
+	IPDiscoveryConfig ipDiscoveryConfig `xml:"ipDiscoveryConfig"`
+	MacLearningConfig macLearningConfig `xml:"macLearningConfig"`
+}
+
+type hwGatewayBinding struct {
+	XMLName           xml.Name `xml:"hardwareGatewayBinding"`
+	Id                string   `xml:"id,omitempty"`
+	HardwareGatewayId string   `xml:"hardwareGatewayId"`
+	SwitchName        string   `xml:"switchName"`
+	PortName          string   `xml:"portName"`
+	Vlan              int      `xml:"vlan"`
+}
+
+type hwGatewayBindings struct {
+	Bindings []hwGatewayBinding `xml:"hardwareGatewayBinding"`
+}
+
 func resourceLogicalSwitch() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceLogicalSwitchCreate,
 		Read:   resourceLogicalSwitchRead,
 		Update: resourceLogicalSwitchUpdate,
 		Delete: resourceLogicalSwitchDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		CustomizeDiff: checkUniversalChange,
 
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
@@ -30,6 +74,15 @@ func resourceLogicalSwitch() *schema.Resource {
 				ForceNew: true,
 			},
 
+			// A universal logical switch lives in the universal transport
+			// zone.
+			"universal": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
 			"description": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
@@ -42,9 +95,11 @@ func resourceLogicalSwitch() *schema.Resource {
 				ForceNew: false,
 			},
 
+			// Inherited from the transport zone when not set.
 			"control_plane_mode": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
+				Computed: true,
 				ForceNew: false,
 			},
 
@@ -55,6 +110,67 @@ func resourceLogicalSwitch() *schema.Resource {
 				ForceNew: true,
 			},
 
+			"mac_learning_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"ip_discovery": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"arp_snooping": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+						"dhcp_snooping": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+						"vmtools": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+					},
+				},
+			},
+
+			"hardware_gateway_binding": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"hardware_gateway_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"switch_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"port_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"vlan": &schema.Schema{
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validateVlanId,
+						},
+						"id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
 			"network_label": &schema.Schema{
 				Type:     schema.TypeString,
 				Computed: true,
@@ -70,13 +186,23 @@ func resourceLogicalSwitch() *schema.Resource {
 
 func resourceLogicalSwitchCreate(d *schema.ResourceData, meta interface{}) error {
 
-	nsxclient := meta.(*govnsx.Client)
+	nsxclient := nsxClient(meta, d.Get("universal").(bool))
 
 	netobj := nsxresource.NewNetwork(nsxclient)
 	vWspec := NewVWCreateSpec(d)
 
 	scopeId := d.Get("scope_id").(string)
 
+	if d.Get("universal").(bool) {
+		scope, err := getTransportZone(nsxclient, scopeId)
+		if err != nil {
+			return err
+		}
+		if !scope.IsUniversal {
+			return fmt.Errorf("scope_id: Transport Zone '%s' is not universal", scopeId)
+		}
+	}
+
 	vWpostresp, err := netobj.Post(vWspec, scopeId)
 
 	if err != nil {
@@ -87,11 +213,26 @@ func resourceLogicalSwitchCreate(d *schema.ResourceData, meta interface{}) error
 	log.Printf("[INFO] Logical Switch %s created at:%s", vWspec.Name,
 		vWpostresp.Location)
 
+	vwireId := vWpostresp.VirtualWireOID
+	_, ipDiscoverySet := d.GetOk("ip_discovery")
+	if d.Get("mac_learning_enabled").(bool) || ipDiscoverySet {
+		if err := updateNetworkFeatures(nsxclient, vwireId, d); err != nil {
+			return err
+		}
+	}
+
+	for _, raw := range d.Get("hardware_gateway_binding").([]interface{}) {
+		if err := addHwGatewayBinding(nsxclient, vwireId,
+			expandHwGatewayBinding(raw.(map[string]interface{}))); err != nil {
+			return err
+		}
+	}
+
 	return resourceLogicalSwitchRead(d, meta)
 }
 
 func resourceLogicalSwitchRead(d *schema.ResourceData, meta interface{}) error {
-	nsxclient := meta.(*govnsx.Client)
+	nsxclient := nsxClient(meta, d.Get("universal").(bool))
 
 	netobj := nsxresource.NewNetwork(nsxclient)
 	location := d.Id()
@@ -110,11 +251,58 @@ func resourceLogicalSwitchRead(d *schema.ResourceData, meta interface{}) error {
 		log.Printf("[INFO] Logical Switch portgroup name set to:%s", net_label)
 	}
 
+	d.Set("virtual_wire_id", vwire.ObjectId)
+	d.Set("name", vwire.Name)
+	d.Set("description", vwire.Description)
+	d.Set("tenant_id", vwire.TenantId)
+	d.Set("control_plane_mode", vwire.ControlPlaneMode)
+	d.Set("guest_vlan_allowed", vwire.GuestVlanAllowed)
+
+	features, err := getNetworkFeatures(nsxclient, vwire.ObjectId)
+	if err != nil {
+		return err
+	}
+
+	d.Set("mac_learning_enabled", features.MacLearningConfig.Enabled)
+
+	// Keep an ip_discovery block with every flag turned off in state as well,
+	// otherwise it would show up as a change on every plan.
+	ipDiscovery := make([]map[string]interface{}, 0)
+	if features.IPDiscoveryConfig.Enabled || len(d.Get("ip_discovery").([]interface{})) > 0 {
+		ipDiscovery = append(ipDiscovery, map[string]interface{}{
+			"arp_snooping":  features.IPDiscoveryConfig.ArpSnooping,
+			"dhcp_snooping": features.IPDiscoveryConfig.DhcpSnooping,
+			"vmtools":       features.IPDiscoveryConfig.VmTools,
+		})
+	}
+	if err := d.Set("ip_discovery", ipDiscovery); err != nil {
+		return fmt.Errorf("Invalid ip discovery settings to set: %#v", ipDiscovery)
+	}
+
+	bindings, err := getHwGatewayBindings(nsxclient, vwire.ObjectId)
+	if err != nil {
+		return err
+	}
+
+	hwBindings := make([]map[string]interface{}, 0)
+	for _, binding := range bindings {
+		hwBindings = append(hwBindings, map[string]interface{}{
+			"hardware_gateway_id": binding.HardwareGatewayId,
+			"switch_name":         binding.SwitchName,
+			"port_name":           binding.PortName,
+			"vlan":                binding.Vlan,
+			"id":                  binding.Id,
+		})
+	}
+	if err := d.Set("hardware_gateway_binding", hwBindings); err != nil {
+		return fmt.Errorf("Invalid hardware gateway bindings to set: %#v", hwBindings)
+	}
+
 	return nil
 }
 
 func resourceLogicalSwitchUpdate(d *schema.ResourceData, meta interface{}) error {
-	nsxclient := meta.(*govnsx.Client)
+	nsxclient := nsxClient(meta, d.Get("universal").(bool))
 
 	updateVW := nsxtypes.NewUpdateVirtualWire()
 	if d.HasChange("name") {
@@ -147,14 +335,57 @@ func resourceLogicalSwitchUpdate(d *schema.ResourceData, meta interface{}) error
 		return err
 	}
 
-	return nil
+	vwireId := d.Get("virtual_wire_id").(string)
+	if vwireId == "" {
+		vwireId = path.Base(location)
+	}
+
+	if d.HasChange("mac_learning_enabled") || d.HasChange("ip_discovery") {
+		log.Printf("[INFO] Updating Logical switch :features: mac_learning_enabled: %v, ip_discovery: %v",
+			d.Get("mac_learning_enabled"), d.Get("ip_discovery"))
+		if err := updateNetworkFeatures(nsxclient, vwireId, d); err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("hardware_gateway_binding") {
+		o, n := d.GetChange("hardware_gateway_binding")
+
+		oldBindings := []hwGatewayBinding{}
+		for _, raw := range o.([]interface{}) {
+			oldBindings = append(oldBindings, expandHwGatewayBinding(raw.(map[string]interface{})))
+		}
+		newBindings := []hwGatewayBinding{}
+		for _, raw := range n.([]interface{}) {
+			newBindings = append(newBindings, expandHwGatewayBinding(raw.(map[string]interface{})))
+		}
+
+		for _, binding := range oldBindings {
+			if !containsHwGatewayBinding(newBindings, binding) {
+				if err := deleteHwGatewayBinding(nsxclient, vwireId, binding.Id); err != nil {
+					return err
+				}
+			}
+		}
+		for _, binding := range newBindings {
+			if !containsHwGatewayBinding(oldBindings, binding) {
+				if err := addHwGatewayBinding(nsxclient, vwireId, binding); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return resourceLogicalSwitchRead(d, meta)
 }
 
 func resourceLogicalSwitchDelete(d *schema.ResourceData, meta interface{}) error {
-	nsxclient := meta.(*govnsx.Client)
+	nsxclient := nsxClient(meta, d.Get("universal").(bool))
 
 	netobj := nsxresource.NewNetwork(nsxclient)
-	err := netobj.Delete(d.Id())
+	err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return netobj.Delete(d.Id())
+	})
 	if err != nil {
 		return err
 	}
@@ -188,3 +419,114 @@ func NewVWCreateSpec(d *schema.ResourceData) *nsxtypes.VWCreateSpec {
 	}
 	return vWspec
 }
+
+func getNetworkFeatures(client *govnsx.Client, vwireId string) (*networkFeatureConfig, error) {
+
+	getUri := fmt.Sprintf(NetworkFeaturesUriFormat, client.MgrConfig.Uri, vwireId)
+
+	features := &networkFeatureConfig{}
+	if err := nsxGet(client, getUri, features); err != nil {
+		log.Printf("[ERROR] Retriving features of Logical Switch '%s' failed with error : '%v'",
+			vwireId, err)
+		return nil, err
+	}
+
+	return features, nil
+}
+
+func updateNetworkFeatures(client *govnsx.Client, vwireId string, d *schema.ResourceData) error {
+
+	features := &networkFeatureConfig{}
+	features.MacLearningConfig.Enabled = d.Get("mac_learning_enabled").(bool)
+
+	// Without an ip_discovery block IPDiscoveryConfig is sent zeroed, which
+	// disables IP discovery on the Logical Switch.
+	for _, raw := range d.Get("ip_discovery").([]interface{}) {
+		ipDiscovery := raw.(map[string]interface{})
+
+		features.IPDiscoveryConfig.ArpSnooping = ipDiscovery["arp_snooping"].(bool)
+		features.IPDiscoveryConfig.DhcpSnooping = ipDiscovery["dhcp_snooping"].(bool)
+		features.IPDiscoveryConfig.VmTools = ipDiscovery["vmtools"].(bool)
+		features.IPDiscoveryConfig.Enabled = features.IPDiscoveryConfig.ArpSnooping ||
+			features.IPDiscoveryConfig.DhcpSnooping || features.IPDiscoveryConfig.VmTools
+	}
+
+	putUri := fmt.Sprintf(NetworkFeaturesUriFormat, client.MgrConfig.Uri, vwireId)
+	if err := nsxPut(client, putUri, features); err != nil {
+		log.Printf("[ERROR] Updating features of Logical Switch '%s' failed with error : '%v'",
+			vwireId, err)
+		return err
+	}
+
+	return nil
+}
+
+func getHwGatewayBindings(client *govnsx.Client, vwireId string) ([]hwGatewayBinding, error) {
+
+	getUri := fmt.Sprintf(HwGatewayBindingsUriFormat, client.MgrConfig.Uri, vwireId)
+
+	bindings := &hwGatewayBindings{}
+	if err := nsxGet(client, getUri, bindings); err != nil {
+		log.Printf("[ERROR] Retriving hardware gateway bindings of Logical Switch '%s' failed with error : '%v'",
+			vwireId, err)
+		return nil, err
+	}
+
+	return bindings.Bindings, nil
+}
+
+func addHwGatewayBinding(client *govnsx.Client, vwireId string, binding hwGatewayBinding) error {
+
+	binding.Id = ""
+	log.Printf("[INFO] Attaching Logical Switch '%s' to hardware gateway: %#v", vwireId, binding)
+
+	postUri := fmt.Sprintf(HwGatewayBindingsUriFormat, client.MgrConfig.Uri, vwireId)
+	if _, _, err := nsxPost(client, postUri, &binding); err != nil {
+		log.Printf("[ERROR] Attaching Logical Switch '%s' to hardware gateway failed with error : '%v'",
+			vwireId, err)
+		return err
+	}
+
+	return nil
+}
+
+func deleteHwGatewayBinding(client *govnsx.Client, vwireId string, bindingId string) error {
+
+	log.Printf("[INFO] Detaching Logical Switch '%s' from hardware gateway binding '%s'",
+		vwireId, bindingId)
+
+	deleteUri := fmt.Sprintf(HwGatewayBindingUriLocFormat, client.MgrConfig.Uri, vwireId, bindingId)
+	if err := nsxDelete(client, deleteUri); err != nil && !isNotFoundError(err) {
+		log.Printf("[ERROR] Detaching Logical Switch '%s' from hardware gateway failed with error : '%v'",
+			vwireId, err)
+		return err
+	}
+
+	return nil
+}
+
+func expandHwGatewayBinding(binding map[string]interface{}) hwGatewayBinding {
+
+	return hwGatewayBinding{
+		Id:                binding["id"].(string),
+		HardwareGatewayId: binding["hardware_gateway_id"].(string),
+		SwitchName:        binding["switch_name"].(string),
+		PortName:          binding["port_name"].(string),
+		Vlan:              binding["vlan"].(int),
+	}
+}
+
+// containsHwGatewayBinding compares bindings by their configuration, the
+// binding id is only known for bindings which were read back from NSX.
+func containsHwGatewayBinding(bindings []hwGatewayBinding, binding hwGatewayBinding) bool {
+
+	for _, b := range bindings {
+		if b.HardwareGatewayId == binding.HardwareGatewayId &&
+			b.SwitchName == binding.SwitchName &&
+			b.PortName == binding.PortName &&
+			b.Vlan == binding.Vlan {
+			return true
+		}
+	}
+	return false
+}
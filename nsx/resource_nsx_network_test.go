@@ -0,0 +1,286 @@
+package nsx
+
+import (
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/IBM-tfproviders/govnsx/nsxresource"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+var vdnScopeId = testAccEnvOrMock("NSX_VDN_SCOPE", mockScopeId)
+
+const testAccCheckLogicalSwitchConf = `
+resource "nsxv_logical_switch" "%s" {
+    name = "%s"
+    scope_id = "%s"
+    tenant_id = "tf-acc"
+    control_plane_mode = "UNICAST_MODE"
+    mac_learning_enabled = %t
+}
+`
+
+const testAccCheckLogicalSwitchConf_inheritedMode = `
+resource "nsxv_logical_switch" "%s" {
+    name = "%s"
+    scope_id = "%s"
+    tenant_id = "tf-acc"
+}
+`
+
+const testAccCheckLogicalSwitchConf_ipDiscovery = `
+resource "nsxv_logical_switch" "%s" {
+    name = "%s"
+    scope_id = "%s"
+    tenant_id = "tf-acc"
+    control_plane_mode = "UNICAST_MODE"
+    %s
+}
+`
+
+func TestAccNsxLogicalSwitch_ValidatorFunc(t *testing.T) {
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "vlan", validatorFn: validateVlanId,
+			values: []attributeProperty{
+				{value: 0, successCase: true},
+				{value: 100, successCase: true},
+				{value: 4094, successCase: true},
+				{value: 4095, expErr: "is not valid"},
+				{value: -1, expErr: "is not valid"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+func TestAccNsxLogicalSwitch_ContainsHwGatewayBinding(t *testing.T) {
+
+	bindings := []hwGatewayBinding{
+		expandHwGatewayBinding(map[string]interface{}{
+			"id": "binding-1", "hardware_gateway_id": "hwgw-1",
+			"switch_name": "tor-1", "port_name": "eth1", "vlan": 10}),
+	}
+
+	// Configured bindings have no id yet.
+	same := hwGatewayBinding{HardwareGatewayId: "hwgw-1", SwitchName: "tor-1",
+		PortName: "eth1", Vlan: 10}
+	otherVlan := hwGatewayBinding{HardwareGatewayId: "hwgw-1", SwitchName: "tor-1",
+		PortName: "eth1", Vlan: 20}
+
+	if !containsHwGatewayBinding(bindings, same) {
+		t.Fatalf("Binding %#v not found in %#v", same, bindings)
+	}
+	if containsHwGatewayBinding(bindings, otherVlan) {
+		t.Fatalf("Binding %#v unexpectedly found in %#v", otherVlan, bindings)
+	}
+}
+
+func TestAccNsxLogicalSwitch_Basic(t *testing.T) {
+
+	lsName := "TFT_LS"
+	resourceName := "nsxv_logical_switch." + lsName
+
+	config := fmt.Sprintf(testAccCheckLogicalSwitchConf, lsName, lsName, vdnScopeId, false)
+	log.Printf("[DEBUG] template config= %s", config)
+
+	configUpdate := fmt.Sprintf(testAccCheckLogicalSwitchConf, lsName, lsName+"_UPD", vdnScopeId, true)
+	log.Printf("[DEBUG] template configUpdate= %s", configUpdate)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckLogicalSwitch(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckLogicalSwitchDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						resourceName, "name", lsName),
+					resource.TestCheckResourceAttr(
+						resourceName, "mac_learning_enabled", "false"),
+					resource.TestCheckResourceAttrSet(
+						resourceName, "network_label"),
+				),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						resourceName, "name", lsName+"_UPD"),
+					resource.TestCheckResourceAttr(
+						resourceName, "mac_learning_enabled", "true"),
+				),
+			},
+		},
+	})
+}
+
+// The control plane mode of the transport zone is used when none is set, the
+// plan following the apply must be empty.
+func TestAccNsxLogicalSwitch_InheritedControlPlaneMode(t *testing.T) {
+
+	lsName := "TFT_LS_CPM"
+	resourceName := "nsxv_logical_switch." + lsName
+
+	config := fmt.Sprintf(testAccCheckLogicalSwitchConf_inheritedMode, lsName, lsName, vdnScopeId)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckLogicalSwitch(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckLogicalSwitchDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(
+						resourceName, "control_plane_mode"),
+				),
+			},
+			resource.TestStep{
+				Config:   config,
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+// An ip_discovery block with every flag off must not show up as a change,
+// and removing the block must disable IP discovery.
+func TestAccNsxLogicalSwitch_IPDiscovery(t *testing.T) {
+
+	lsName := "TFT_LS_IPD"
+	resourceName := "nsxv_logical_switch." + lsName
+
+	allOff := `ip_discovery {
+        arp_snooping = false
+        dhcp_snooping = false
+        vmtools = false
+    }`
+	arpOnly := `ip_discovery {
+        arp_snooping = true
+        dhcp_snooping = false
+        vmtools = false
+    }`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckLogicalSwitch(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckLogicalSwitchDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckLogicalSwitchConf_ipDiscovery,
+					lsName, lsName, vdnScopeId, allOff),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						resourceName, "ip_discovery.#", "1"),
+					resource.TestCheckResourceAttr(
+						resourceName, "ip_discovery.0.arp_snooping", "false"),
+					testAccCheckLogicalSwitchIPDiscovery(resourceName, false),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckLogicalSwitchConf_ipDiscovery,
+					lsName, lsName, vdnScopeId, allOff),
+				PlanOnly: true,
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckLogicalSwitchConf_ipDiscovery,
+					lsName, lsName, vdnScopeId, arpOnly),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						resourceName, "ip_discovery.0.arp_snooping", "true"),
+					testAccCheckLogicalSwitchIPDiscovery(resourceName, true),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckLogicalSwitchConf_ipDiscovery,
+					lsName, lsName, vdnScopeId, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						resourceName, "ip_discovery.#", "0"),
+					testAccCheckLogicalSwitchIPDiscovery(resourceName, false),
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxLogicalSwitch_RequestXML(t *testing.T) {
+
+	if !isTestAccMock() {
+		t.Skip("Checking the requests requires the mock NSX Manager")
+	}
+	m := testAccMockNsxManager(t)
+
+	lsName := "TFT_LS"
+
+	resource.Test(t, resource.TestCase{
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckLogicalSwitchDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckLogicalSwitchConf, lsName, lsName, vdnScopeId, false),
+				Check: testAccCheckMockRequestXML(m, "POST",
+					"/api/2.0/vdn/scopes/"+vdnScopeId+"/virtualwires", map[string][]string{
+						"virtualWireCreateSpec/name":             {lsName},
+						"virtualWireCreateSpec/tenantId":         {"tf-acc"},
+						"virtualWireCreateSpec/controlPlaneMode": {"UNICAST_MODE"},
+					}),
+			},
+		},
+	})
+}
+
+func testAccCheckLogicalSwitchIPDiscovery(resourceName string, enabled bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		client := testAccProvider.Meta().(*govnsx.Client)
+		features, err := getNetworkFeatures(client, rs.Primary.Attributes["virtual_wire_id"])
+		if err != nil {
+			return err
+		}
+
+		if features.IPDiscoveryConfig.Enabled != enabled {
+			return fmt.Errorf("IP discovery of Logical Switch %s is enabled: %t, expected: %t",
+				rs.Primary.ID, features.IPDiscoveryConfig.Enabled, enabled)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckLogicalSwitchDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+	netobj := nsxresource.NewNetwork(client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_logical_switch" {
+			continue
+		}
+
+		if _, err := netobj.Get(rs.Primary.ID); err == nil {
+			return fmt.Errorf("Logical Switch %s still exists", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+func testAccPreCheckLogicalSwitch(t *testing.T) {
+
+	testAccPreCheck(t)
+
+	if vdnScopeId == "" {
+		t.Fatal("NSX_VDN_SCOPE must be set for acceptance tests")
+	}
+}
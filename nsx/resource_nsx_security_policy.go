@@ -0,0 +1,574 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	SecurityPolicyUriFormat    = "%s/api/2.0/services/policy/securitypolicy"
+	SecurityPolicyUriLocFormat = "%s/api/2.0/services/policy/securitypolicy/%s"
+	SecurityPolicyDelUriFormat = "%s/api/2.0/services/policy/securitypolicy/%s?force=false"
+
+	SecurityActionCategoryFirewall = "firewall"
+	SecurityActionClassFirewall    = "firewallSecurityAction"
+
+	FirewallActionAllow  = "allow"
+	FirewallActionBlock  = "block"
+	FirewallActionReject = "reject"
+
+	FirewallDirectionInbound  = "inbound"
+	FirewallDirectionOutbound = "outbound"
+	FirewallDirectionIntra    = "intra"
+)
+
+var firewallActionsList = []string{
+	string(FirewallActionAllow),
+	string(FirewallActionBlock),
+	string(FirewallActionReject),
+}
+
+var firewallDirectionsList = []string{
+	string(FirewallDirectionInbound),
+	string(FirewallDirectionOutbound),
+	string(FirewallDirectionIntra),
+}
+
+type securityAction struct {
+	XMLName                xml.Name    `xml:"action"`
+	Class                  string      `xml:"class,attr"`
+	ObjectId               string      `xml:"objectId,omitempty"`
+	Revision               int         `xml:"revision,omitempty"`
+	Name                   string      `xml:"name,omitempty"`
+	Description            string      `xml:"description,omitempty"`
+	Category               string      `xml:"category"`
+	IsEnabled              bool        `xml:"isEnabled"`
+	SecondarySecurityGroup []objectRef `xml:"secondarySecurityGroup,omitempty"`
+	Applications           []objectRef `xml:"applications>application,omitempty"`
+	Logged                 bool        `xml:"logged"`
+	Action                 string      `xml:"action,omitempty"`
+	Direction              string      `xml:"direction,omitempty"`
+	ExecutionOrder         int         `xml:"executionOrder,omitempty"`
+}
+
+type securityActionsByCategory struct {
+	XMLName  xml.Name         `xml:"actionsByCategory"`
+	Category string           `xml:"category"`
+	Actions  []securityAction `xml:"action"`
+}
+
+type securityPolicy struct {
+	XMLName              xml.Name    `xml:"securityPolicy"`
+	ObjectId             string      `xml:"objectId,omitempty"`
+	Revision             int         `xml:"revision,omitempty"`
+	Name                 string      `xml:"name"`
+	Description          string      `xml:"description,omitempty"`
+	Precedence           int         `xml:"precedence"`
+	Parent               *objectRef  `xml:"parent,omitempty"`
+	InheritanceAllowed   bool        `xml:"inheritanceAllowed"`
+	SecurityGroupBinding []objectRef `xml:"securityGroupBinding,omitempty"`
+	ActionsByCategory    []rawXML    `xml:"actionsByCategory,omitempty"`
+}
+
+func resourceSecurityPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceSecurityPolicyCreate,
+		Read:   resourceSecurityPolicyRead,
+		Update: resourceSecurityPolicyUpdate,
+		Delete: resourceSecurityPolicyDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		CustomizeDiff: resourceSecurityPolicyCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"precedence": &schema.Schema{
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validatePrecedence,
+			},
+			"parent_policy_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"inheritance_allowed": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"security_group_ids": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			// The rules in execution order. They are matched with the
+			// firewall actions of NSX by name, so the names are unique.
+			"firewall_rule": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"description": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"action": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateFirewallAction,
+						},
+						"direction": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateFirewallDirection,
+						},
+						"secondary_security_group_ids": &schema.Schema{
+							Type:     schema.TypeSet,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"service_ids": &schema.Schema{
+							Type:     schema.TypeSet,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"logged": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						"enabled": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+						"object_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceSecurityPolicyCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	policySpec := &securityPolicy{}
+	if err := setSecurityPolicySpec(d, policySpec); err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Creating Security Policy: %#v", policySpec)
+
+	postUri := fmt.Sprintf(SecurityPolicyUriFormat, client.MgrConfig.Uri)
+	location, body, err := nsxPost(client, postUri, policySpec)
+	if err != nil {
+		log.Printf("[ERROR] Security Policy creation failed. %v", err)
+		return err
+	}
+
+	// NSX answers either with the new object id in the body or in the
+	// Location header.
+	policyId := strings.TrimSpace(string(body))
+	if location != "" {
+		policyId = path.Base(location)
+	}
+
+	d.SetId(policyId)
+	log.Printf("[INFO] Security Policy %s created with id: %s", policySpec.Name, policyId)
+
+	return resourceSecurityPolicyRead(d, meta)
+}
+
+func resourceSecurityPolicyRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	policy, err := getSecurityPolicy(client, d.Id())
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Security Policy '%s' not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", policy.Name)
+	d.Set("description", policy.Description)
+	d.Set("precedence", policy.Precedence)
+	d.Set("inheritance_allowed", policy.InheritanceAllowed)
+
+	parentId := ""
+	if policy.Parent != nil {
+		parentId = policy.Parent.ObjectId
+	}
+	d.Set("parent_policy_id", parentId)
+
+	d.Set("security_group_ids", flattenObjectRefs(policy.SecurityGroupBinding))
+
+	actions, err := getFirewallActions(policy)
+	if err != nil {
+		return err
+	}
+
+	// The rules are kept in execution order, so a rule added, removed or
+	// moved outside of Terraform shows in the next plan.
+	sort.SliceStable(actions, func(i, j int) bool {
+		return actions[i].ExecutionOrder < actions[j].ExecutionOrder
+	})
+
+	rules := make([]map[string]interface{}, 0)
+	for _, action := range actions {
+		rules = append(rules, flattenFirewallAction(action))
+	}
+
+	if err := d.Set("firewall_rule", rules); err != nil {
+		return fmt.Errorf("Invalid firewall rules to set: %#v", rules)
+	}
+
+	return nil
+}
+
+func resourceSecurityPolicyUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	// The PUT needs the current revision and must carry the actions of
+	// the categories which are not managed here.
+	policy, err := getSecurityPolicy(client, d.Id())
+	if err != nil {
+		return err
+	}
+
+	if err := setSecurityPolicySpec(d, policy); err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Updating Security Policy %s: %#v", d.Id(), policy)
+
+	putUri := fmt.Sprintf(SecurityPolicyUriLocFormat, client.MgrConfig.Uri, d.Id())
+	if err := nsxPut(client, putUri, policy); err != nil {
+		log.Printf("[ERROR] Updating Security Policy '%s' failed with error : '%v'", d.Id(), err)
+		return err
+	}
+
+	return resourceSecurityPolicyRead(d, meta)
+}
+
+func resourceSecurityPolicyDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	deleteUri := fmt.Sprintf(SecurityPolicyDelUriFormat, client.MgrConfig.Uri, d.Id())
+	err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return nsxDelete(client, deleteUri)
+	})
+	if err != nil && !isNotFoundError(err) {
+		log.Printf("[ERROR] Deleting Security Policy '%s' failed with error : %v", d.Id(), err)
+		return err
+	}
+
+	log.Printf("[INFO] Security Policy deleted :%s", d.Id())
+	d.SetId("")
+	return nil
+}
+
+func getSecurityPolicy(client *govnsx.Client, policyId string) (*securityPolicy, error) {
+
+	getUri := fmt.Sprintf(SecurityPolicyUriLocFormat, client.MgrConfig.Uri, policyId)
+
+	policy := &securityPolicy{}
+	if err := nsxGet(client, getUri, policy); err != nil {
+		log.Printf("[ERROR] Retriving Security Policy '%s' failed with error : '%v'", policyId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] Security Policy details of '%s': '%v'", policyId, policy)
+	return policy, nil
+}
+
+// setSecurityPolicySpec copies the configuration into policy. Only the
+// firewall category of actionsByCategory is replaced.
+func setSecurityPolicySpec(d *schema.ResourceData, policy *securityPolicy) error {
+
+	policy.Name = d.Get("name").(string)
+	policy.Description = d.Get("description").(string)
+	policy.Precedence = d.Get("precedence").(int)
+	policy.InheritanceAllowed = d.Get("inheritance_allowed").(bool)
+
+	policy.Parent = nil
+	if v, ok := d.GetOk("parent_policy_id"); ok {
+		policy.Parent = &objectRef{ObjectId: v.(string)}
+	}
+
+	policy.SecurityGroupBinding = expandObjectRefs(d.Get("security_group_ids"))
+
+	rules := d.Get("firewall_rule").([]interface{})
+	prvActions, err := matchFirewallActions(policy, rules)
+	if err != nil {
+		return err
+	}
+
+	firewallActions := []securityAction{}
+	for i, raw := range rules {
+		rule := raw.(map[string]interface{})
+
+		firewallActions = append(firewallActions, securityAction{
+			Class:                  SecurityActionClassFirewall,
+			ObjectId:               prvActions[i].ObjectId,
+			Revision:               prvActions[i].Revision,
+			Name:                   rule["name"].(string),
+			Description:            rule["description"].(string),
+			Category:               SecurityActionCategoryFirewall,
+			IsEnabled:              rule["enabled"].(bool),
+			SecondarySecurityGroup: expandObjectRefs(rule["secondary_security_group_ids"]),
+			Applications:           expandObjectRefs(rule["service_ids"]),
+			Logged:                 rule["logged"].(bool),
+			Action:                 rule["action"].(string),
+			Direction:              rule["direction"].(string),
+			ExecutionOrder:         i + 1,
+		})
+	}
+
+	categories := []rawXML{}
+	for _, raw := range policy.ActionsByCategory {
+		category, err := decodeActionsByCategory(raw)
+		if err != nil {
+			return err
+		}
+		if category.Category != SecurityActionCategoryFirewall {
+			categories = append(categories, raw)
+		}
+	}
+	if len(firewallActions) > 0 {
+		raw, err := encodeActionsByCategory(&securityActionsByCategory{
+			Category: SecurityActionCategoryFirewall,
+			Actions:  firewallActions,
+		})
+		if err != nil {
+			return err
+		}
+		categories = append(categories, raw)
+	}
+	policy.ActionsByCategory = categories
+
+	return nil
+}
+
+// matchFirewallActions returns, for each rule, the firewall action of policy
+// it updates, or an empty one for a new rule. The rules are matched by name
+// first, then by object id for a renamed rule. The object ids of the list
+// follow the positions of the previous rules, so they only reuse an action
+// no rule is named after.
+func matchFirewallActions(policy *securityPolicy, rules []interface{}) ([]securityAction, error) {
+
+	actions, err := getFirewallActions(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]int)
+	byId := make(map[string]int)
+	for i, action := range actions {
+		byName[action.Name] = i
+		byId[action.ObjectId] = i
+	}
+
+	matched := make([]securityAction, len(rules))
+	used := make(map[int]bool)
+	for i, raw := range rules {
+		rule := raw.(map[string]interface{})
+		if j, ok := byName[rule["name"].(string)]; ok && !used[j] {
+			matched[i] = actions[j]
+			used[j] = true
+		}
+	}
+	for i, raw := range rules {
+		rule := raw.(map[string]interface{})
+		if matched[i].ObjectId != "" {
+			continue
+		}
+		j, ok := byId[rule["object_id"].(string)]
+		if !ok || used[j] || isFirewallRuleName(rules, actions[j].Name) {
+			continue
+		}
+		matched[i] = actions[j]
+		used[j] = true
+	}
+
+	return matched, nil
+}
+
+func isFirewallRuleName(rules []interface{}, name string) bool {
+	for _, raw := range rules {
+		if raw.(map[string]interface{})["name"].(string) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getFirewallActions returns the actions of the firewall category of policy.
+func getFirewallActions(policy *securityPolicy) ([]securityAction, error) {
+
+	actions := []securityAction{}
+	for _, raw := range policy.ActionsByCategory {
+		category, err := decodeActionsByCategory(raw)
+		if err != nil {
+			return nil, err
+		}
+		if category.Category == SecurityActionCategoryFirewall {
+			actions = append(actions, category.Actions...)
+		}
+	}
+	return actions, nil
+}
+
+func resourceSecurityPolicyCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+
+	names := make(map[string]bool)
+	for i, raw := range d.Get("firewall_rule").([]interface{}) {
+		rule, ok := raw.(map[string]interface{})
+		if !ok || !d.NewValueKnown(fmt.Sprintf("firewall_rule.%d.name", i)) {
+			continue
+		}
+		name := rule["name"].(string)
+		if names[name] {
+			return fmt.Errorf("firewall_rule: more than one rule named '%s'", name)
+		}
+		names[name] = true
+	}
+
+	return nil
+}
+
+func decodeActionsByCategory(raw rawXML) (*securityActionsByCategory, error) {
+
+	category := &securityActionsByCategory{}
+	err := xml.Unmarshal([]byte("<actionsByCategory>"+raw.InnerXML+"</actionsByCategory>"),
+		category)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode Security Policy actions: %v", err)
+	}
+	return category, nil
+}
+
+func encodeActionsByCategory(category *securityActionsByCategory) (rawXML, error) {
+
+	raw := rawXML{}
+	outputXML, err := xml.Marshal(category)
+	if err != nil {
+		return raw, err
+	}
+	err = xml.Unmarshal(outputXML, &raw)
+	return raw, err
+}
+
+func flattenFirewallAction(action securityAction) map[string]interface{} {
+
+	return map[string]interface{}{
+		"name":                         action.Name,
+		"description":                  action.Description,
+		"action":                       action.Action,
+		"direction":                    action.Direction,
+		"secondary_security_group_ids": flattenObjectRefs(action.SecondarySecurityGroup),
+		"service_ids":                  flattenObjectRefs(action.Applications),
+		"logged":                       action.Logged,
+		"enabled":                      action.IsEnabled,
+		"object_id":                    action.ObjectId,
+	}
+}
+
+func expandObjectRefs(v interface{}) []objectRef {
+
+	refs := []objectRef{}
+	if idSet, ok := v.(*schema.Set); ok {
+		for _, id := range idSet.List() {
+			refs = append(refs, objectRef{ObjectId: id.(string)})
+		}
+	}
+	return refs
+}
+
+func flattenObjectRefs(refs []objectRef) []string {
+
+	ids := []string{}
+	for _, ref := range refs {
+		ids = append(ids, ref.ObjectId)
+	}
+	return ids
+}
+
+func validatePrecedence(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(int)
+
+	if value <= 0 {
+		errors = append(errors, fmt.Errorf(
+			"%s: Precedence must be a positive number, got %d", k, value))
+	}
+
+	return
+}
+
+func validateFirewallAction(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	found := false
+
+	for _, t := range firewallActionsList {
+		if t == value {
+			found = true
+		}
+	}
+	if !found {
+		errors = append(errors, fmt.Errorf(
+			"%s: Supported values are %s", k, strings.Join(firewallActionsList, ", ")))
+	}
+
+	return
+}
+
+func validateFirewallDirection(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	found := false
+
+	for _, t := range firewallDirectionsList {
+		if t == value {
+			found = true
+		}
+	}
+	if !found {
+		errors = append(errors, fmt.Errorf(
+			"%s: Supported values are %s", k, strings.Join(firewallDirectionsList, ", ")))
+	}
+
+	return
+}
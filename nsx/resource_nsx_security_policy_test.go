@@ -0,0 +1,290 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const testAccCheckSecurityPolicyConf = `
+resource "nsxv_security_policy" "policy" {
+    name = "tf-acc-policy"
+    precedence = 5500
+%s}
+`
+
+const testAccCheckSecurityPolicyConf_rule = `
+    firewall_rule {
+        name = "%s"
+        description = "%s"
+        action = "allow"
+        direction = "inbound"
+    }
+`
+
+const testSecurityPolicyXML = `
+<securityPolicy>
+    <objectId>policy-7</objectId>
+    <revision>3</revision>
+    <name>web</name>
+    <precedence>5500</precedence>
+    <inheritanceAllowed>false</inheritanceAllowed>
+    <securityGroupBinding><objectId>securitygroup-10</objectId></securityGroupBinding>
+    <actionsByCategory>
+        <category>endpoint</category>
+        <action class="endpointSecurityAction">
+            <objectId>action-1</objectId>
+            <name>AV</name>
+            <category>endpoint</category>
+            <serviceId>service-5</serviceId>
+        </action>
+    </actionsByCategory>
+    <actionsByCategory>
+        <category>firewall</category>
+        <action class="firewallSecurityAction">
+            <objectId>action-2</objectId>
+            <name>old</name>
+            <category>firewall</category>
+            <isEnabled>true</isEnabled>
+            <logged>false</logged>
+            <action>allow</action>
+            <direction>inbound</direction>
+        </action>
+    </actionsByCategory>
+</securityPolicy>
+`
+
+func TestAccNsxSecurityPolicy_ValidatorFunc(t *testing.T) {
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "action", validatorFn: validateFirewallAction,
+			values: []attributeProperty{
+				{value: "allow", successCase: true},
+				{value: "block", successCase: true},
+				{value: "reject", successCase: true},
+				{value: "deny", expErr: "Supported values are"},
+			},
+		},
+		{name: "direction", validatorFn: validateFirewallDirection,
+			values: []attributeProperty{
+				{value: "inbound", successCase: true},
+				{value: "outbound", successCase: true},
+				{value: "intra", successCase: true},
+				{value: "in", expErr: "Supported values are"},
+			},
+		},
+		{name: "precedence", validatorFn: validatePrecedence,
+			values: []attributeProperty{
+				{value: 5500, successCase: true},
+				{value: 0, expErr: "must be a positive number"},
+				{value: -1, expErr: "must be a positive number"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+func TestAccNsxSecurityPolicy_SetSpecKeepsOtherCategories(t *testing.T) {
+
+	policy := &securityPolicy{}
+	if err := xml.Unmarshal([]byte(testSecurityPolicyXML), policy); err != nil {
+		t.Fatalf("Unable to decode security policy: %s", err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceSecurityPolicy().Schema,
+		map[string]interface{}{
+			"name":               "web",
+			"precedence":         5500,
+			"security_group_ids": []interface{}{"securitygroup-10"},
+			"firewall_rule": []interface{}{
+				map[string]interface{}{
+					"name":        "new",
+					"action":      "block",
+					"direction":   "outbound",
+					"service_ids": []interface{}{"application-3"},
+				},
+			},
+		})
+
+	if err := setSecurityPolicySpec(d, policy); err != nil {
+		t.Fatalf("setSecurityPolicySpec failed with error: %s", err)
+	}
+
+	if len(policy.ActionsByCategory) != 2 {
+		t.Fatalf("Expected 2 action categories, got %d", len(policy.ActionsByCategory))
+	}
+
+	endpoint, err := decodeActionsByCategory(policy.ActionsByCategory[0])
+	if err != nil {
+		t.Fatalf("Unable to decode endpoint actions: %s", err)
+	}
+	if endpoint.Category != "endpoint" ||
+		!strings.Contains(policy.ActionsByCategory[0].InnerXML, "<serviceId>service-5</serviceId>") {
+		t.Fatalf("Endpoint actions were not preserved: %#v", policy.ActionsByCategory[0])
+	}
+
+	firewall, err := decodeActionsByCategory(policy.ActionsByCategory[1])
+	if err != nil {
+		t.Fatalf("Unable to decode firewall actions: %s", err)
+	}
+	if len(firewall.Actions) != 1 {
+		t.Fatalf("Expected 1 firewall action, got %d", len(firewall.Actions))
+	}
+
+	action := firewall.Actions[0]
+	if action.Name != "new" || action.Action != "block" || action.Direction != "outbound" ||
+		action.Class != SecurityActionClassFirewall || !action.IsEnabled ||
+		len(action.Applications) != 1 || action.Applications[0].ObjectId != "application-3" {
+		t.Fatalf("Unexpected firewall action: %#v", action)
+	}
+
+	outputXML, err := xml.Marshal(policy)
+	if err != nil {
+		t.Fatalf("Unable to encode security policy: %s", err)
+	}
+	if strings.Count(string(outputXML), "<actionsByCategory>") != 2 {
+		t.Fatalf("Unexpected security policy XML: %s", outputXML)
+	}
+}
+
+func TestAccNsxSecurityPolicy_FirewallRules(t *testing.T) {
+
+	resourceName := "nsxv_security_policy.policy"
+	config := func(rules ...string) string {
+		blocks := ""
+		for _, rule := range rules {
+			blocks += fmt.Sprintf(testAccCheckSecurityPolicyConf_rule, rule, rule+" rule")
+		}
+		return fmt.Sprintf(testAccCheckSecurityPolicyConf, blocks)
+	}
+
+	// The object ids of the firewall actions by rule name, the rules must
+	// keep them whatever their position.
+	actionIds := make(map[string]string)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckSecurityPolicyDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      config("web", "web"),
+				ExpectError: regexp.MustCompile("more than one rule named 'web'"),
+			},
+			resource.TestStep{
+				Config: config("web", "db"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "firewall_rule.#", "2"),
+					testAccCheckSecurityPolicyRules(resourceName, actionIds, nil, "web", "db"),
+				),
+			},
+			resource.TestStep{
+				Config: config("ssh", "web", "db"),
+				Check: testAccCheckSecurityPolicyRules(resourceName, actionIds, nil,
+					"ssh", "web", "db"),
+			},
+			resource.TestStep{
+				Config: config("db", "ssh", "web"),
+				Check: testAccCheckSecurityPolicyRules(resourceName, actionIds, nil,
+					"db", "ssh", "web"),
+			},
+			resource.TestStep{
+				Config: config("db", "ssh-admin", "web"),
+				Check: testAccCheckSecurityPolicyRules(resourceName, actionIds,
+					map[string]string{"ssh-admin": "ssh"}, "db", "ssh-admin", "web"),
+			},
+			resource.TestStep{
+				Config: config("web"),
+				Check: testAccCheckSecurityPolicyRules(resourceName, actionIds, nil,
+					"web"),
+			},
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+// testAccCheckSecurityPolicyRules checks the firewall actions of the policy
+// in NSX are the rules names, in order, and that the rules seen before kept
+// the object id of their action, or the one of the rule they were renamed
+// from.
+func testAccCheckSecurityPolicyRules(resourceName string, actionIds map[string]string,
+	renamed map[string]string, names ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		client := testAccProvider.Meta().(*govnsx.Client)
+
+		policy, err := getSecurityPolicy(client, rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+		actions, err := getFirewallActions(policy)
+		if err != nil {
+			return err
+		}
+
+		if len(actions) != len(names) {
+			return fmt.Errorf("Security Policy has %d firewall actions, expected %d",
+				len(actions), len(names))
+		}
+		for i, name := range names {
+			action := actions[i]
+			if action.Name != name || action.Description != name+" rule" {
+				return fmt.Errorf("Firewall action %d is '%s', expected '%s'", i, action.Name, name)
+			}
+			if rs.Primary.Attributes[fmt.Sprintf("firewall_rule.%d.object_id", i)] !=
+				action.ObjectId {
+				return fmt.Errorf("Firewall rule '%s' has not the object id %s", name,
+					action.ObjectId)
+			}
+
+			prvName := name
+			if renamed[name] != "" {
+				prvName = renamed[name]
+			}
+			if id, ok := actionIds[prvName]; ok && id != action.ObjectId {
+				return fmt.Errorf("Firewall rule '%s' moved from action %s to %s", name,
+					id, action.ObjectId)
+			}
+			actionIds[name] = action.ObjectId
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckSecurityPolicyDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_security_policy" {
+			continue
+		}
+
+		_, err := getSecurityPolicy(client, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Security Policy %s still exists", rs.Primary.ID)
+		}
+		if !isNotFoundError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
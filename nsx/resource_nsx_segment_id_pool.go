@@ -0,0 +1,243 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	SegmentPoolsUriFormat = "%s/api/2.0/vdn/config/segments"
+	SegmentPoolUriFormat  = "%s/api/2.0/vdn/config/segments/%s"
+
+	// VNIs below 5000 are reserved by NSX.
+	SegmentIdMin = 5000
+	SegmentIdMax = 16777215
+)
+
+// segmentRange is a pool of VXLAN segment ids (VNIs), a VNI is taken from
+// it for every logical switch.
+type segmentRange struct {
+	XMLName xml.Name `xml:"segmentRange"`
+	Id      string   `xml:"id,omitempty"`
+	Name    string   `xml:"name"`
+	Desc    string   `xml:"desc,omitempty"`
+	Begin   int      `xml:"begin"`
+	End     int      `xml:"end"`
+}
+
+type segmentRangeList struct {
+	XMLName xml.Name       `xml:"segmentRanges"`
+	Ranges  []segmentRange `xml:"segmentRange"`
+}
+
+func resourceSegmentIdPool() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceSegmentIdPoolCreate,
+		Read:   resourceSegmentIdPoolRead,
+		Update: resourceSegmentIdPoolUpdate,
+		Delete: resourceSegmentIdPoolDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"begin": &schema.Schema{
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validateSegmentId,
+			},
+
+			"end": &schema.Schema{
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validateSegmentId,
+			},
+		},
+	}
+}
+
+func resourceSegmentIdPoolCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	poolSpec := &segmentRange{
+		Name:  d.Get("name").(string),
+		Desc:  d.Get("description").(string),
+		Begin: d.Get("begin").(int),
+		End:   d.Get("end").(int),
+	}
+
+	if err := validateSegmentRange(client, poolSpec); err != nil {
+		log.Printf("[ERROR] Configuration validation failed.")
+		return err
+	}
+
+	postUri := fmt.Sprintf(SegmentPoolsUriFormat, client.MgrConfig.Uri)
+
+	_, body, err := nsxPost(client, postUri, poolSpec)
+	if err != nil {
+		log.Printf("[ERROR] Segment ID Pool creation failed. %v", err)
+		return err
+	}
+
+	d.SetId(strings.TrimSpace(string(body)))
+	log.Printf("[INFO] Segment ID Pool %s created with id: %s", poolSpec.Name, d.Id())
+
+	return resourceSegmentIdPoolRead(d, meta)
+}
+
+func resourceSegmentIdPoolRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	pool, err := getSegmentIdPool(client, d.Id())
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Segment ID Pool '%s' not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", pool.Name)
+	d.Set("description", pool.Desc)
+	d.Set("begin", pool.Begin)
+	d.Set("end", pool.End)
+
+	return nil
+}
+
+func resourceSegmentIdPoolUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	poolSpec := &segmentRange{
+		Id:    d.Id(),
+		Name:  d.Get("name").(string),
+		Desc:  d.Get("description").(string),
+		Begin: d.Get("begin").(int),
+		End:   d.Get("end").(int),
+	}
+
+	if err := validateSegmentRange(client, poolSpec); err != nil {
+		log.Printf("[ERROR] Configuration validation failed.")
+		return err
+	}
+
+	log.Printf("[INFO] Updating Segment ID Pool %s : %#v", d.Id(), poolSpec)
+
+	putUri := fmt.Sprintf(SegmentPoolUriFormat, client.MgrConfig.Uri, d.Id())
+	if err := nsxPut(client, putUri, poolSpec); err != nil {
+		log.Printf("[ERROR] Updating Segment ID Pool '%s' failed with error : '%v'", d.Id(), err)
+		return err
+	}
+
+	return resourceSegmentIdPoolRead(d, meta)
+}
+
+func resourceSegmentIdPoolDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	deleteUri := fmt.Sprintf(SegmentPoolUriFormat, client.MgrConfig.Uri, d.Id())
+	err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return nsxDelete(client, deleteUri)
+	})
+	if err != nil && !isNotFoundError(err) {
+		log.Printf("[ERROR] Deleting Segment ID Pool '%s' failed with error : %v", d.Id(), err)
+		return err
+	}
+
+	log.Printf("[INFO] Segment ID Pool deleted :%s", d.Id())
+	d.SetId("")
+	return nil
+}
+
+func getSegmentIdPool(client *govnsx.Client, poolId string) (*segmentRange, error) {
+
+	getUri := fmt.Sprintf(SegmentPoolUriFormat, client.MgrConfig.Uri, poolId)
+
+	pool := &segmentRange{}
+	if err := nsxGet(client, getUri, pool); err != nil {
+		log.Printf("[ERROR] Retriving Segment ID Pool '%s' failed with error : '%v'", poolId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] Segment ID Pool details of '%s': '%v'", poolId, pool)
+	return pool, nil
+}
+
+// validateSegmentRange checks that the range of the pool is valid and does
+// not overlap the other pools of NSX Manager.
+func validateSegmentRange(client *govnsx.Client, pool *segmentRange) error {
+
+	if pool.Begin > pool.End {
+		return fmt.Errorf("Segment ID Pool begin %d needs to be smaller than its end %d.",
+			pool.Begin, pool.End)
+	}
+
+	getUri := fmt.Sprintf(SegmentPoolsUriFormat, client.MgrConfig.Uri)
+
+	list := &segmentRangeList{}
+	if err := nsxGet(client, getUri, list); err != nil {
+		log.Printf("[ERROR] Retriving Segment ID Pools failed with error : '%v'", err)
+		return err
+	}
+
+	pools := []segmentRange{*pool}
+	for _, p := range list.Ranges {
+		if p.Id != pool.Id {
+			pools = append(pools, p)
+		}
+	}
+
+	return checkSegmentRangesOverlap(pools)
+}
+
+func checkSegmentRangesOverlap(pools []segmentRange) error {
+
+	values := make([]valueRange, len(pools))
+	for i, p := range pools {
+		values[i] = valueRange{big.NewInt(int64(p.Begin)), big.NewInt(int64(p.End))}
+	}
+
+	if _, overlap := sortRanges(values); overlap != nil {
+		p1, p2 := pools[overlap[0]], pools[overlap[1]]
+		return fmt.Errorf("Overlapping Segment ID Pools '%s' (%d-%d) and '%s' (%d-%d)",
+			p1.Name, p1.Begin, p1.End, p2.Name, p2.Begin, p2.End)
+	}
+
+	return nil
+}
+
+func validateSegmentId(v interface{}, k string) (ws []string, errors []error) {
+
+	segmentId := v.(int)
+	if segmentId < SegmentIdMin || segmentId > SegmentIdMax {
+		errors = append(errors, fmt.Errorf(
+			"%s: Segment ID %d is not valid, it must be between %d and %d.",
+			k, segmentId, SegmentIdMin, SegmentIdMax))
+	}
+	return
+}
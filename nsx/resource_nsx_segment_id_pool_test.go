@@ -0,0 +1,135 @@
+package nsx
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const (
+	testAccCheckSegmentIdPoolConf = `
+resource "nsxv_segment_id_pool" "%s" {
+    name = "%s"
+    description = "Created by Terraform acceptance test"
+    begin = %d
+    end = %d
+}
+`
+)
+
+func TestAccNsxSegmentIdPool_ValidatorFunc(t *testing.T) {
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "begin", validatorFn: validateSegmentId,
+			values: []attributeProperty{
+				{value: 5000, successCase: true},
+				{value: 16777215, successCase: true},
+				{value: 4999, expErr: "is not valid"},
+				{value: 16777216, expErr: "is not valid"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+func TestAccNsxSegmentIdPool_Overlap(t *testing.T) {
+
+	cases := []struct {
+		pools  []segmentRange
+		expErr string
+	}{
+		{[]segmentRange{{Name: "a", Begin: 5000, End: 5999}, {Name: "b", Begin: 6000, End: 6999}}, ""},
+		{[]segmentRange{{Name: "a", Begin: 7000, End: 7000}, {Name: "b", Begin: 5000, End: 6999}}, ""},
+		{[]segmentRange{{Name: "a", Begin: 5000, End: 6000}, {Name: "b", Begin: 6000, End: 6999}},
+			"Overlapping Segment ID Pools 'a' (5000-6000) and 'b' (6000-6999)"},
+		{[]segmentRange{{Name: "a", Begin: 5500, End: 5600}, {Name: "b", Begin: 5000, End: 6999}},
+			"Overlapping Segment ID Pools 'b' (5000-6999) and 'a' (5500-5600)"},
+	}
+
+	for _, c := range cases {
+		err := checkSegmentRangesOverlap(c.pools)
+		if c.expErr == "" {
+			if err != nil {
+				t.Fatalf("%v: unexpected error %s", c.pools, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.expErr) {
+			t.Fatalf("%v: expected error '%s', got '%v'", c.pools, c.expErr, err)
+		}
+	}
+}
+
+func TestAccNsxSegmentIdPool_Basic(t *testing.T) {
+
+	poolName := "TFT_SEGMENT_POOL"
+	resourceName := "nsxv_segment_id_pool." + poolName
+
+	config := fmt.Sprintf(testAccCheckSegmentIdPoolConf, poolName, poolName, 70000, 70999)
+	log.Printf("[DEBUG] template config= %s", config)
+
+	configUpdate := fmt.Sprintf(testAccCheckSegmentIdPoolConf, poolName, poolName+"_UPD",
+		70000, 71999)
+	log.Printf("[DEBUG] template configUpdate= %s", configUpdate)
+
+	configOverlap := configUpdate + fmt.Sprintf(testAccCheckSegmentIdPoolConf, "overlap",
+		"overlap", 71500, 72999)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckSegmentIdPoolDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", poolName),
+					resource.TestCheckResourceAttr(resourceName, "end", "70999"),
+				),
+			},
+			resource.TestStep{
+				Config: configUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", poolName+"_UPD"),
+					resource.TestCheckResourceAttr(resourceName, "end", "71999"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			resource.TestStep{
+				Config:      configOverlap,
+				ExpectError: regexp.MustCompile("Overlapping Segment ID Pools"),
+			},
+		},
+	})
+}
+
+func testAccCheckSegmentIdPoolDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_segment_id_pool" {
+			continue
+		}
+
+		_, err := getSegmentIdPool(client, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Segment ID Pool %s still exists", rs.Primary.ID)
+		}
+		if !isNotFoundError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/IBM-tfproviders/govnsx/nsxtypes"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	VdnScopeUriFormat       = "%s/api/2.0/vdn/scopes"
+	VdnScopeUniversalUri    = "%s/api/2.0/vdn/scopes?isUniversal=true"
+	VdnScopeUriLocFormat    = "%s/api/2.0/vdn/scopes/%s"
+	VdnScopeAttrUriFormat   = "%s/api/2.0/vdn/scopes/%s/attributes"
+	VdnScopeActionUriFormat = "%s/api/2.0/vdn/scopes/%s?action=%s"
+
+	VdnScopeActionExpand = "expand"
+	VdnScopeActionShrink = "shrink"
+)
+
+var controlPlaneModesList = []string{
+	nsxtypes.CpmUnicastMode,
+	nsxtypes.CpmHybridMode,
+	nsxtypes.CpmMulticastMode,
+}
+
+type vdnScopeCluster struct {
+	Cluster objectRef `xml:"cluster"`
+}
+
+type vdnScope struct {
+	XMLName          xml.Name          `xml:"vdnScope"`
+	ObjectId         string            `xml:"objectId,omitempty"`
+	Name             string            `xml:"name,omitempty"`
+	Description      string            `xml:"description,omitempty"`
+	Clusters         []vdnScopeCluster `xml:"clusters>cluster,omitempty"`
+	ControlPlaneMode string            `xml:"controlPlaneMode,omitempty"`
+	IsUniversal      bool              `xml:"isUniversal,omitempty"`
+}
+
+type vdnScopes struct {
+	XMLName   xml.Name   `xml:"vdnScopes"`
+	VdnScopes []vdnScope `xml:"vdnScope"`
+}
+
+func resourceTransportZone() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceTransportZoneCreate,
+		Read:   resourceTransportZoneRead,
+		Update: resourceTransportZoneUpdate,
+		Delete: resourceTransportZoneDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		CustomizeDiff: checkUniversalChange,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"control_plane_mode": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      nsxtypes.CpmUnicastMode,
+				ValidateFunc: validateControlPlaneMode,
+			},
+
+			"cluster_ids": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+
+			// The universal transport zone of a cross-vCenter deployment,
+			// the one of universal logical switches.
+			"universal": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func resourceTransportZoneCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := nsxClient(meta, d.Get("universal").(bool))
+
+	scopeSpec := &vdnScope{
+		Name:             d.Get("name").(string),
+		Description:      d.Get("description").(string),
+		ControlPlaneMode: d.Get("control_plane_mode").(string),
+		Clusters:         expandVdnScopeClusters(d.Get("cluster_ids").(*schema.Set).List()),
+	}
+
+	log.Printf("[INFO] Creating Transport Zone: %#v", scopeSpec)
+
+	postUri := fmt.Sprintf(VdnScopeUriFormat, client.MgrConfig.Uri)
+	if d.Get("universal").(bool) {
+		postUri = fmt.Sprintf(VdnScopeUniversalUri, client.MgrConfig.Uri)
+	}
+	_, body, err := nsxPost(client, postUri, scopeSpec)
+	if err != nil {
+		log.Printf("[ERROR] Transport Zone creation failed. %v", err)
+		return err
+	}
+
+	d.SetId(strings.TrimSpace(string(body)))
+	log.Printf("[INFO] Transport Zone %s created with id: %s", scopeSpec.Name, d.Id())
+
+	return resourceTransportZoneRead(d, meta)
+}
+
+func resourceTransportZoneRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := nsxClient(meta, d.Get("universal").(bool))
+
+	scope, err := getTransportZone(client, d.Id())
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] Transport Zone '%s' not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("name", scope.Name)
+	d.Set("description", scope.Description)
+	d.Set("control_plane_mode", scope.ControlPlaneMode)
+	d.Set("cluster_ids", flattenVdnScopeClusters(scope.Clusters))
+	d.Set("universal", scope.IsUniversal)
+
+	return nil
+}
+
+func resourceTransportZoneUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	client := nsxClient(meta, d.Get("universal").(bool))
+	scopeId := d.Id()
+
+	if d.HasChange("name") || d.HasChange("description") ||
+		d.HasChange("control_plane_mode") {
+
+		scopeSpec := &vdnScope{
+			ObjectId:         scopeId,
+			Name:             d.Get("name").(string),
+			Description:      d.Get("description").(string),
+			ControlPlaneMode: d.Get("control_plane_mode").(string),
+		}
+
+		log.Printf("[INFO] Updating Transport Zone %s attributes: %#v", scopeId, scopeSpec)
+
+		putUri := fmt.Sprintf(VdnScopeAttrUriFormat, client.MgrConfig.Uri, scopeId)
+		if err := nsxPut(client, putUri, scopeSpec); err != nil {
+			log.Printf("[ERROR] Updating Transport Zone '%s' failed with error : '%v'",
+				scopeId, err)
+			return err
+		}
+	}
+
+	if d.HasChange("cluster_ids") {
+
+		oldClusters, newClusters := d.GetChange("cluster_ids")
+		oldClusterSet := oldClusters.(*schema.Set)
+		newClusterSet := newClusters.(*schema.Set)
+
+		addedClusters := newClusterSet.Difference(oldClusterSet)
+		removedClusters := oldClusterSet.Difference(newClusterSet)
+
+		log.Printf("[DEBUG] added clusters : %#v\n", addedClusters)
+		log.Printf("[DEBUG] removed clusters : %#v\n", removedClusters)
+
+		// Expand first, NSX refuses to shrink a transport zone to zero clusters.
+		if addedClusters.Len() > 0 {
+			if err := changeTransportZoneClusters(client, scopeId, VdnScopeActionExpand,
+				addedClusters.List()); err != nil {
+				return err
+			}
+		}
+
+		if removedClusters.Len() > 0 {
+			if err := changeTransportZoneClusters(client, scopeId, VdnScopeActionShrink,
+				removedClusters.List()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return resourceTransportZoneRead(d, meta)
+}
+
+func resourceTransportZoneDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := nsxClient(meta, d.Get("universal").(bool))
+
+	deleteUri := fmt.Sprintf(VdnScopeUriLocFormat, client.MgrConfig.Uri, d.Id())
+	err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return nsxDelete(client, deleteUri)
+	})
+	if err != nil && !isNotFoundError(err) {
+		log.Printf("[ERROR] Deleting Transport Zone '%s' failed with error : %v", d.Id(), err)
+		return err
+	}
+
+	log.Printf("[INFO] Transport Zone deleted :%s", d.Id())
+	d.SetId("")
+	return nil
+}
+
+func changeTransportZoneClusters(client *govnsx.Client, scopeId string, action string,
+	clusterIds []interface{}) error {
+
+	scopeSpec := &vdnScope{
+		ObjectId: scopeId,
+		Clusters: expandVdnScopeClusters(clusterIds),
+	}
+
+	log.Printf("[INFO] Transport Zone %s: %s clusters %v", scopeId, action, clusterIds)
+
+	postUri := fmt.Sprintf(VdnScopeActionUriFormat, client.MgrConfig.Uri, scopeId, action)
+	if _, _, err := nsxPost(client, postUri, scopeSpec); err != nil {
+		log.Printf("[ERROR] Transport Zone '%s' %s failed with error : '%v'",
+			scopeId, action, err)
+		return err
+	}
+
+	return nil
+}
+
+func getTransportZone(client *govnsx.Client, scopeId string) (*vdnScope, error) {
+
+	getUri := fmt.Sprintf(VdnScopeUriLocFormat, client.MgrConfig.Uri, scopeId)
+
+	scope := &vdnScope{}
+	if err := nsxGet(client, getUri, scope); err != nil {
+		log.Printf("[ERROR] Retriving Transport Zone '%s' failed with error : '%v'", scopeId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] Transport Zone details of '%s': '%v'", scopeId, scope)
+	return scope, nil
+}
+
+func listTransportZones(client *govnsx.Client) ([]vdnScope, error) {
+
+	getUri := fmt.Sprintf(VdnScopeUriFormat, client.MgrConfig.Uri)
+
+	scopes := &vdnScopes{}
+	if err := nsxGet(client, getUri, scopes); err != nil {
+		log.Printf("[ERROR] Retriving Transport Zones failed with error : '%v'", err)
+		return nil, err
+	}
+
+	return scopes.VdnScopes, nil
+}
+
+func expandVdnScopeClusters(clusterIds []interface{}) []vdnScopeCluster {
+
+	clusters := []vdnScopeCluster{}
+	for _, id := range clusterIds {
+		clusters = append(clusters, vdnScopeCluster{Cluster: objectRef{ObjectId: id.(string)}})
+	}
+	return clusters
+}
+
+func flattenVdnScopeClusters(clusters []vdnScopeCluster) []string {
+
+	clusterIds := []string{}
+	for _, cluster := range clusters {
+		clusterIds = append(clusterIds, cluster.Cluster.ObjectId)
+	}
+	return clusterIds
+}
+
+func validateControlPlaneMode(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	found := false
+
+	for _, t := range controlPlaneModesList {
+		if t == value {
+			found = true
+		}
+	}
+	if !found {
+		errors = append(errors, fmt.Errorf(
+			"%s: Supported values are %s", k, strings.Join(controlPlaneModesList, ", ")))
+	}
+
+	return
+}
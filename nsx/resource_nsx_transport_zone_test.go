@@ -0,0 +1,188 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+var secondClusterId = testAccEnvOrMock("NSX_SECOND_CLUSTER_ID", "domain-c8")
+
+const testAccCheckTransportZoneConf = `
+resource "nsxv_transport_zone" "tz" {
+    name = "%s"
+    description = "%s"
+    control_plane_mode = "%s"
+    cluster_ids = [%s]
+}
+`
+
+func TestAccNsxTransportZone_ValidatorFunc(t *testing.T) {
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "control_plane_mode", validatorFn: validateControlPlaneMode,
+			values: []attributeProperty{
+				{value: "UNICAST_MODE", successCase: true},
+				{value: "HYBRID_MODE", successCase: true},
+				{value: "MULTICAST_MODE", successCase: true},
+				{value: "unicast", expErr: "Supported values are"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+func TestAccNsxTransportZone_ClustersXML(t *testing.T) {
+
+	scope := &vdnScope{
+		Name: "tz",
+		Clusters: expandVdnScopeClusters([]interface{}{
+			"domain-c7", "domain-c8"}),
+	}
+
+	outputXML, err := xml.Marshal(scope)
+	if err != nil {
+		t.Fatalf("Unable to encode transport zone: %s", err)
+	}
+
+	expected := "<clusters><cluster><cluster><objectId>domain-c7</objectId></cluster></cluster>" +
+		"<cluster><cluster><objectId>domain-c8</objectId></cluster></cluster></clusters>"
+	if !strings.Contains(string(outputXML), expected) {
+		t.Fatalf("Unexpected transport zone XML: %s", outputXML)
+	}
+
+	decoded := &vdnScope{}
+	if err := xml.Unmarshal(outputXML, decoded); err != nil {
+		t.Fatalf("Unable to decode transport zone: %s", err)
+	}
+
+	clusterIds := flattenVdnScopeClusters(decoded.Clusters)
+	if len(clusterIds) != 2 || clusterIds[0] != "domain-c7" || clusterIds[1] != "domain-c8" {
+		t.Fatalf("Unexpected cluster ids: %v", clusterIds)
+	}
+}
+
+func TestAccNsxTransportZone_Basic(t *testing.T) {
+
+	resourceName := "nsxv_transport_zone.tz"
+	clusters := func(ids ...string) string {
+		return `"` + strings.Join(ids, `", "`) + `"`
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckTransportZone(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckTransportZoneDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckTransportZoneConf, "tf-acc-tz", "created",
+					"UNICAST_MODE", clusters(clusterId)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", "tf-acc-tz"),
+					resource.TestCheckResourceAttr(resourceName, "universal", "false"),
+					testAccCheckTransportZone(resourceName, &vdnScope{Name: "tf-acc-tz",
+						Description: "created", ControlPlaneMode: "UNICAST_MODE"},
+						clusterId),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckTransportZoneConf, "tf-acc-tz-upd", "updated",
+					"HYBRID_MODE", clusters(clusterId, secondClusterId)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "cluster_ids.#", "2"),
+					testAccCheckTransportZone(resourceName, &vdnScope{Name: "tf-acc-tz-upd",
+						Description: "updated", ControlPlaneMode: "HYBRID_MODE"},
+						clusterId, secondClusterId),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckTransportZoneConf, "tf-acc-tz-upd", "updated",
+					"HYBRID_MODE", clusters(secondClusterId)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "cluster_ids.#", "1"),
+					testAccCheckTransportZone(resourceName, &vdnScope{Name: "tf-acc-tz-upd",
+						Description: "updated", ControlPlaneMode: "HYBRID_MODE"},
+						secondClusterId),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+// testAccCheckTransportZone compares the transport zone read from NSX with
+// the expected attributes and clusters.
+func testAccCheckTransportZone(resourceName string, expected *vdnScope,
+	clusterIds ...string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		client := testAccProvider.Meta().(*govnsx.Client)
+
+		scope, err := getTransportZone(client, rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if scope.Name != expected.Name || scope.Description != expected.Description ||
+			scope.ControlPlaneMode != expected.ControlPlaneMode {
+			return fmt.Errorf("Unexpected Transport Zone %#v", scope)
+		}
+
+		found := flattenVdnScopeClusters(scope.Clusters)
+		if len(found) != len(clusterIds) {
+			return fmt.Errorf("Transport Zone has clusters %v, expected %v", found, clusterIds)
+		}
+		for _, id := range clusterIds {
+			if !containsVdnScopeCluster(scope.Clusters,
+				vdnScopeCluster{Cluster: objectRef{ObjectId: id}}) {
+				return fmt.Errorf("Transport Zone has clusters %v, expected %v", found, clusterIds)
+			}
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckTransportZoneDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_transport_zone" {
+			continue
+		}
+
+		_, err := getTransportZone(client, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Transport Zone %s still exists", rs.Primary.ID)
+		}
+		if !isNotFoundError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func testAccPreCheckTransportZone(t *testing.T) {
+
+	testAccPreCheck(t)
+
+	if clusterId == "" || secondClusterId == "" {
+		t.Fatal("NSX_CLUSTER_ID and NSX_SECOND_CLUSTER_ID must be set for acceptance tests")
+	}
+}
@@ -0,0 +1,413 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	NwFabricClusterUriFormat = "%s/api/2.0/nwfabric/clusters/%s"
+	VdsContextsUriFormat     = "%s/api/2.0/vdn/switches"
+	VdsContextUriFormat      = "%s/api/2.0/vdn/switches/%s"
+
+	ConfigSpecClassClusterMapping = "clusterMappingSpec"
+	ConfigSpecClassVdsContext     = "vdsContext"
+
+	VxlanTeamingFailoverOrder     = "FAILOVER_ORDER"
+	VxlanTeamingEtherChannel      = "ETHER_CHANNEL"
+	VxlanTeamingLacpActive        = "LACP_ACTIVE"
+	VxlanTeamingLacpPassive       = "LACP_PASSIVE"
+	VxlanTeamingLacpV2            = "LACP_V2"
+	VxlanTeamingLoadBalanceSrcId  = "LOADBALANCE_SRCID"
+	VxlanTeamingLoadBalanceSrcMac = "LOADBALANCE_SRCMAC"
+
+	VxlanDefaultMtu = 1600
+	VxlanMinMtu     = 1550
+	VxlanMaxMtu     = 9000
+)
+
+var vxlanTeamingList = []string{
+	VxlanTeamingFailoverOrder,
+	VxlanTeamingEtherChannel,
+	VxlanTeamingLacpActive,
+	VxlanTeamingLacpPassive,
+	VxlanTeamingLacpV2,
+	VxlanTeamingLoadBalanceSrcId,
+	VxlanTeamingLoadBalanceSrcMac,
+}
+
+// Only the load balancing teaming policies can use more than one VTEP per
+// host.
+var vxlanMultiVtepTeamings = []string{
+	VxlanTeamingLoadBalanceSrcId,
+	VxlanTeamingLoadBalanceSrcMac,
+}
+
+// vdsContext is the VXLAN configuration of a distributed switch, shared by
+// all the clusters using the switch.
+type vdsContext struct {
+	XMLName xml.Name  `xml:"vdsContext"`
+	Switch  objectRef `xml:"switch"`
+	Mtu     int       `xml:"mtu"`
+	Teaming string    `xml:"teaming"`
+}
+
+func resourceVxlanClusterConfig() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVxlanClusterConfigCreate,
+		Read:   resourceVxlanClusterConfigRead,
+		Update: resourceVxlanClusterConfigUpdate,
+		Delete: resourceVxlanClusterConfigDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(30 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"cluster_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"switch_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"vlan_id": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ForceNew:     true,
+				ValidateFunc: validateVlanId,
+			},
+
+			"mtu": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      VxlanDefaultMtu,
+				ValidateFunc: validateVxlanMtu,
+			},
+
+			"teaming": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      VxlanTeamingFailoverOrder,
+				ForceNew:     true,
+				ValidateFunc: validateVxlanTeaming,
+			},
+
+			"vmknic_count": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  1,
+				ForceNew: true,
+			},
+
+			// The VTEPs get their address from DHCP when no IP pool is
+			// given.
+			"ip_pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			// Read sets it to false while a host of the cluster has no
+			// VTEP, e.g. a host added to the cluster, so the next apply
+			// configures it.
+			"ready": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"host_status": nwFabricHostStatusSchema(),
+		},
+	}
+}
+
+// expandVxlanClusterConfig builds the VXLAN configuration of the cluster
+// and of its distributed switch.
+func expandVxlanClusterConfig(d *schema.ResourceData) (*nwFabricFeatureConfig, error) {
+
+	clusterId := d.Get("cluster_id").(string)
+	switchId := d.Get("switch_id").(string)
+	vlanId := d.Get("vlan_id").(int)
+	teaming := d.Get("teaming").(string)
+	vmknicCount := d.Get("vmknic_count").(int)
+
+	if vmknicCount < 1 {
+		return nil, fmt.Errorf("vmknic_count %d is not valid, it must be at least 1.",
+			vmknicCount)
+	}
+
+	multiVtep := false
+	for _, t := range vxlanMultiVtepTeamings {
+		if t == teaming {
+			multiVtep = true
+		}
+	}
+	if vmknicCount > 1 && !multiVtep {
+		return nil, fmt.Errorf("Teaming policy '%s' supports a single VTEP per host, "+
+			"vmknic_count %d needs one of %s.", teaming, vmknicCount,
+			strings.Join(vxlanMultiVtepTeamings, ", "))
+	}
+
+	return &nwFabricFeatureConfig{
+		FeatureId: NwFabricFeatureVxlan,
+		ResourceConfigs: []nwFabricResourceConfig{
+			{
+				ResourceId: clusterId,
+				ConfigSpec: &nwFabricConfigSpec{
+					Class:       ConfigSpecClassClusterMapping,
+					Switch:      &objectRef{ObjectId: switchId},
+					VlanId:      &vlanId,
+					VmknicCount: vmknicCount,
+					IpPoolId:    d.Get("ip_pool_id").(string),
+				},
+			},
+			{
+				ResourceId: switchId,
+				ConfigSpec: &nwFabricConfigSpec{
+					Class:   ConfigSpecClassVdsContext,
+					Switch:  &objectRef{ObjectId: switchId},
+					Mtu:     d.Get("mtu").(int),
+					Teaming: teaming,
+				},
+			},
+		},
+	}, nil
+}
+
+// configureVxlanCluster configures VXLAN on the hosts of the cluster which
+// do not have VTEPs and waits for all hosts to be ready.
+func configureVxlanCluster(client *govnsx.Client, config *nwFabricFeatureConfig,
+	timeout time.Duration) error {
+
+	clusterId := config.ResourceConfigs[0].ResourceId
+
+	jobId, err := configureNwFabric(client, config)
+	if err != nil {
+		log.Printf("[ERROR] Configuring VXLAN on cluster '%s' failed. %v", clusterId, err)
+		return err
+	}
+
+	log.Printf("[INFO] VXLAN configuration of cluster %s started, job id: %s", clusterId, jobId)
+
+	return waitForNwFabricHosts(client, clusterId, NwFabricFeatureVxlan, true, timeout)
+}
+
+func resourceVxlanClusterConfigCreate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	config, err := expandVxlanClusterConfig(d)
+	if err != nil {
+		log.Printf("[ERROR] Configuration validation failed.")
+		return err
+	}
+
+	clusterId := d.Get("cluster_id").(string)
+
+	// The id is set before waiting, a failed host leaves the cluster
+	// partially configured and tainted.
+	d.SetId(clusterId)
+
+	if err := configureVxlanCluster(client, config, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] VXLAN configured on cluster %s", clusterId)
+
+	return resourceVxlanClusterConfigRead(d, meta)
+}
+
+func resourceVxlanClusterConfigRead(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	spec, err := getVxlanClusterMapping(client, d.Id())
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] VXLAN configuration of cluster '%s' not found, removing from state",
+				d.Id())
+			d.SetId("")
+			return nil
+		}
+		return err
+	}
+
+	d.Set("cluster_id", d.Id())
+	d.Set("vmknic_count", spec.VmknicCount)
+	d.Set("ip_pool_id", spec.IpPoolId)
+	if spec.VlanId != nil {
+		d.Set("vlan_id", *spec.VlanId)
+	}
+
+	if spec.Switch != nil {
+		d.Set("switch_id", spec.Switch.ObjectId)
+
+		context, err := getVdsContext(client, spec.Switch.ObjectId)
+		if err != nil {
+			return err
+		}
+		d.Set("mtu", context.Mtu)
+		d.Set("teaming", context.Teaming)
+	}
+
+	return readNwFabricHosts(d, client, d.Id(), NwFabricFeatureVxlan)
+}
+
+func resourceVxlanClusterConfigUpdate(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	if d.HasChange("mtu") {
+		context := &vdsContext{
+			Switch:  objectRef{ObjectId: d.Get("switch_id").(string)},
+			Mtu:     d.Get("mtu").(int),
+			Teaming: d.Get("teaming").(string),
+		}
+
+		log.Printf("[INFO] Updating VXLAN configuration of switch %s : %#v",
+			context.Switch.ObjectId, context)
+
+		postUri := fmt.Sprintf(VdsContextsUriFormat, client.MgrConfig.Uri)
+		if _, _, err := nsxPost(client, postUri, context); err != nil {
+			log.Printf("[ERROR] Updating VXLAN configuration of switch '%s' failed with error : '%v'",
+				context.Switch.ObjectId, err)
+			return err
+		}
+	}
+
+	if d.HasChange("ready") {
+		log.Printf("[INFO] Configuring VXLAN on the hosts of cluster %s which are not ready",
+			d.Id())
+
+		config, err := expandVxlanClusterConfig(d)
+		if err != nil {
+			log.Printf("[ERROR] Configuration validation failed.")
+			return err
+		}
+
+		if err := configureVxlanCluster(client, config, d.Timeout(schema.TimeoutUpdate)); err != nil {
+			return err
+		}
+	}
+
+	return resourceVxlanClusterConfigRead(d, meta)
+}
+
+func resourceVxlanClusterConfigDelete(d *schema.ResourceData, meta interface{}) error {
+
+	client := meta.(*govnsx.Client)
+
+	config := &nwFabricFeatureConfig{
+		FeatureId:       NwFabricFeatureVxlan,
+		ResourceConfigs: []nwFabricResourceConfig{{ResourceId: d.Id()}},
+	}
+
+	// The VTEPs can not be removed while logical switches use them.
+	err := retryWhileInUse(d.Timeout(schema.TimeoutDelete), func() error {
+		return unconfigureNwFabric(client, config)
+	})
+	if err != nil {
+		if isNotFoundError(err) {
+			log.Printf("[WARN] VXLAN configuration of cluster '%s' not found", d.Id())
+			d.SetId("")
+			return nil
+		}
+		log.Printf("[ERROR] Unconfiguring VXLAN on cluster '%s' failed with error : %v",
+			d.Id(), err)
+		return err
+	}
+
+	if err := waitForNwFabricHosts(client, d.Id(), NwFabricFeatureVxlan, false,
+		d.Timeout(schema.TimeoutDelete)); err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] VXLAN unconfigured on cluster :%s", d.Id())
+	d.SetId("")
+	return nil
+}
+
+// getVxlanClusterMapping returns the VXLAN configuration of the cluster.
+func getVxlanClusterMapping(client *govnsx.Client,
+	clusterId string) (*nwFabricConfigSpec, error) {
+
+	getUri := fmt.Sprintf(NwFabricClusterUriFormat, client.MgrConfig.Uri, clusterId)
+
+	config := &nwFabricFeatureConfig{}
+	if err := nsxGet(client, getUri, config); err != nil {
+		log.Printf("[ERROR] Retriving VXLAN configuration of cluster '%s' failed with error : '%v'",
+			clusterId, err)
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] VXLAN configuration of cluster '%s': '%v'", clusterId, config)
+
+	for _, r := range config.ResourceConfigs {
+		if r.ConfigSpec != nil && r.ConfigSpec.Class == ConfigSpecClassClusterMapping {
+			return r.ConfigSpec, nil
+		}
+	}
+
+	return nil, &nsxAPIError{StatusCode: 404, Status: "404 Not Found", Uri: getUri,
+		Body: "VXLAN is not configured on the cluster"}
+}
+
+func getVdsContext(client *govnsx.Client, switchId string) (*vdsContext, error) {
+
+	getUri := fmt.Sprintf(VdsContextUriFormat, client.MgrConfig.Uri, switchId)
+
+	context := &vdsContext{}
+	if err := nsxGet(client, getUri, context); err != nil {
+		log.Printf("[ERROR] Retriving VXLAN configuration of switch '%s' failed with error : '%v'",
+			switchId, err)
+		return nil, err
+	}
+
+	return context, nil
+}
+
+func validateVxlanMtu(v interface{}, k string) (ws []string, errors []error) {
+
+	mtu := v.(int)
+	if mtu < VxlanMinMtu || mtu > VxlanMaxMtu {
+		errors = append(errors, fmt.Errorf(
+			"%s: MTU %d is not valid, VXLAN needs an MTU between %d and %d.",
+			k, mtu, VxlanMinMtu, VxlanMaxMtu))
+	}
+	return
+}
+
+func validateVxlanTeaming(v interface{}, k string) (ws []string, errors []error) {
+
+	value := v.(string)
+	found := false
+
+	for _, t := range vxlanTeamingList {
+		if t == value {
+			found = true
+		}
+	}
+	if !found {
+		errors = append(errors, fmt.Errorf(
+			"%s: Supported values are %s", k, strings.Join(vxlanTeamingList, ", ")))
+	}
+
+	return
+}
@@ -0,0 +1,163 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+var switchId = testAccEnvOrMock("NSX_VDS_ID", mockSwitchId)
+
+const testAccCheckVxlanClusterConfigConf = `
+resource "nsxv_cluster_preparation" "cluster" {
+    cluster_id = "%s"
+}
+
+resource "nsxv_vxlan_cluster_config" "vxlan" {
+    cluster_id = "${nsxv_cluster_preparation.cluster.cluster_id}"
+    switch_id = "%s"
+    vlan_id = 100
+    mtu = %d
+}
+`
+
+func TestAccNsxVxlanClusterConfig_ValidatorFunc(t *testing.T) {
+	var validatorCases = []attributeValueValidationTestSpec{
+		{name: "mtu", validatorFn: validateVxlanMtu,
+			values: []attributeProperty{
+				{value: 1600, successCase: true},
+				{value: 9000, successCase: true},
+				{value: 1500, expErr: "is not valid"},
+				{value: 9001, expErr: "is not valid"},
+			},
+		},
+		{name: "teaming", validatorFn: validateVxlanTeaming,
+			values: []attributeProperty{
+				{value: "FAILOVER_ORDER", successCase: true},
+				{value: "LOADBALANCE_SRCID", successCase: true},
+				{value: "failover", expErr: "Supported values are"},
+			},
+		},
+	}
+
+	verifySchemaValidationFunctions(t, validatorCases)
+}
+
+func TestAccNsxVxlanClusterConfig_Expand(t *testing.T) {
+
+	cases := []struct {
+		teaming     string
+		vmknicCount int
+		expErr      string
+	}{
+		{VxlanTeamingFailoverOrder, 1, ""},
+		{VxlanTeamingLoadBalanceSrcId, 2, ""},
+		{VxlanTeamingLacpV2, 2, "supports a single VTEP per host"},
+		{VxlanTeamingFailoverOrder, 0, "must be at least 1"},
+	}
+
+	for _, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceVxlanClusterConfig().Schema,
+			map[string]interface{}{
+				"cluster_id":   mockClusterId,
+				"switch_id":    mockSwitchId,
+				"teaming":      c.teaming,
+				"vmknic_count": c.vmknicCount,
+			})
+
+		config, err := expandVxlanClusterConfig(d)
+		if c.expErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expErr) {
+				t.Fatalf("%s/%d: expected error '%s', got '%v'", c.teaming, c.vmknicCount,
+					c.expErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s/%d: unexpected error %s", c.teaming, c.vmknicCount, err)
+		}
+
+		outputXML, err := xml.Marshal(config)
+		if err != nil {
+			t.Fatalf("Unable to encode VXLAN configuration: %s", err)
+		}
+
+		// VLAN 0 is sent, the VTEPs use DHCP without an IP pool.
+		expected := fmt.Sprintf("<resourceId>%s</resourceId><configSpec class=\"clusterMappingSpec\">"+
+			"<switch><objectId>%s</objectId></switch><vlanId>0</vlanId><vmknicCount>%d</vmknicCount>"+
+			"</configSpec>", mockClusterId, mockSwitchId, c.vmknicCount)
+		if !strings.Contains(string(outputXML), expected) {
+			t.Fatalf("Unexpected VXLAN configuration XML: %s", outputXML)
+		}
+	}
+}
+
+func TestAccNsxVxlanClusterConfig_Basic(t *testing.T) {
+
+	resourceName := "nsxv_vxlan_cluster_config.vxlan"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheckVxlanClusterConfig(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVxlanClusterConfigDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVxlanClusterConfigConf, clusterId, switchId, 1600),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "vlan_id", "100"),
+					resource.TestCheckResourceAttr(resourceName, "mtu", "1600"),
+					resource.TestCheckResourceAttr(resourceName, "teaming",
+						VxlanTeamingFailoverOrder),
+					resource.TestCheckResourceAttr(resourceName, "ready", "true"),
+					resource.TestCheckResourceAttr(resourceName, "host_status.0.status",
+						NwFabricStatusGreen),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVxlanClusterConfigConf, clusterId, switchId, 9000),
+				Check:  resource.TestCheckResourceAttr(resourceName, "mtu", "9000"),
+			},
+			resource.TestStep{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccPreCheckVxlanClusterConfig(t *testing.T) {
+
+	testAccPreCheckCluster(t)
+
+	if switchId == "" {
+		t.Fatal("NSX_VDS_ID must be set for acceptance tests")
+	}
+}
+
+func testAccCheckVxlanClusterConfigDestroy(s *terraform.State) error {
+
+	client := testAccProvider.Meta().(*govnsx.Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "nsxv_vxlan_cluster_config" {
+			continue
+		}
+
+		_, err := getVxlanClusterMapping(client, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("VXLAN is still configured on cluster %s", rs.Primary.ID)
+		}
+		if !isNotFoundError(err) {
+			return err
+		}
+	}
+
+	return testAccCheckClusterPreparationDestroy(s)
+}
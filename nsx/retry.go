@@ -0,0 +1,182 @@
+package nsx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultMaxRetries    = 4
+	DefaultRetryMinDelay = 500  // milliseconds
+	DefaultRetryMaxDelay = 5000 // milliseconds
+)
+
+// NSX error codes returned while an edge is busy with another operation or
+// is being (re)deployed. The request did not change anything and can be
+// sent again as is.
+var nsxRetryableErrorCodes = map[int]string{
+	10220: "edge is busy with another operation",
+	10164: "edge appliance is being deployed",
+	14039: "edge is being upgraded",
+}
+
+// Messages used by NSX for the same conditions when no known error code
+// is returned.
+var nsxRetryableErrorMessages = []string{
+	"is busy",
+	"another operation",
+	"operation in progress",
+	"is being redeployed",
+}
+
+// nsxError is the error document returned by NSX Manager.
+type nsxError struct {
+	XMLName    xml.Name `xml:"error"`
+	Details    string   `xml:"details"`
+	ErrorCode  int      `xml:"errorCode"`
+	ModuleName string   `xml:"moduleName"`
+}
+
+func parseNsxError(body []byte) *nsxError {
+
+	nsxErr := &nsxError{}
+	if err := xml.Unmarshal(body, nsxErr); err != nil {
+		return nil
+	}
+	return nsxErr
+}
+
+// isIdempotentMethod reports whether sending the request again cannot
+// create a second object. POSTs create objects, allocate addresses or
+// deploy appliances, and NSX may have done so before the response was lost.
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRetryableResponse reports whether a failed NSX API call can be retried.
+// Gateway errors and busy or in progress messages are only retried for
+// idempotent methods, the other ones are only retried on the NSX error codes
+// telling the request was rejected before anything was changed.
+func isRetryableResponse(method string, statusCode int, body []byte) bool {
+
+	idempotent := isIdempotentMethod(method)
+
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return false
+	case statusCode == 502 || statusCode == 503 || statusCode == 504:
+		return idempotent
+	}
+
+	nsxErr := parseNsxError(body)
+	if nsxErr == nil {
+		return false
+	}
+
+	if _, ok := nsxRetryableErrorCodes[nsxErr.ErrorCode]; ok {
+		return true
+	}
+	if !idempotent {
+		return false
+	}
+
+	details := strings.ToLower(nsxErr.Details)
+	for _, msg := range nsxRetryableErrorMessages {
+		if strings.Contains(details, msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nsxRetryTransport sends the requests to NSX Manager again, with a capped
+// exponential backoff and jitter, while NSX Manager is busy. The retries are
+// not left to resty, which retries transport errors of every method.
+type nsxRetryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	minDelay   time.Duration
+	maxDelay   time.Duration
+}
+
+func (t *nsxRetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+
+		retry := false
+		if err != nil {
+			retry = isIdempotentMethod(req.Method)
+		} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, readErr := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if readErr != nil {
+				return nil, readErr
+			}
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+			retry = isRetryableResponse(req.Method, resp.StatusCode, body)
+		}
+
+		// A body which cannot be read again cannot be sent again.
+		if req.Body != nil && req.GetBody == nil {
+			retry = false
+		}
+
+		if !retry || attempt >= t.maxRetries {
+			return resp, err
+		}
+
+		if err != nil {
+			log.Printf("[WARN] %s %s failed with error : %v, retrying", req.Method, req.URL, err)
+		} else {
+			log.Printf("[WARN] NSX Manager returned %d for %s %s, retrying", resp.StatusCode,
+				req.Method, req.URL)
+		}
+
+		select {
+		case <-time.After(t.backoff(attempt)):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// backoff returns the delay before the retry following attempt, between
+// half and all of the exponential delay, and never below minDelay.
+func (t *nsxRetryTransport) backoff(attempt int) time.Duration {
+
+	delay := t.minDelay
+	for i := 0; i < attempt && delay < t.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	if delay < t.minDelay {
+		delay = t.minDelay
+	}
+	return delay
+}
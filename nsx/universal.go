@@ -0,0 +1,128 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+//
+// Universal objects of a cross-vCenter NSX deployment are replicated by the
+// primary NSX Manager to the secondary ones, which only hold read only
+// copies. They are created, changed and deleted through the primary NSX
+// Manager.
+//
+
+const (
+	UniversalSyncRoleUri = "%s/api/2.0/universalsync/configuration/role"
+
+	UniversalSyncRolePrimary    = "PRIMARY"
+	UniversalSyncRoleSecondary  = "SECONDARY"
+	UniversalSyncRoleStandalone = "STANDALONE"
+	UniversalSyncRoleTransit    = "TRANSIT"
+
+	UniversalScopeId = "universalroot-0"
+)
+
+type universalSyncRole struct {
+	XMLName xml.Name `xml:"universalSyncRole"`
+	Role    string   `xml:"role"`
+}
+
+// universalClients holds the client of the primary NSX Manager of each
+// provider client, when the provider is configured with a secondary one,
+// and the universal sync role of the NSX Managers.
+var universalClients = struct {
+	sync.Mutex
+	primary map[*govnsx.Client]*govnsx.Client
+	roles   map[*govnsx.Client]string
+}{
+	primary: make(map[*govnsx.Client]*govnsx.Client),
+	roles:   make(map[*govnsx.Client]string),
+}
+
+func setPrimaryClient(client *govnsx.Client, primary *govnsx.Client) {
+	universalClients.Lock()
+	defer universalClients.Unlock()
+
+	universalClients.primary[client] = primary
+}
+
+// nsxClient returns the client the calls of an object are sent through, the
+// one of the primary NSX Manager for a universal object.
+func nsxClient(meta interface{}, universal bool) *govnsx.Client {
+
+	client := meta.(*govnsx.Client)
+	if !universal {
+		return client
+	}
+
+	universalClients.Lock()
+	defer universalClients.Unlock()
+
+	if primary, ok := universalClients.primary[client]; ok {
+		return primary
+	}
+	return client
+}
+
+// getUniversalSyncRole returns the role of the NSX Manager in the
+// cross-vCenter deployment, it is read once per client.
+func getUniversalSyncRole(client *govnsx.Client) (string, error) {
+
+	universalClients.Lock()
+	role, ok := universalClients.roles[client]
+	universalClients.Unlock()
+	if ok {
+		return role, nil
+	}
+
+	getUri := fmt.Sprintf(UniversalSyncRoleUri, client.MgrConfig.Uri)
+
+	syncRole := &universalSyncRole{}
+	if err := nsxGet(client, getUri, syncRole); err != nil {
+		log.Printf("[ERROR] Retriving universal sync role of NSX Manager '%s' failed with error : '%v'",
+			client.MgrConfig.Uri, err)
+		return "", err
+	}
+
+	log.Printf("[INFO] NSX Manager '%s' universal sync role: %s", client.MgrConfig.Uri,
+		syncRole.Role)
+
+	universalClients.Lock()
+	universalClients.roles[client] = syncRole.Role
+	universalClients.Unlock()
+
+	return syncRole.Role, nil
+}
+
+// checkUniversalChange rejects at plan time the changes of a universal
+// object which would not be sent to the primary NSX Manager.
+func checkUniversalChange(d *schema.ResourceDiff, meta interface{}) error {
+
+	if meta == nil || !d.Get("universal").(bool) {
+		return nil
+	}
+	if d.Id() != "" && len(d.GetChangedKeysPrefix("")) == 0 {
+		return nil
+	}
+
+	client := nsxClient(meta, true)
+
+	role, err := getUniversalSyncRole(client)
+	if err != nil {
+		return err
+	}
+
+	if role != UniversalSyncRolePrimary {
+		return fmt.Errorf("universal: NSX Manager '%s' has the role %s, universal objects "+
+			"can only be changed through the primary NSX Manager, set primary_nsx_manager_uri",
+			client.MgrConfig.Uri, role)
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package nsx
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const testAccCheckUniversalConf = `
+resource "nsxv_transport_zone" "utz" {
+    name = "tf-acc-utz"
+    cluster_ids = ["%s"]
+    universal = true
+}
+
+resource "nsxv_logical_switch" "uls" {
+    name = "tf-acc-uls"
+    scope_id = "${nsxv_transport_zone.utz.id}"
+    tenant_id = "tf-acc"
+    universal = true
+}
+
+resource "nsxv_mac_set" "umacset" {
+    name = "tf-acc-umacset"
+    mac_addresses = ["00:50:56:aa:bb:01"]
+    universal = true
+}
+
+resource "nsxv_mac_set" "macset" {
+    name = "tf-acc-macset"
+    mac_addresses = ["00:50:56:aa:bb:02"]
+}
+
+resource "nsxv_edge" "udlr" {
+    name = "tf-acc-udlr"
+    type = "distributedRouter"
+    universal = true
+    appliances {
+        appliance {
+            resource_pool_id = "%s"
+            datastore_id = "%s"
+        }
+    }
+}
+`
+
+const testAccCheckUniversalConf_macSet = `
+resource "nsxv_mac_set" "umacset" {
+    name = "tf-acc-umacset"
+    universal = true
+}
+`
+
+const testAccCheckUniversalConf_esg = `
+resource "nsxv_edge" "uesg" {
+    name = "tf-acc-uesg"
+    type = "gatewayServices"
+    universal = true
+    appliances {
+        appliance {
+            resource_pool_id = "%s"
+            datastore_id = "%s"
+        }
+    }
+}
+`
+
+func TestAccNsxUniversal_Primary(t *testing.T) {
+
+	var primary, secondary *mockNsxManager
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { primary, secondary = testAccPreCheckUniversal(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckUniversalDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckUniversalConf, clusterId,
+					resourcePoolId, datastoreId),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("nsxv_transport_zone.utz", "universal", "true"),
+					resource.TestCheckResourceAttr("nsxv_mac_set.umacset", "universal", "true"),
+					resource.TestCheckResourceAttr("nsxv_mac_set.umacset", "scope_id", DefaultScopeId),
+					resource.TestCheckResourceAttr("nsxv_mac_set.macset", "universal", "false"),
+					resource.TestCheckResourceAttr("nsxv_edge.udlr", "universal", "true"),
+					func(s *terraform.State) error {
+						if primary == nil {
+							return nil
+						}
+						return testAccCheckUniversalMockObjects(s, primary, secondary)
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccNsxUniversal_Secondary(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheckUniversalSecondary(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccCheckUniversalConf_macSet,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("has the role SECONDARY, universal objects can only be changed through the primary NSX Manager"),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckUniversalConf_esg, resourcePoolId, datastoreId),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("universal: only supported on distributedRouter edges"),
+			},
+		},
+	})
+}
+
+// testAccCheckUniversalMockObjects checks the universal objects were created
+// on the primary NSX Manager and the local ones on the secondary.
+func testAccCheckUniversalMockObjects(s *terraform.State, primary *mockNsxManager,
+	secondary *mockNsxManager) error {
+
+	primary.Lock()
+	defer primary.Unlock()
+	secondary.Lock()
+	defer secondary.Unlock()
+
+	objects := []struct {
+		resourceName string
+		primary      bool
+		found        func(m *mockNsxManager, id string) bool
+	}{
+		{"nsxv_transport_zone.utz", true,
+			func(m *mockNsxManager, id string) bool { return m.scopes[id] != nil }},
+		{"nsxv_logical_switch.uls", true,
+			func(m *mockNsxManager, id string) bool { return m.virtualWires[id] != nil }},
+		{"nsxv_mac_set.umacset", true,
+			func(m *mockNsxManager, id string) bool { return m.macSets[id] != nil }},
+		{"nsxv_mac_set.macset", false,
+			func(m *mockNsxManager, id string) bool { return m.macSets[id] != nil }},
+		{"nsxv_edge.udlr", true,
+			func(m *mockNsxManager, id string) bool { return m.edges[id] != nil }},
+	}
+
+	for _, o := range objects {
+		rs, ok := s.RootModule().Resources[o.resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", o.resourceName)
+		}
+		id := rs.Primary.ID
+		switch rs.Type {
+		case "nsxv_logical_switch":
+			id = rs.Primary.Attributes["virtual_wire_id"]
+		case "nsxv_edge":
+			id = rs.Primary.Attributes["edge_id"]
+		}
+
+		onPrimary, onSecondary := o.found(primary, id), o.found(secondary, id)
+		if onPrimary != o.primary || onSecondary == o.primary {
+			return fmt.Errorf("%s (%s) found on primary: %t, on secondary: %t",
+				o.resourceName, id, onPrimary, onSecondary)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckUniversalDestroy(s *terraform.State) error {
+
+	meta := testAccProvider.Meta()
+
+	for _, rs := range s.RootModule().Resources {
+		client := nsxClient(meta, rs.Primary.Attributes["universal"] == "true")
+
+		var err error
+		switch rs.Type {
+		case "nsxv_transport_zone":
+			_, err = getTransportZone(client, rs.Primary.ID)
+		case "nsxv_mac_set":
+			_, err = getMacSet(client, rs.Primary.ID)
+		case "nsxv_edge":
+			_, err = getEdge(client, rs.Primary.Attributes["edge_id"])
+		default:
+			continue
+		}
+
+		if err == nil {
+			return fmt.Errorf("%s %s still exists", rs.Type, rs.Primary.ID)
+		}
+		if !isNotFoundError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// testAccPreCheckUniversal points the provider at a secondary NSX Manager
+// and at the primary one of its cross-vCenter deployment. It returns the
+// mock NSX Managers, or nil when running against real ones.
+func testAccPreCheckUniversal(t *testing.T) (*mockNsxManager, *mockNsxManager) {
+
+	if !isTestAccMock() {
+		testAccPreCheckEdge(t)
+		if os.Getenv("NSXV_PRIMARY_NSX_MANAGER_URI") == "" || clusterId == "" {
+			t.Fatal("NSXV_PRIMARY_NSX_MANAGER_URI and NSX_CLUSTER_ID must be set for acceptance tests")
+		}
+		return nil, nil
+	}
+
+	primary := newMockNsxManager()
+	t.Cleanup(primary.Server.Close)
+	primary.Role = UniversalSyncRolePrimary
+	// Keep the object ids of the two NSX Managers apart.
+	primary.nextId = 1000
+
+	secondary := testAccMockNsxManager(t)
+	secondary.Role = UniversalSyncRoleSecondary
+
+	t.Setenv("NSXV_PRIMARY_NSX_MANAGER_URI", primary.Server.URL)
+
+	return primary, secondary
+}
+
+// testAccPreCheckUniversalSecondary points the provider at a secondary NSX
+// Manager only.
+func testAccPreCheckUniversalSecondary(t *testing.T) {
+
+	if !isTestAccMock() {
+		testAccPreCheckEdge(t)
+		if os.Getenv("NSX_SECONDARY_NSX_MANAGER_URI") == "" {
+			t.Fatal("NSX_SECONDARY_NSX_MANAGER_URI must be set for acceptance tests")
+		}
+		t.Setenv("NSXV_NSX_MANAGER_URI", os.Getenv("NSX_SECONDARY_NSX_MANAGER_URI"))
+		t.Setenv("NSXV_PRIMARY_NSX_MANAGER_URI", "")
+		return
+	}
+
+	m := testAccMockNsxManager(t)
+	m.Role = UniversalSyncRoleSecondary
+}
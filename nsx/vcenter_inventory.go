@@ -0,0 +1,81 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/IBM-tfproviders/govnsx"
+)
+
+//
+// Lookup of vCenter inventory objects by name, through the scoping objects
+// NSX Manager synchronizes from its vCenter.
+//
+
+const (
+	ScopingObjectsUri = "%s/api/2.0/services/usermgmt/scopingobjects"
+
+	InventoryTypeCluster      = "ClusterComputeResource"
+	InventoryTypeResourcePool = "ResourcePool"
+	InventoryTypeDatastore    = "Datastore"
+	InventoryTypeHost         = "HostSystem"
+	InventoryTypeFolder       = "Folder"
+)
+
+type inventoryObject struct {
+	ObjectId       string `xml:"objectId"`
+	ObjectTypeName string `xml:"objectTypeName"`
+	Name           string `xml:"name"`
+}
+
+type inventoryObjectList struct {
+	XMLName xml.Name          `xml:"list"`
+	Objects []inventoryObject `xml:"object"`
+}
+
+func listInventoryObjects(client *govnsx.Client) ([]inventoryObject, error) {
+
+	getUri := fmt.Sprintf(ScopingObjectsUri, client.MgrConfig.Uri)
+
+	list := &inventoryObjectList{}
+	if err := nsxGet(client, getUri, list); err != nil {
+		log.Printf("[ERROR] Retriving vCenter inventory failed with error : '%v'", err)
+		return nil, err
+	}
+
+	return list.Objects, nil
+}
+
+// findInventoryObject returns the id of the only object of one of the types
+// named name.
+func findInventoryObject(objects []inventoryObject, types []string,
+	name string) (string, error) {
+
+	ids := []string{}
+	for _, object := range objects {
+		if object.Name != name {
+			continue
+		}
+		for _, t := range types {
+			if object.ObjectTypeName == t {
+				ids = append(ids, object.ObjectId)
+				break
+			}
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("No %s named '%s' found in the vCenter inventory",
+			strings.Join(types, " or "), name)
+	case 1:
+		log.Printf("[DEBUG] Resolved %s '%s' to '%s'", strings.Join(types, " or "),
+			name, ids[0])
+		return ids[0], nil
+	}
+
+	return "", fmt.Errorf("%s name '%s' is ambiguous, it matches %s",
+		strings.Join(types, " or "), name, strings.Join(ids, ", "))
+}
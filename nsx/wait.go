@@ -0,0 +1,187 @@
+package nsx
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/IBM-tfproviders/govnsx/nsxresource"
+	"github.com/IBM-tfproviders/govnsx/nsxtypes"
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+const (
+	EdgeStatusUriFormat = "%s/api/4.0/edges/%s/status"
+
+	EdgeStatusDeployed   = "deployed"
+	EdgeStatusActive     = "active"
+	EdgeStatusUndeployed = "undeployed"
+
+	EdgePublishStatusApplied = "APPLIED"
+
+	edgeStatePending = "pending"
+	edgeStateReady   = "ready"
+	edgeStateDeleted = "deleted"
+)
+
+// Smallest interval between two edge status polls. Deploying an appliance
+// takes minutes, there is no point in asking more often.
+var edgeStatusPollInterval = 10 * time.Second
+
+// Errors NSX returns while an object is still referenced by another one,
+// which is usually being deleted in parallel.
+var nsxInUseErrorMessages = []string{
+	"in use",
+	"is being used",
+	"devices may present",
+}
+
+// edgeStatus is the document returned by the edge status API.
+type edgeStatus struct {
+	XMLName       xml.Name `xml:"edgeStatus"`
+	EdgeStatus    string   `xml:"edgeStatus"`
+	PublishStatus string   `xml:"publishStatus"`
+	Version       string   `xml:"version"`
+}
+
+func getEdgeStatus(client *govnsx.Client, edgeId string) (*edgeStatus, error) {
+
+	getUri := fmt.Sprintf(EdgeStatusUriFormat, client.MgrConfig.Uri, edgeId)
+
+	status := &edgeStatus{}
+	if err := nsxGet(client, getUri, status); err != nil {
+		log.Printf("[ERROR] Retriving status of Edge '%s' failed with error : '%v'", edgeId, err)
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// edgeStateRefreshFunc reports an edge as ready once the last configuration
+// change has been published to it and, when deployed is set, its appliances
+// are up.
+func edgeStateRefreshFunc(client *govnsx.Client, edgeId string,
+	deployed bool) resource.StateRefreshFunc {
+
+	return func() (interface{}, string, error) {
+
+		edgeCfg, err := nsxresource.NewEdge(client).Get(edgeId)
+		if err != nil {
+			log.Printf("[ERROR] Retriving Edge '%s' failed with error : '%v'", edgeId, err)
+			return nil, "", err
+		}
+
+		status, err := getEdgeStatus(client, edgeId)
+		if err != nil {
+			return nil, "", err
+		}
+
+		log.Printf("[DEBUG] Edge '%s' status: '%s', publish status: '%s'",
+			edgeId, edgeCfg.Status, status.PublishStatus)
+
+		if status.PublishStatus != EdgePublishStatusApplied {
+			return edgeCfg, edgeStatePending, nil
+		}
+
+		switch edgeCfg.Status {
+		case EdgeStatusDeployed, EdgeStatusActive:
+			return edgeCfg, edgeStateReady, nil
+		case EdgeStatusUndeployed:
+			if !deployed {
+				return edgeCfg, edgeStateReady, nil
+			}
+		}
+
+		return edgeCfg, edgeStatePending, nil
+	}
+}
+
+// waitForEdgeReady polls the edge until the last change has taken effect.
+func waitForEdgeReady(client *govnsx.Client, edgeId string, deployed bool,
+	timeout time.Duration) (*nsxtypes.Edge, error) {
+
+	log.Printf("[INFO] Waiting for Edge '%s' to be ready", edgeId)
+
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{edgeStatePending},
+		Target:     []string{edgeStateReady},
+		Refresh:    edgeStateRefreshFunc(client, edgeId, deployed),
+		Timeout:    timeout,
+		MinTimeout: edgeStatusPollInterval,
+	}
+
+	edgeCfg, err := stateConf.WaitForState()
+	if err != nil {
+		log.Printf("[ERROR] Waiting for Edge '%s' failed with error : '%v'", edgeId, err)
+		return nil, fmt.Errorf("Error waiting for Edge '%s' to be ready: %s", edgeId, err)
+	}
+
+	return edgeCfg.(*nsxtypes.Edge), nil
+}
+
+// waitForEdgeDeleted polls the edge until NSX Manager no longer knows it.
+func waitForEdgeDeleted(client *govnsx.Client, edgeId string, timeout time.Duration) error {
+
+	log.Printf("[INFO] Waiting for Edge '%s' to be deleted", edgeId)
+
+	getUri := fmt.Sprintf(nsxtypes.EdgeUriLocFormat, client.MgrConfig.Uri, edgeId)
+
+	stateConf := &resource.StateChangeConf{
+		Pending: []string{edgeStatePending},
+		Target:  []string{edgeStateDeleted},
+		Refresh: func() (interface{}, string, error) {
+			err := nsxGet(client, getUri, nil)
+			if isNotFoundError(err) {
+				return edgeId, edgeStateDeleted, nil
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			return edgeId, edgeStatePending, nil
+		},
+		Timeout:    timeout,
+		MinTimeout: edgeStatusPollInterval,
+	}
+
+	if _, err := stateConf.WaitForState(); err != nil {
+		log.Printf("[ERROR] Waiting for Edge '%s' deletion failed with error : '%v'", edgeId, err)
+		return fmt.Errorf("Error waiting for Edge '%s' to be deleted: %s", edgeId, err)
+	}
+
+	return nil
+}
+
+func isInUseError(err error) bool {
+
+	if err == nil {
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, inUse := range nsxInUseErrorMessages {
+		if strings.Contains(msg, inUse) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryWhileInUse calls f until it succeeds, fails with an error other than
+// an in use error, or the timeout runs out.
+func retryWhileInUse(timeout time.Duration, f func() error) error {
+
+	return resource.Retry(timeout, func() *resource.RetryError {
+		err := f()
+		if isInUseError(err) {
+			log.Printf("[WARN] Object is still in use, retrying: %v", err)
+			return resource.RetryableError(err)
+		}
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+		return nil
+	})
+}
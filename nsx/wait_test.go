@@ -0,0 +1,100 @@
+package nsx
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM-tfproviders/govnsx"
+	"github.com/IBM-tfproviders/govnsx/nsxresource"
+)
+
+func testAccWaitClient(t *testing.T) (*mockNsxManager, *govnsx.Client) {
+
+	interval := edgeStatusPollInterval
+	edgeStatusPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { edgeStatusPollInterval = interval })
+
+	return newMockNsxClient(t)
+}
+
+func TestAccNsxWait_EdgeReady(t *testing.T) {
+
+	m, client := testAccWaitClient(t)
+	m.pendingPolls[mockEdgeId] = 2
+
+	edgeCfg, err := waitForEdgeReady(client, mockEdgeId, true, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Waiting for edge failed with error: %s", err)
+	}
+	if edgeCfg.Id != mockEdgeId {
+		t.Fatalf("Unexpected edge: %#v", edgeCfg)
+	}
+	if m.pendingPolls[mockEdgeId] != 0 {
+		t.Fatalf("Edge ready before its change was published")
+	}
+}
+
+func TestAccNsxWait_EdgeReadyTimeout(t *testing.T) {
+
+	m, client := testAccWaitClient(t)
+	m.pendingPolls[mockEdgeId] = 1000
+
+	if _, err := waitForEdgeReady(client, mockEdgeId, true, 200*time.Millisecond); err == nil {
+		t.Fatalf("Waiting for a busy edge succeeded, expected a timeout")
+	}
+}
+
+func TestAccNsxWait_EdgeNotDeployed(t *testing.T) {
+
+	m, client := testAccWaitClient(t)
+	m.edges[mockEdgeId].Status = EdgeStatusUndeployed
+
+	if _, err := waitForEdgeReady(client, mockEdgeId, false, 10*time.Second); err != nil {
+		t.Fatalf("Waiting for edge failed with error: %s", err)
+	}
+	if _, err := waitForEdgeReady(client, mockEdgeId, true, 200*time.Millisecond); err == nil {
+		t.Fatalf("Undeployed edge reported as deployed")
+	}
+}
+
+func TestAccNsxWait_EdgeDeleted(t *testing.T) {
+
+	_, client := testAccWaitClient(t)
+
+	if err := nsxresource.NewEdge(client).Delete(mockEdgeId); err != nil {
+		t.Fatalf("Edge Delete failed with error: %s", err)
+	}
+	if err := waitForEdgeDeleted(client, mockEdgeId, 10*time.Second); err != nil {
+		t.Fatalf("Waiting for edge deletion failed with error: %s", err)
+	}
+}
+
+func TestAccNsxWait_RetryWhileInUse(t *testing.T) {
+
+	calls := 0
+	err := retryWhileInUse(10*time.Second, func() error {
+		calls++
+		if calls < 3 {
+			return &nsxAPIError{StatusCode: 400, Body: "<error><details>MAC Set is in use</details></error>"}
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("Expected success after 3 calls, got %d calls and error %v", calls, err)
+	}
+
+	calls = 0
+	notFound := &nsxAPIError{StatusCode: 404}
+	err = retryWhileInUse(10*time.Second, func() error {
+		calls++
+		return notFound
+	})
+	if !isNotFoundError(err) || calls != 1 {
+		t.Fatalf("Expected a single call returning not found, got %d calls and error %v", calls, err)
+	}
+
+	if isInUseError(errors.New("Invalid IP address")) {
+		t.Fatalf("Unexpected in use error")
+	}
+}